@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/bernard-sh/tfs/internal/planfile"
+	"github.com/bernard-sh/tfs/internal/source"
+	"github.com/bernard-sh/tfs/internal/ui"
+)
+
+// allowApply enables the apply action of the TUI.
+var allowApply bool
+
+// applyForPlan returns the TUI apply action for a saved plan file, exiting if the
+// location can't be applied.
+func applyForPlan(location string) ui.ApplyFunc {
+	path, ok := source.LocalPath(location)
+	if !ok {
+		log.Fatalf("--allow-apply needs a plan file on disk, got %s", location)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("Failed to read plan: %v", err)
+	}
+	if !planfile.IsPlanFile(data) {
+		log.Fatalf("--allow-apply needs a binary plan file (terraform plan -out), %s is JSON", path)
+	}
+
+	dir := planSource.Dir
+	if dir == "" {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		dir = filepath.Dir(abs)
+	}
+	return applier(binaryFor(dir), dir, path)
+}
+
+// applier runs `<bin> apply` on the saved plan, for the TUI apply action. Input is
+// disabled since the TUI owns the terminal.
+func applier(bin source.Kind, dir, planPath string) ui.ApplyFunc {
+	return func(w io.Writer) error {
+		abs, err := filepath.Abs(planPath)
+		if err != nil {
+			return err
+		}
+		c := exec.Command(string(bin), "apply", "-input=false", abs)
+		c.Dir = dir
+		c.Env = append(os.Environ(), planSource.Env...)
+		c.Stdout = w
+		c.Stderr = w
+		return c.Run()
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+	"github.com/bernard-sh/tfs/internal/comment"
+	"github.com/bernard-sh/tfs/internal/markdown"
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+var (
+	commentProvider  string
+	commentNumber    int
+	commentMarker    string
+	commentReportURL string
+)
+
+var commentCmd = &cobra.Command{
+	Use:   "comment <plan.binary>",
+	Short: "Post the plan summary on a GitHub pull request or GitLab merge request",
+	Long: `Creates or updates a single sticky comment containing the plan summary and report link.
+Re-runs update the same comment instead of posting a new one.
+
+The token, repository and pull/merge request number are read from the CI environment:
+  github: GITHUB_TOKEN, GITHUB_REPOSITORY, GITHUB_REF (GITHUB_API_URL for Enterprise)
+  gitlab: GITLAB_TOKEN, CI_PROJECT_ID, CI_MERGE_REQUEST_IID, CI_API_V4_URL`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		plan := loadPlan(args[0])
+		postComment(plan, commentReportURL)
+	},
+}
+
+// postComment upserts the sticky comment for plan, linking to reportURL if set.
+func postComment(plan models.TfPlan, reportURL string) {
+	poster, err := comment.FromEnv(commentProvider, commentNumber)
+	if err != nil {
+		log.Fatalf("Failed to configure comment: %v", err)
+	}
+
+	// GitLab allows much longer notes, but GitHub's limit keeps comments readable on both
+	body := markdown.Generate(plan, markdown.Options{ReportURL: reportURL})
+
+	url, err := poster.Upsert(context.Background(), commentMarker, body)
+	if err != nil {
+		log.Fatalf("Failed to post comment: %v", err)
+	}
+	fmt.Printf("💬 Comment posted: %s\n", url)
+}
+
+// addCommentFlags registers the flags shared by every command able to post a comment.
+func addCommentFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&commentProvider, "provider", "", "Code host to comment on: github or gitlab (default: detected from the CI environment)")
+	cmd.Flags().IntVar(&commentNumber, "pr", 0, "Pull/merge request number (default: read from the CI environment)")
+	cmd.Flags().StringVar(&commentMarker, "marker", comment.DefaultMarker, "Hidden marker identifying the sticky comment, set one per stack to keep separate comments")
+}
+
+func init() {
+	rootCmd.AddCommand(commentCmd)
+
+	addCommentFlags(commentCmd)
+	commentCmd.Flags().StringVar(&commentReportURL, "report-url", "", "URL of the full HTML report to link to")
+}
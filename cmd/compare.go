@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/bernard-sh/tfs/internal/compare"
+	"github.com/bernard-sh/tfs/internal/ui"
+	"github.com/bernard-sh/tfs/internal/web"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+)
+
+var compareHTML string
+
+var compareCmd = &cobra.Command{
+	Use:   "compare <old.plan> <new.plan>",
+	Short: "Show what changed between two plans",
+	Long: `Compares the change sets of two plans, e.g. before and after a pull request update:
+resources newly added to or removed from the change set, resources whose action
+changed, and resources whose planned after-values differ.
+
+Opens in the TUI, or writes an HTML report with --html.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		oldPlan := loadPlan(args[0])
+		newPlan := loadPlan(args[1])
+
+		result := compare.Compare(oldPlan, newPlan)
+
+		if compareHTML != "" {
+			if err := web.GenerateCompareHTML(result, args[0], args[1], compareHTML); err != nil {
+				log.Fatalf("Failed to generate HTML: %v", err)
+			}
+			fmt.Printf("✅ Generated %s\n", compareHTML)
+			return
+		}
+
+		opts := []tea.ProgramOption{tea.WithAltScreen()}
+		if readsStdin(args) {
+			opts = append(opts, tea.WithInputTTY())
+		}
+		p := tea.NewProgram(ui.CompareModel(result), opts...)
+		if _, err := p.Run(); err != nil {
+			fmt.Printf("Display error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(compareCmd)
+
+	compareCmd.Flags().StringVar(&compareHTML, "html", "", "Write an HTML report to this path instead of opening the TUI")
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/bernard-sh/tfs/internal/graph"
+	"github.com/spf13/cobra"
+)
+
+var (
+	graphFormat string
+	graphAll    bool
+)
+
+var graphCmd = &cobra.Command{
+	Use:   "graph <plan.binary>",
+	Short: "Print the dependency graph of the changed resources",
+	Long: `Prints the dependencies between the resources of the plan, from the references of
+its configuration and the depends_on recorded in the prior state, so cascades stand
+out: replacing a subnet replaces every instance depending on it.
+
+Nodes are colored by action. Unchanged resources are collapsed: changed resources
+depending on each other through them are linked by a dashed (DOT) or dotted
+(Mermaid) edge. Use --all to keep them.
+
+  tfs graph plan | dot -Tsvg > graph.svg
+  tfs graph --format mermaid plan    # For Markdown, e.g. a pull request comment
+
+Dependencies come from the plan's configuration, so binary plans are read through
+the show command of the detected binary. When it fails, only the dependencies
+recorded in the prior state are shown.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		planSource.Config = true
+		g := graph.Build(loadPlan(args[0]))
+		if !graphAll {
+			g = g.Collapse()
+		}
+
+		var err error
+		switch graphFormat {
+		case "dot":
+			err = graph.WriteDOT(os.Stdout, g)
+		case "mermaid":
+			err = graph.WriteMermaid(os.Stdout, g)
+		default:
+			log.Fatalf("Invalid --format value %q (expected dot or mermaid)", graphFormat)
+		}
+		if err != nil {
+			log.Fatalf("Failed to write graph: %v", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(graphCmd)
+
+	graphCmd.Flags().StringVar(&graphFormat, "format", "dot", "Output format: dot or mermaid")
+	graphCmd.Flags().BoolVar(&graphAll, "all", false, "Keep the unchanged resources")
+}
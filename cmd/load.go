@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bernard-sh/tfs/internal/models"
+	"github.com/bernard-sh/tfs/internal/source"
+	"github.com/bernard-sh/tfs/internal/stack"
+	"github.com/bernard-sh/tfs/internal/uploader"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// planSource is how plan files are read, set by the persistent source flags.
+	planSource source.Source
+	sourceKind string
+)
+
+// planPatterns are the file names looked for when a directory is given instead of a plan.
+var planPatterns []string
+
+// readPlan returns the parsed plan for filename, read as selected by --source.
+func readPlan(filename string) (models.TfPlan, error) {
+	kind, err := source.ParseKind(sourceKind)
+	if err != nil {
+		return models.TfPlan{}, err
+	}
+	planSource.Kind = kind
+	return planSource.Load(filename)
+}
+
+// loadPlan is readPlan for commands that exit on failure.
+func loadPlan(filename string) models.TfPlan {
+	plan, err := readPlan(filename)
+	if err != nil {
+		log.Fatalf("Failed to load plan: %v", err)
+	}
+	return plan
+}
+
+// remoteClient returns the bucket client reading s3:// and gs:// plan URLs.
+func remoteClient(ctx context.Context, scheme string) (source.Downloader, error) {
+	switch scheme {
+	case "s3":
+		u, err := uploader.NewS3Uploader(ctx, region)
+		if err != nil {
+			return nil, err
+		}
+		return u, nil
+	case "gs":
+		u, err := uploader.NewGCSUploader(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return u, nil
+	}
+	return nil, fmt.Errorf("unsupported scheme %q", scheme)
+}
+
+// readsStdin reports whether any plan argument is read from stdin, in which case
+// the TUI must take its keyboard input from the terminal instead.
+func readsStdin(args []string) bool {
+	for _, arg := range args {
+		if arg == source.StdinLocation {
+			return true
+		}
+	}
+	return false
+}
+
+// loadStacks expands the plan arguments (files, globs or directories) and loads
+// every plan, labelled by its stack path.
+func loadStacks(args []string) []stack.Stack {
+	files, err := stack.Discover(args, planPatterns)
+	if err != nil {
+		log.Fatalf("Failed to find plans: %v", err)
+	}
+
+	labels := stack.Labels(files)
+	stacks := make([]stack.Stack, 0, len(files))
+	for i, f := range files {
+		plan, err := readPlan(f)
+		if err != nil {
+			log.Fatalf("Failed to load plan %s: %v", f, err)
+		}
+		stacks = append(stacks, stack.Stack{Label: labels[i], Path: f, Dir: planSource.ConfigDir(f), Plan: plan})
+	}
+	return stacks
+}
+
+// addStackFlags registers the flags of commands accepting several plans.
+func addStackFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&planPatterns, "plan-pattern", stack.DefaultPatterns, "File name patterns matched when scanning directories for plans")
+}
+
+func init() {
+	kinds := make([]string, len(source.Kinds))
+	for i, k := range source.Kinds {
+		kinds[i] = string(k)
+	}
+
+	planSource.Remote = remoteClient
+	planSource.Warn = func(msg string) { log.Printf("Warning: %s", msg) }
+
+	flags := rootCmd.PersistentFlags()
+	flags.StringVar(&sourceKind, "source", string(source.Auto), "How plan files are read: "+strings.Join(kinds, ", "))
+	flags.StringVar(&planSource.Dir, "workdir", "", "Working directory of the show command (default: the plan's directory)")
+	flags.StringArrayVar(&planSource.Env, "source-env", nil, "Extra KEY=VALUE environment for the show command (repeatable)")
+	flags.StringArrayVar(&planSource.Args, "source-arg", nil, "Extra argument for the show command, e.g. -no-color (repeatable)")
+}
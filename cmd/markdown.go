@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/bernard-sh/tfs/internal/markdown"
+)
+
+var (
+	markdownOutput    string
+	markdownReportURL string
+	markdownMaxLength int
+)
+
+var markdownCmd = &cobra.Command{
+	Use:   "markdown <plan.binary>",
+	Short: "Generate a Markdown report for pull request comments",
+	Long: `Generates a Markdown report of the terraform plan: a table of counts per action and a
+collapsible diff per resource. The report is truncated to fit comment size limits,
+linking to the full HTML report when --report-url is set.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		plan := loadPlan(args[0])
+
+		md := markdown.Generate(plan, markdown.Options{
+			ReportURL: markdownReportURL,
+			MaxLength: markdownMaxLength,
+		})
+
+		if markdownOutput == "" || markdownOutput == "-" {
+			fmt.Print(md)
+			return
+		}
+		if err := os.WriteFile(markdownOutput, []byte(md), 0644); err != nil {
+			log.Fatalf("Failed to write Markdown: %v", err)
+		}
+		fmt.Printf("✅ Generated %s\n", markdownOutput)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(markdownCmd)
+
+	markdownCmd.Flags().StringVarP(&markdownOutput, "output", "o", "", "File to write the report to (default stdout)")
+	markdownCmd.Flags().StringVar(&markdownReportURL, "report-url", "", "URL of the full HTML report to link to")
+	markdownCmd.Flags().IntVar(&markdownMaxLength, "max-length", markdown.DefaultMaxLength, "Maximum report length in characters (negative disables truncation)")
+}
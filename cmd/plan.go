@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/bernard-sh/tfs/internal/models"
+	"github.com/bernard-sh/tfs/internal/source"
+	"github.com/bernard-sh/tfs/internal/ui"
+	"github.com/bernard-sh/tfs/internal/web"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+)
+
+var (
+	planOut  string
+	planHTML string
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan [flags] [-- terraform args...]",
+	Short: "Run terraform plan and open the result",
+	Long: `Runs terraform plan in the current directory (or --workdir), streaming its output,
+then opens the plan in the TUI, or writes an HTML report with --html.
+
+The binary plan is kept in --out and its ` + "`show -json`" + ` output next to it (tfplan.json).
+The binary is the one given with --source, or detected from the project.
+
+With --allow-apply, the TUI can apply the plan once every high-risk resource has
+been marked as reviewed.
+
+Arguments after -- are passed to plan as-is. With -detailed-exitcode, tfs exits with
+the plan's code: 0 for no changes, 2 for changes, 1 for errors.
+
+Example:
+  tfs plan -- -var-file=prod.tfvars -detailed-exitcode`,
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		bin := binaryFor(planDir())
+		out := planOutPath(args)
+
+		// 1. Plan, streaming the output
+		code, err := runPlan(bin, out, args)
+		if err != nil {
+			log.Fatalf("Failed to run %s plan: %v", bin, err)
+		}
+		if code != 0 && code != 2 {
+			os.Exit(code)
+		}
+
+		// 2. Capture the JSON next to the binary plan
+		jsonContent, err := planSource.Show(bin, out)
+		if err != nil {
+			log.Fatalf("Failed to retrieve plan JSON: %v", err)
+		}
+		jsonPath := out + ".json"
+		if err := os.WriteFile(jsonPath, jsonContent, 0o644); err != nil {
+			log.Fatalf("Failed to write %s: %v", jsonPath, err)
+		}
+		plan, err := models.ParsePlan(string(jsonContent))
+		if err != nil {
+			log.Fatalf("Failed to parse plan JSON: %v", err)
+		}
+		// Key review progress by the binary plan, as when it is opened later
+		if data, err := os.ReadFile(out); err == nil {
+			plan.Meta.InputHash = models.HashInput(data)
+		}
+
+		// 3. Report
+		if planHTML != "" {
+			if err := web.GenerateHTML(plan, planHTML); err != nil {
+				log.Fatalf("Failed to generate HTML: %v", err)
+			}
+			fmt.Printf("✅ Generated %s\n", planHTML)
+		} else {
+			opts := ui.Options{Reviews: reviewStore(), ConfigDir: planDir()}
+			if allowApply {
+				opts.Apply = applier(bin, planDir(), out)
+			}
+			p := tea.NewProgram(ui.PlanModel(plan, opts), tea.WithAltScreen())
+			if _, err := p.Run(); err != nil {
+				fmt.Printf("Display error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		if hasFlag(args, "detailed-exitcode") {
+			os.Exit(code)
+		}
+	},
+}
+
+// binaryFor is the binary running plans and applies in dir: the one selected with
+// --source, or the one detected from the project.
+func binaryFor(dir string) source.Kind {
+	kind, err := source.ParseKind(sourceKind)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if kind.IsBinary() {
+		return kind
+	}
+	return source.Detect(dir)
+}
+
+// planDir is the directory the plan runs in.
+func planDir() string {
+	if planSource.Dir != "" {
+		return planSource.Dir
+	}
+	return "."
+}
+
+// planOutPath is the binary plan file: an -out given in the plan arguments, or --out,
+// relative to the plan's directory.
+func planOutPath(args []string) string {
+	out := planOut
+	if v, ok := flagValue(args, "out"); ok {
+		out = v
+	}
+	if !filepath.IsAbs(out) {
+		out = filepath.Join(planDir(), out)
+	}
+	return out
+}
+
+// runPlan runs `<bin> plan -out=<out> args...` with the terminal attached and
+// returns its exit code.
+func runPlan(bin source.Kind, out string, args []string) (int, error) {
+	planArgs := []string{"plan"}
+	if !hasFlag(args, "out") {
+		abs, err := filepath.Abs(out)
+		if err != nil {
+			return 0, err
+		}
+		planArgs = append(planArgs, "-out="+abs)
+	}
+	planArgs = append(planArgs, args...)
+
+	c := exec.Command(string(bin), planArgs...)
+	c.Dir = planDir()
+	c.Env = append(os.Environ(), planSource.Env...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+
+	err := c.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	return 0, err
+}
+
+// hasFlag reports whether the terraform arguments set the flag, in its -name,
+// --name or -name=value forms.
+func hasFlag(args []string, name string) bool {
+	for _, arg := range args {
+		if flagName(arg) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// flagValue returns the value of the last occurrence of a flag in the terraform
+// arguments, given as -name=value or -name value, with one or two dashes.
+func flagValue(args []string, name string) (string, bool) {
+	value, found := "", false
+	for i, arg := range args {
+		if flagName(arg) != name {
+			continue
+		}
+		if _, v, ok := strings.Cut(arg, "="); ok {
+			value, found = v, true
+		} else if i+1 < len(args) {
+			value, found = args[i+1], true
+		}
+	}
+	return value, found
+}
+
+// flagName is the name of the flag arg sets, "" when it is not a flag.
+func flagName(arg string) string {
+	if !strings.HasPrefix(arg, "-") {
+		return ""
+	}
+	name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+	return name
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+
+	planCmd.Flags().StringVar(&planOut, "out", "tfplan", "Binary plan file to write, relative to the working directory")
+	planCmd.Flags().StringVar(&planHTML, "html", "", "Write an HTML report to this path instead of opening the TUI")
+	planCmd.Flags().BoolVar(&allowApply, "allow-apply", false, "Enable applying the plan from the TUI ([a]) after review")
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPlanOutPath(t *testing.T) {
+	planOut, planSource.Dir = "tfplan", "work"
+	t.Cleanup(func() { planOut, planSource.Dir = "tfplan", "" })
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{nil, filepath.Join("work", "tfplan")},
+		{[]string{"-out=a.tfplan"}, filepath.Join("work", "a.tfplan")},
+		{[]string{"--out=a.tfplan"}, filepath.Join("work", "a.tfplan")},
+		{[]string{"-out", "myplan"}, filepath.Join("work", "myplan")},
+		{[]string{"--out", "myplan"}, filepath.Join("work", "myplan")},
+		{[]string{"-var", "region=eu-west-1", "-out", "myplan", "-lock=false"}, filepath.Join("work", "myplan")},
+		{[]string{"-out", "/tmp/myplan"}, "/tmp/myplan"},
+		{[]string{"-var", "out=x"}, filepath.Join("work", "tfplan")},
+	}
+	for _, tt := range tests {
+		if got := planOutPath(tt.args); got != tt.expected {
+			t.Errorf("planOutPath(%q) = %q; want %q", tt.args, got, tt.expected)
+		}
+	}
+}
+
+func TestHasFlag(t *testing.T) {
+	tests := []struct {
+		args     []string
+		name     string
+		expected bool
+	}{
+		{[]string{"-out=plan"}, "out", true},
+		{[]string{"-out", "plan"}, "out", true},
+		{[]string{"--out", "plan"}, "out", true},
+		{[]string{"-var", "out=x"}, "out", false},
+		{[]string{"-outfile=x"}, "out", false},
+		{[]string{"-detailed-exitcode"}, "detailed-exitcode", true},
+		{nil, "detailed-exitcode", false},
+	}
+	for _, tt := range tests {
+		if got := hasFlag(tt.args, tt.name); got != tt.expected {
+			t.Errorf("hasFlag(%q, %q) = %v; want %v", tt.args, tt.name, got, tt.expected)
+		}
+	}
+}
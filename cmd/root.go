@@ -10,7 +10,10 @@ import (
 var rootCmd = &cobra.Command{
 	Use:   "tfs",
 	Short: "Terraform Plan Analyzer",
-	Long:  `A CLI tool to analyze Terraform plans. Use subcommands to generate reports or view in TUI.`,
+	Long: `A CLI tool to analyze Terraform plans. Use subcommands to generate reports or view in TUI.
+
+Plans are binary plan files or ` + "`terraform show -json`" + ` output, given as a path, a
+file://, s3:// or gs:// URL, or "-" to read from stdin.`,
 }
 
 func Execute() {
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/bernard-sh/tfs/internal/models"
+	"github.com/bernard-sh/tfs/internal/server"
+	"github.com/bernard-sh/tfs/internal/source"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddr string
+	serveOpen bool
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve <plan.binary>",
+	Short: "Serve the HTML report on a local HTTP server",
+	Long: `Starts a local HTTP server hosting the report, with a JSON API for the plan data
+(/api/plan, /api/summary). The report reloads itself when the plan file changes.
+
+The server listens on localhost by default; from a remote machine, forward the port
+(e.g. ssh -L 8080:localhost:8080 devbox) instead of exposing it.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		filename := args[0]
+		planSource.Config = true
+
+		srv, err := server.New(func() (models.TfPlan, error) {
+			return readPlan(filename)
+		})
+		if err != nil {
+			log.Fatalf("Failed to load plan: %v", err)
+		}
+
+		listener, err := net.Listen("tcp", serveAddr)
+		if err != nil {
+			log.Fatalf("Failed to listen on %s: %v", serveAddr, err)
+		}
+		url := fmt.Sprintf("http://%s/", listener.Addr())
+		fmt.Printf("🌐 Serving %s on %s (Ctrl+C to stop)\n", filename, url)
+
+		// Only files can be watched; stdin and bucket URLs are served as first read
+		if path, ok := source.LocalPath(filename); ok {
+			go srv.Watch(context.Background(), path, time.Second)
+		}
+
+		if serveOpen {
+			if err := openBrowser(url); err != nil {
+				log.Printf("Failed to open browser: %v", err)
+			}
+		}
+
+		if err := http.Serve(listener, srv.Handler(serveAddr)); err != nil {
+			log.Fatalf("Server error: %v", err)
+		}
+	},
+}
+
+// openBrowser opens url with the platform's default handler.
+func openBrowser(url string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	default:
+		return exec.Command("xdg-open", url).Start()
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:8080", "Address to listen on")
+	serveCmd.Flags().BoolVar(&serveOpen, "open", false, "Open the report in the default browser")
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/bernard-sh/tfs/internal/ui"
+)
+
+var showColor string
+
+var showCmd = &cobra.Command{
+	Use:   "show <plan.binary>",
+	Short: "Print the categorised plan to stdout",
+	Long: `Prints the plan counts followed by every resource diff, grouped by category, without
+requiring a terminal. Suitable for CI logs and pagers (e.g. tfs show --color always plan | less -R).`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		profile, ok := colorProfile(showColor)
+		if !ok {
+			log.Fatalf("Invalid --color value %q (expected auto, always or never)", showColor)
+		}
+		lipgloss.SetColorProfile(profile)
+
+		plan := loadPlan(args[0])
+		fmt.Print(ui.RenderPlan(plan))
+	},
+}
+
+// colorProfile is the lipgloss colour profile selected by --color. It returns false
+// for an unknown mode.
+func colorProfile(mode string) (termenv.Profile, bool) {
+	switch mode {
+	case "auto":
+		// Colours only when stdout is a terminal, honouring NO_COLOR / CLICOLOR
+		return termenv.NewOutput(os.Stdout).EnvColorProfile(), true
+	case "always":
+		return termenv.ANSI256, true
+	case "never":
+		return termenv.Ascii, true
+	default:
+		return termenv.Ascii, false
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(showCmd)
+
+	showCmd.Flags().StringVar(&showColor, "color", "auto", "Colorize the output: auto, always or never")
+}
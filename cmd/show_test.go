@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bernard-sh/tfs/internal/models"
+	"github.com/bernard-sh/tfs/internal/ui"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestColorProfile(t *testing.T) {
+	previous := lipgloss.ColorProfile()
+	t.Cleanup(func() { lipgloss.SetColorProfile(previous) })
+
+	plan := models.TfPlan{ResourceChanges: []models.ResourceChange{
+		{Address: "res.a", Type: "res", Name: "a", Change: models.Change{Actions: []string{"create"}}},
+	}}
+	tests := []struct {
+		mode string
+		ansi bool
+	}{
+		{"never", false},
+		{"always", true},
+	}
+	for _, tt := range tests {
+		profile, ok := colorProfile(tt.mode)
+		if !ok {
+			t.Fatalf("colorProfile(%q) rejected a valid mode", tt.mode)
+		}
+		lipgloss.SetColorProfile(profile)
+		out := ui.RenderPlan(plan)
+		if got := strings.Contains(out, "\x1b["); got != tt.ansi {
+			t.Errorf("--color=%s: output has ANSI escapes = %v; want %v:\n%q", tt.mode, got, tt.ansi, out)
+		}
+	}
+
+	if _, ok := colorProfile("sometimes"); ok {
+		t.Errorf("Expected an error for an invalid --color value")
+	}
+}
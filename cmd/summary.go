@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/bernard-sh/tfs/internal/findings"
+	"github.com/bernard-sh/tfs/internal/summary"
+	"github.com/spf13/cobra"
+)
+
+var (
+	destroyThreshold int
+	replaceThreshold int
+	summaryFormat    string
+	configDir        string
+	repoRoot         string
+)
+
+var summaryCmd = &cobra.Command{
+	Use:   "summary <plan.binary>",
+	Short: "Print a plan summary and exit with a code describing its content",
+	Long: `Prints the number of changes per category and exits with:
+  0  no changes
+  1  error
+  2  only additive changes (create, update, import)
+  3  destroys or replaces above the configured thresholds
+
+Intended for CI, e.g. to require an extra approval on destructive plans.
+
+With --format json, prints a versioned machine-readable report instead: counts per
+category and every changed resource with its category, module, provider, risk score
+and changed attribute paths.
+
+With --format junit or sarif, prints the risk rule results for CI dashboards and
+code-scanning UIs, pointing at the resource declarations found under --config-dir.
+Their paths are relative to --repo-root, by default the git repository holding
+--config-dir, so code scanning can place them.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// Locating declarations needs the configuration
+		planSource.Config = summaryFormat == "junit" || summaryFormat == "sarif"
+		plan := loadPlan(args[0])
+
+		s := summary.New(plan)
+		switch summaryFormat {
+		case "text":
+			fmt.Println(s.String())
+		case "json":
+			if err := summary.NewReport(plan).WriteJSON(os.Stdout); err != nil {
+				log.Fatalf("Failed to write JSON summary: %v", err)
+			}
+		case "junit":
+			if err := findings.WriteJUnit(os.Stdout, findings.Evaluate(plan, configDir, findingsRoot())); err != nil {
+				log.Fatalf("Failed to write JUnit report: %v", err)
+			}
+		case "sarif":
+			if err := findings.WriteSARIF(os.Stdout, findings.Evaluate(plan, configDir, findingsRoot())); err != nil {
+				log.Fatalf("Failed to write SARIF report: %v", err)
+			}
+		default:
+			log.Fatalf("Invalid --format value %q (expected text, json, junit or sarif)", summaryFormat)
+		}
+
+		os.Exit(s.ExitCode(summary.Thresholds{
+			Destroy: destroyThreshold,
+			Replace: replaceThreshold,
+		}))
+	},
+}
+
+// findingsRoot is the directory finding locations are relative to: --repo-root, else
+// the git repository holding --config-dir, else --config-dir itself.
+func findingsRoot() string {
+	if repoRoot != "" {
+		return repoRoot
+	}
+	dir, err := filepath.Abs(configDir)
+	if err != nil {
+		return configDir
+	}
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d
+		}
+		if filepath.Dir(d) == d {
+			return configDir
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(summaryCmd)
+
+	summaryCmd.Flags().StringVar(&summaryFormat, "format", "text", "Output format: text, json, junit or sarif")
+	summaryCmd.Flags().StringVar(&configDir, "config-dir", ".", "Root module directory, used to locate resource declarations")
+	summaryCmd.Flags().StringVar(&repoRoot, "repo-root", "", "Directory the JUnit and SARIF file paths are relative to (default: the git repository holding --config-dir)")
+	summaryCmd.Flags().IntVar(&destroyThreshold, "destroy-threshold", 0, "Number of destroys tolerated before exiting with the destructive code")
+	summaryCmd.Flags().IntVar(&replaceThreshold, "replace-threshold", 0, "Number of replaces tolerated before exiting with the destructive code")
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindingsRoot(t *testing.T) {
+	repo := t.TempDir()
+	infra := filepath.Join(repo, "envs", "prod")
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(infra, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { configDir, repoRoot = ".", "" })
+
+	configDir, repoRoot = infra, ""
+	if got := findingsRoot(); got != repo {
+		t.Errorf("findingsRoot() = %q; want the git repository %q", got, repo)
+	}
+	repoRoot = filepath.Join(repo, "envs")
+	if got := findingsRoot(); got != repoRoot {
+		t.Errorf("findingsRoot() = %q; want --repo-root %q", got, repoRoot)
+	}
+}
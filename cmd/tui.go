@@ -1,62 +1,64 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
-	"strings"
 	"log"
 	"os"
-	"os/exec"
 
 	"github.com/spf13/cobra"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/bernard-sh/tfs/internal/review"
 	"github.com/bernard-sh/tfs/internal/ui"
 )
 
 var tuiCmd = &cobra.Command{
-	Use:   "tui <plan.binary>",
+	Use:   "tui <plan.binary>...",
 	Short: "Show terraform plan on TUI mode",
-	Long:  `Show terraform plan on TUI mode`,
-	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {		
-		filename := args[0]
+	Long: `Show terraform plan on TUI mode.
 
-		// Validations
-		if _, err := os.Stat(filename); os.IsNotExist(err) {
-			log.Fatalf("File does not exist: %s", filename)
-		}
+Several plans (files, glob patterns or directories to scan) open an index of the
+stacks first, with per-stack counts and risk.
 
-		// Get JSON Content
-		// Try terraform show -json first
-		tfCmd := exec.Command("terraform", "show", "-json", filename)
-		output, err := tfCmd.Output()
-		if err != nil {
-			// Fallback: Read file directly if it's already JSON
-			raw, readErr := os.ReadFile(filename)
-			if readErr != nil {
-				log.Fatalf("Failed to run terraform output: %v, and failed to read file: %v", err, readErr)
-			}
-			output = raw
-		}
-		
-		jsonContent := string(output)
+With --allow-apply, a binary plan file can be applied from the TUI ([a]) once every
+high-risk resource has been marked as reviewed ([Space]). The apply output is
+streamed into the TUI.
 
-		// 2. Parse
-		var plan ui.TfPlan
-		dec := json.NewDecoder(strings.NewReader(jsonContent))
-		dec.UseNumber()
-		if err := dec.Decode(&plan); err != nil {
-			log.Fatalf("Failed to parse plan JSON: %v", err)
-		}
+The detail view shows where each resource is declared: its module, the expressions
+of its attributes and, when the configuration files are found next to the plan, the
+file and line. [e] opens the declaration in $VISUAL or $EDITOR.
+
+A status line above the tabs shows the Terraform and provider versions, workspace,
+variables and time of the plan, and warns about errored or incomplete plans. [i]
+opens the full plan info.
 
-		// 3. Start TUI
+Review marks are saved per plan (by content hash) in the user cache directory, so
+reopening the same plan resumes the review.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// 1. Load the plan(s), with their configuration for dependencies and declarations
+		planSource.Config = true
+		stacks := loadStacks(args)
 
-		model, err := ui.InitialModel(jsonContent)
-		if err != nil {
-			log.Fatalf("Error initializing model: %v\nPossible causes:\n1. Input is not valid JSON and 'terraform show -json' failed.\n2. JSON structure mismatch.", err)
+		// 2. Start TUI
+		var model tea.Model
+		if allowApply && len(stacks) > 1 {
+			log.Fatalf("--allow-apply supports a single plan, got %d", len(stacks))
+		}
+		if len(stacks) > 1 {
+			model = ui.InitialDashboard(stacks, reviewStore())
+		} else {
+			opts := ui.Options{Reviews: reviewStore(), ConfigDir: stacks[0].Dir}
+			if allowApply {
+				opts.Apply = applyForPlan(stacks[0].Path)
+			}
+			model = ui.PlanModel(stacks[0].Plan, opts)
 		}
 
-		p := tea.NewProgram(model, tea.WithAltScreen())
+		opts := []tea.ProgramOption{tea.WithAltScreen()}
+		if readsStdin(args) {
+			opts = append(opts, tea.WithInputTTY())
+		}
+		p := tea.NewProgram(model, opts...)
 		if _, err := p.Run(); err != nil {
 			fmt.Printf("Display error: %v\n", err)
 			os.Exit(1)
@@ -64,6 +66,20 @@ var tuiCmd = &cobra.Command{
 	},
 }
 
+// reviewStore is where the TUI saves review progress, keyed by plan hash; nil
+// (not saved) when there is no cache directory.
+func reviewStore() ui.ReviewStore {
+	store, err := review.DefaultStore()
+	if err != nil {
+		log.Printf("Review progress won't be saved: %v", err)
+		return nil
+	}
+	return store
+}
+
 func init() {
 	rootCmd.AddCommand(tuiCmd)
+
+	addStackFlags(tuiCmd)
+	tuiCmd.Flags().BoolVar(&allowApply, "allow-apply", false, "Enable applying the plan from the TUI ([a]) after review")
 }
\ No newline at end of file
@@ -1,25 +1,22 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
-	"os/exec"
-	"strings"
+	"path/filepath"
 	"time"
 
 	"github.com/spf13/cobra"
-	"github.com/bernard-sh/tfs/internal/web"
+	"github.com/bernard-sh/tfs/internal/models"
+	"github.com/bernard-sh/tfs/internal/output"
+	"github.com/bernard-sh/tfs/internal/risk"
+	"github.com/bernard-sh/tfs/internal/stack"
 	"github.com/bernard-sh/tfs/internal/uploader"
-	
-	// Duplicate struct definition or import from ui?
-	// Need to parse JSON into struct for web renderer.
-	// web.GenerateHTML takes interface{}, so we need a struct that matches JSON.
-	// We can reuse ui.TfPlan if exported or redefine local one.
-	// Reusing ui.TfPlan requires importing "github.com/bernard-sh/tfs/internal/ui" which is fine.
-	"github.com/bernard-sh/tfs/internal/ui"
+	"github.com/bernard-sh/tfs/internal/web"
 )
 
 var (
@@ -27,82 +24,171 @@ var (
 	gcsBucket  string
 	region     string
 	expiration time.Duration
+	webComment bool
+	webOutput  string
+	webName    string
+	webFormats []string
 )
 
 var webCmd = &cobra.Command{
-	Use:   "web <plan.binary>",
+	Use:   "web <plan.binary>...",
 	Short: "Generate HTML report",
-	Long:  `Generates a static HTML report of the terraform plan. Optionally upload to S3 or GCS.`,
-	Args:  cobra.ExactArgs(1),
+	Long: `Generates a static HTML report of the terraform plan. Optionally upload to S3 or GCS.
+
+Several plans (files, glob patterns or directories to scan) produce a single report
+opening on an index of the stacks.
+
+After uploading, a deep link into the report is printed for every high-risk resource.
+Each tab and resource of the report has its own link (#replace, #module.db.aws_rds_cluster.main).
+
+--format html,markdown,json writes several reports in one run, the Markdown one
+linking to the uploaded HTML report. --output is a file, a directory or - for stdout.
+
+--name names the files written into an --output directory and the uploaded objects.
+It may contain slashes and these placeholders:
+  {workspace}  Terraform workspace
+  {branch}     git branch (from the CI environment, or git)
+  {sha}        commit SHA, 12 characters
+  {hash}       plan hash, the key of the review progress
+  {timestamp}  Unix time
+
+Example:
+  tfs web tfplan --format html,markdown --output reports/ --name "{branch}/{sha}-{workspace}"`,
+	Args: cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		filename := args[0]
-		
-		// 1. Get JSON
-		if _, err := os.Stat(filename); os.IsNotExist(err) {
-			log.Fatalf("File does not exist: %s", filename)
+		// 1. Get JSON & Parse, with the configuration for dependencies and declarations
+		planSource.Config = true
+		stacks := loadStacks(args)
+		if webComment && len(stacks) > 1 {
+			log.Fatalf("--comment supports a single plan, got %d", len(stacks))
 		}
-		
-		tfCmd := exec.Command("terraform", "show", "-json", filename)
-		output, err := tfCmd.Output()
+
+		formats, err := output.ParseFormats(webFormats)
 		if err != nil {
-			raw, readErr := os.ReadFile(filename)
-			if readErr != nil {
-				log.Fatalf("Failed to retrieve plan JSON: %v", err)
-			}
-			output = raw
+			log.Fatalf("Invalid --format: %v", err)
 		}
-		jsonContent := string(output)
-
-		// 2. Parse
-		var plan ui.TfPlan
-		dec := json.NewDecoder(strings.NewReader(jsonContent))
-		dec.UseNumber()
-		if err := dec.Decode(&plan); err != nil {
-			log.Fatalf("Failed to parse plan JSON: %v", err)
+		if webOutput == output.Stdout && len(formats) > 1 {
+			log.Fatalf("--output - writes a single format, got %d", len(formats))
+		}
+		vars := output.DetectVars(planDir(), stacks, time.Now())
+		name, err := output.Expand(webName, vars)
+		if err != nil {
+			log.Fatalf("Invalid --name: %v", err)
 		}
 
-		// 3. Generate HTML
-		// Use absolute path for safety or just current dir
-		outputPath := "tfs.html"
-		if err := web.GenerateHTML(plan, outputPath); err != nil {
-			log.Fatalf("Failed to generate HTML: %v", err)
+		// Progress goes to stderr when the report itself goes to stdout
+		var status io.Writer = os.Stdout
+		if webOutput == output.Stdout {
+			status = os.Stderr
 		}
-		fmt.Printf("✅ Generated %s\n", outputPath)
-		
-		// 4. Upload Logic
+
 		ctx := context.Background()
-		fileKey := fmt.Sprintf("tfs-plan-%d.html", time.Now().Unix())
-		
-		if s3Bucket != "" {
-			fmt.Printf("Uploading to S3 bucket: %s...\n", s3Bucket)
-			u, err := uploader.NewS3Uploader(ctx, region)
-			if err != nil {
-				log.Fatalf("Failed to create S3 uploader: %v", err)
+		opts := output.Options{Workspace: vars.Workspace}
+		for _, f := range formats {
+			// 2. Generate the report, Markdown linking to the HTML uploaded before it
+			var buf bytes.Buffer
+			if err := output.Render(&buf, f, stacks, opts); err != nil {
+				log.Fatalf("Failed to generate %s report: %v", f, err)
 			}
-			
-			url, err := u.UploadAndPresign(ctx, s3Bucket, fileKey, outputPath, expiration)
-			if err != nil {
-				log.Fatalf("S3 Upload failed: %v", err)
-			}
-			fmt.Printf("\n🚀 Presigned URL (Expires in %s):\n%s\n", expiration, url)
-		}
 
-		if gcsBucket != "" {
-			fmt.Printf("Uploading to GCS bucket: %s...\n", gcsBucket)
-			u, err := uploader.NewGCSUploader(ctx)
-			if err != nil {
-				log.Fatalf("Failed to create GCS uploader: %v", err)
+			path := output.Destination(webOutput, name, f, len(formats) == 1)
+			if path == "" {
+				if _, err := os.Stdout.Write(buf.Bytes()); err != nil {
+					log.Fatalf("Failed to write report: %v", err)
+				}
+			} else {
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					log.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+				}
+				if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+					log.Fatalf("Failed to write %s: %v", path, err)
+				}
+				fmt.Fprintf(status, "✅ Generated %s\n", path)
 			}
 
-			url, err := u.UploadAndSign(ctx, gcsBucket, fileKey, outputPath, expiration)
-			if err != nil {
-				log.Fatalf("GCS Upload failed: %v", err)
+			// 3. Upload Logic
+			url := uploadReport(ctx, status, name+f.Ext(), f, buf.Bytes())
+			if f == output.HTML && url != "" {
+				opts.ReportURL = url
+				printHighRiskLinks(status, stacks, url)
 			}
-			fmt.Printf("\n🚀 Signed URL (Expires in %s):\n%s\n", expiration, url)
+		}
+
+		// 4. Pull request comment
+		if webComment {
+			postComment(stacks[0].Plan, opts.ReportURL)
 		}
 	},
 }
 
+// uploadReport stores the report in the --s3-bucket and --gcs-bucket buckets and
+// returns its signed URL, the GCS one when uploading to both.
+func uploadReport(ctx context.Context, status io.Writer, key string, f output.Format, content []byte) string {
+	reportURL := ""
+
+	if s3Bucket != "" {
+		fmt.Fprintf(status, "Uploading %s to S3 bucket: %s...\n", key, s3Bucket)
+		u, err := uploader.NewS3Uploader(ctx, region)
+		if err != nil {
+			log.Fatalf("Failed to create S3 uploader: %v", err)
+		}
+
+		url, err := u.UploadAndPresign(ctx, s3Bucket, key, bytes.NewReader(content), f.ContentType(), expiration)
+		if err != nil {
+			log.Fatalf("S3 Upload failed: %v", err)
+		}
+		fmt.Fprintf(status, "\n🚀 Presigned URL (Expires in %s):\n%s\n", expiration, url)
+		reportURL = url
+	}
+
+	if gcsBucket != "" {
+		fmt.Fprintf(status, "Uploading %s to GCS bucket: %s...\n", key, gcsBucket)
+		u, err := uploader.NewGCSUploader(ctx)
+		if err != nil {
+			log.Fatalf("Failed to create GCS uploader: %v", err)
+		}
+
+		url, err := u.UploadAndSign(ctx, gcsBucket, key, bytes.NewReader(content), f.ContentType(), expiration)
+		if err != nil {
+			log.Fatalf("GCS Upload failed: %v", err)
+		}
+		fmt.Fprintf(status, "\n🚀 Signed URL (Expires in %s):\n%s\n", expiration, url)
+		reportURL = url
+	}
+
+	return reportURL
+}
+
+// printHighRiskLinks lists a deep link into the uploaded report for every high-risk
+// resource, ready to paste into a review discussion.
+func printHighRiskLinks(w io.Writer, stacks []stack.Stack, reportURL string) {
+	header := false
+	for _, st := range stacks {
+		label := ""
+		if len(stacks) > 1 {
+			label = st.Label
+		}
+		for _, rc := range st.Plan.ResourceChanges {
+			if rc.IsNoOp() {
+				continue
+			}
+			a := risk.Assess(rc)
+			if !a.IsHigh() {
+				continue
+			}
+			if !header {
+				fmt.Fprintln(w, "\n⚠️  High-risk resources:")
+				header = true
+			}
+			name := rc.Key()
+			if label != "" {
+				name = label + ": " + name
+			}
+			fmt.Fprintf(w, "  %s (%s, risk %d)\n    %s\n", name, models.Categorize(rc), a.Score, web.DeepLink(reportURL, label, rc.Key()))
+		}
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(webCmd)
 	
@@ -110,4 +196,10 @@ func init() {
 	webCmd.Flags().StringVar(&gcsBucket, "gcs-bucket", "", "GCS Bucket name to upload to")
 	webCmd.Flags().StringVar(&region, "region", "", "AWS Region (optional)")
 	webCmd.Flags().DurationVar(&expiration, "expiration", 15*time.Minute, "Duration for the presigned URL to remain valid")
+	webCmd.Flags().BoolVar(&webComment, "comment", false, "Post the summary and report link as a sticky pull/merge request comment")
+	webCmd.Flags().StringVarP(&webOutput, "output", "o", "", "File, directory or - for stdout (default: tfs.<ext> in the current directory)")
+	webCmd.Flags().StringVar(&webName, "name", output.DefaultName, "Name template of the files written to an --output directory and of uploaded objects")
+	webCmd.Flags().StringSliceVar(&webFormats, "format", []string{string(output.HTML)}, "Report formats: html, markdown, json (comma-separated)")
+	addCommentFlags(webCmd)
+	addStackFlags(webCmd)
 }
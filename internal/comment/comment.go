@@ -0,0 +1,78 @@
+package comment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultMarker is the hidden HTML comment identifying the comment tfs owns on a
+// pull/merge request, so re-runs update it instead of posting a new one.
+const DefaultMarker = "<!-- tfs:plan-summary -->"
+
+// perPage is the page size used when listing existing comments.
+const perPage = 100
+
+// Poster creates or updates the sticky comment on a pull/merge request.
+type Poster interface {
+	// Upsert makes sure exactly one comment containing marker exists, with the given body.
+	// It returns the URL of the comment.
+	Upsert(ctx context.Context, marker, body string) (string, error)
+}
+
+// withMarker prepends the marker to the body so the comment can be found again.
+func withMarker(marker, body string) string {
+	if strings.Contains(body, marker) {
+		return body
+	}
+	return marker + "\n" + body
+}
+
+// doJSON sends an API request with an optional JSON payload and decodes the JSON response into out.
+func doJSON(ctx context.Context, client *http.Client, method, url string, header http.Header, payload, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s: unexpected status %s: %s", method, url, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response of %s %s: %w", method, url, err)
+	}
+	return nil
+}
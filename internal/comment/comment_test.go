@@ -0,0 +1,149 @@
+package comment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeAPI is a minimal in-memory comments API shared by the GitHub and GitLab fakes.
+type fakeAPI struct {
+	mu       sync.Mutex
+	comments map[int64]string
+	nextID   int64
+	creates  int
+	updates  int
+}
+
+func newFakeAPI(existing ...string) *fakeAPI {
+	f := &fakeAPI{comments: map[int64]string{}, nextID: 1}
+	for _, body := range existing {
+		f.comments[f.nextID] = body
+		f.nextID++
+	}
+	return f
+}
+
+func (f *fakeAPI) list(w http.ResponseWriter) {
+	var out []map[string]interface{}
+	for id := int64(1); id < f.nextID; id++ {
+		if body, ok := f.comments[id]; ok {
+			out = append(out, map[string]interface{}{"id": id, "body": body})
+		}
+	}
+	json.NewEncoder(w).Encode(out)
+}
+
+func (f *fakeAPI) save(w http.ResponseWriter, r *http.Request, id int64) {
+	var payload struct{ Body string }
+	json.NewDecoder(r.Body).Decode(&payload)
+	if id == 0 {
+		id = f.nextID
+		f.nextID++
+		f.creates++
+	} else {
+		f.updates++
+	}
+	f.comments[id] = payload.Body
+	json.NewEncoder(w).Encode(map[string]interface{}{"id": id, "body": payload.Body, "html_url": fmt.Sprintf("https://example.com/c/%d", id)})
+}
+
+func TestGitHub_Upsert(t *testing.T) {
+	api := newFakeAPI("LGTM")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		api.mu.Lock()
+		defer api.mu.Unlock()
+
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/o/r/issues/7/comments":
+			api.list(w)
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/o/r/issues/7/comments":
+			api.save(w, r, 0)
+		case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, "/repos/o/r/issues/comments/"):
+			var id int64
+			fmt.Sscanf(strings.TrimPrefix(r.URL.Path, "/repos/o/r/issues/comments/"), "%d", &id)
+			api.save(w, r, id)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	g := &GitHub{BaseURL: srv.URL, Token: "secret", Repo: "o/r", Number: 7}
+	for _, body := range []string{"first run", "second run"} {
+		if _, err := g.Upsert(context.Background(), DefaultMarker, body); err != nil {
+			t.Fatalf("Upsert failed: %v", err)
+		}
+	}
+
+	if api.creates != 1 || api.updates != 1 {
+		t.Errorf("Expected 1 create and 1 update, got %d and %d", api.creates, api.updates)
+	}
+	if len(api.comments) != 2 {
+		t.Errorf("Expected 2 comments on the pull request, got %d", len(api.comments))
+	}
+	if got := api.comments[2]; got != DefaultMarker+"\nsecond run" {
+		t.Errorf("Sticky comment body = %q", got)
+	}
+}
+
+func TestGitLab_Upsert(t *testing.T) {
+	api := newFakeAPI(DefaultMarker + "\nold summary")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		api.mu.Lock()
+		defer api.mu.Unlock()
+
+		if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		prefix := "/projects/group%2Fproject/merge_requests/3/notes"
+		switch {
+		case r.Method == http.MethodGet && r.URL.EscapedPath() == prefix:
+			api.list(w)
+		case r.Method == http.MethodPost && r.URL.EscapedPath() == prefix:
+			api.save(w, r, 0)
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.EscapedPath(), prefix+"/"):
+			var id int64
+			fmt.Sscanf(strings.TrimPrefix(r.URL.EscapedPath(), prefix+"/"), "%d", &id)
+			api.save(w, r, id)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	g := &GitLab{BaseURL: srv.URL, Token: "secret", Project: "group/project", MergeRequest: 3}
+	if _, err := g.Upsert(context.Background(), DefaultMarker, "new summary"); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+
+	if api.creates != 0 || api.updates != 1 {
+		t.Errorf("Expected the existing note to be updated, got %d creates and %d updates", api.creates, api.updates)
+	}
+	if got := api.comments[1]; got != DefaultMarker+"\nnew summary" {
+		t.Errorf("Sticky note body = %q", got)
+	}
+}
+
+func TestUpsert_APIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Bad credentials", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	g := &GitHub{BaseURL: srv.URL, Token: "wrong", Repo: "o/r", Number: 1}
+	_, err := g.Upsert(context.Background(), DefaultMarker, "body")
+	if err == nil || !strings.Contains(err.Error(), "Bad credentials") {
+		t.Errorf("Expected an error mentioning the API response, got %v", err)
+	}
+}
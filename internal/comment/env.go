@@ -0,0 +1,92 @@
+package comment
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Providers supported by FromEnv.
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+)
+
+// DetectProvider guesses the provider from the CI environment, returning "" if unknown.
+func DetectProvider() string {
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") != "" || os.Getenv("GITHUB_REPOSITORY") != "":
+		return ProviderGitHub
+	case os.Getenv("GITLAB_CI") != "" || os.Getenv("CI_MERGE_REQUEST_IID") != "":
+		return ProviderGitLab
+	default:
+		return ""
+	}
+}
+
+// FromEnv builds a Poster from the standard CI environment variables:
+//
+//	github: GITHUB_TOKEN (or GH_TOKEN), GITHUB_REPOSITORY, GITHUB_API_URL, GITHUB_REF
+//	gitlab: GITLAB_TOKEN, CI_PROJECT_ID, CI_API_V4_URL, CI_MERGE_REQUEST_IID
+//
+// A number > 0 overrides the pull/merge request number found in the environment.
+func FromEnv(provider string, number int) (Poster, error) {
+	if provider == "" {
+		provider = DetectProvider()
+	}
+
+	switch provider {
+	case ProviderGitHub:
+		token := firstEnv("GITHUB_TOKEN", "GH_TOKEN")
+		if token == "" {
+			return nil, fmt.Errorf("GITHUB_TOKEN is not set")
+		}
+		repo := os.Getenv("GITHUB_REPOSITORY")
+		if repo == "" {
+			return nil, fmt.Errorf("GITHUB_REPOSITORY is not set")
+		}
+		if number <= 0 {
+			// refs/pull/<number>/merge on pull_request events
+			ref := strings.Split(os.Getenv("GITHUB_REF"), "/")
+			if len(ref) == 4 && ref[1] == "pull" {
+				number, _ = strconv.Atoi(ref[2])
+			}
+		}
+		if number <= 0 {
+			return nil, fmt.Errorf("pull request number not found in GITHUB_REF, pass it explicitly")
+		}
+		return &GitHub{BaseURL: os.Getenv("GITHUB_API_URL"), Token: token, Repo: repo, Number: number}, nil
+
+	case ProviderGitLab:
+		token := os.Getenv("GITLAB_TOKEN")
+		if token == "" {
+			return nil, fmt.Errorf("GITLAB_TOKEN is not set")
+		}
+		project := os.Getenv("CI_PROJECT_ID")
+		if project == "" {
+			return nil, fmt.Errorf("CI_PROJECT_ID is not set")
+		}
+		if number <= 0 {
+			number, _ = strconv.Atoi(os.Getenv("CI_MERGE_REQUEST_IID"))
+		}
+		if number <= 0 {
+			return nil, fmt.Errorf("merge request IID not found in CI_MERGE_REQUEST_IID, pass it explicitly")
+		}
+		return &GitLab{BaseURL: os.Getenv("CI_API_V4_URL"), Token: token, Project: project, MergeRequest: number}, nil
+
+	case "":
+		return nil, fmt.Errorf("could not detect the CI provider, set it explicitly (github or gitlab)")
+	default:
+		return nil, fmt.Errorf("unsupported provider %q (expected github or gitlab)", provider)
+	}
+}
+
+func firstEnv(keys ...string) string {
+	for _, k := range keys {
+		if v := os.Getenv(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
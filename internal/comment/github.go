@@ -0,0 +1,81 @@
+package comment
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultGitHubURL is the public GitHub API. Enterprise servers use https://<host>/api/v3.
+const DefaultGitHubURL = "https://api.github.com"
+
+// GitHub posts the comment on a pull request through the REST API.
+type GitHub struct {
+	BaseURL string
+	Token   string
+	Repo    string // "owner/name"
+	Number  int    // Pull request number
+	Client  *http.Client
+}
+
+type githubComment struct {
+	ID      int64  `json:"id"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+}
+
+func (g *GitHub) Upsert(ctx context.Context, marker, body string) (string, error) {
+	body = withMarker(marker, body)
+
+	existing, err := g.find(ctx, marker)
+	if err != nil {
+		return "", err
+	}
+
+	var result githubComment
+	if existing != nil {
+		url := fmt.Sprintf("%s/repos/%s/issues/comments/%d", g.baseURL(), g.Repo, existing.ID)
+		err = doJSON(ctx, g.Client, http.MethodPatch, url, g.header(), map[string]string{"body": body}, &result)
+	} else {
+		url := fmt.Sprintf("%s/repos/%s/issues/%d/comments", g.baseURL(), g.Repo, g.Number)
+		err = doJSON(ctx, g.Client, http.MethodPost, url, g.header(), map[string]string{"body": body}, &result)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to post github comment: %w", err)
+	}
+	return result.HTMLURL, nil
+}
+
+// find returns the first comment of the pull request containing marker, if any.
+func (g *GitHub) find(ctx context.Context, marker string) (*githubComment, error) {
+	for page := 1; ; page++ {
+		var comments []githubComment
+		url := fmt.Sprintf("%s/repos/%s/issues/%d/comments?per_page=%d&page=%d", g.baseURL(), g.Repo, g.Number, perPage, page)
+		if err := doJSON(ctx, g.Client, http.MethodGet, url, g.header(), nil, &comments); err != nil {
+			return nil, fmt.Errorf("failed to list github comments: %w", err)
+		}
+		for i := range comments {
+			if strings.Contains(comments[i].Body, marker) {
+				return &comments[i], nil
+			}
+		}
+		if len(comments) < perPage {
+			return nil, nil
+		}
+	}
+}
+
+func (g *GitHub) baseURL() string {
+	if g.BaseURL == "" {
+		return DefaultGitHubURL
+	}
+	return strings.TrimSuffix(g.BaseURL, "/")
+}
+
+func (g *GitHub) header() http.Header {
+	h := http.Header{}
+	h.Set("Authorization", "Bearer "+g.Token)
+	h.Set("X-GitHub-Api-Version", "2022-11-28")
+	return h
+}
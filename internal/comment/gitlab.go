@@ -0,0 +1,83 @@
+package comment
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultGitLabURL is the public GitLab API.
+const DefaultGitLabURL = "https://gitlab.com/api/v4"
+
+// GitLab posts the comment (a "note") on a merge request through the REST API.
+type GitLab struct {
+	BaseURL      string
+	Token        string
+	Project      string // Numeric ID or "group/project" path
+	MergeRequest int    // Merge request IID
+	Client       *http.Client
+}
+
+type gitlabNote struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+func (g *GitLab) Upsert(ctx context.Context, marker, body string) (string, error) {
+	body = withMarker(marker, body)
+
+	existing, err := g.find(ctx, marker)
+	if err != nil {
+		return "", err
+	}
+
+	var result gitlabNote
+	if existing != nil {
+		u := fmt.Sprintf("%s/notes/%d", g.notesURL(), existing.ID)
+		err = doJSON(ctx, g.Client, http.MethodPut, u, g.header(), map[string]string{"body": body}, &result)
+	} else {
+		err = doJSON(ctx, g.Client, http.MethodPost, g.notesURL()+"/notes", g.header(), map[string]string{"body": body}, &result)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to post gitlab note: %w", err)
+	}
+
+	// The API does not return a web URL for notes, return the API URL of the note
+	return fmt.Sprintf("%s/notes/%d", g.notesURL(), result.ID), nil
+}
+
+// find returns the first note of the merge request containing marker, if any.
+func (g *GitLab) find(ctx context.Context, marker string) (*gitlabNote, error) {
+	for page := 1; ; page++ {
+		var notes []gitlabNote
+		u := fmt.Sprintf("%s/notes?per_page=%d&page=%d", g.notesURL(), perPage, page)
+		if err := doJSON(ctx, g.Client, http.MethodGet, u, g.header(), nil, &notes); err != nil {
+			return nil, fmt.Errorf("failed to list gitlab notes: %w", err)
+		}
+		for i := range notes {
+			if strings.Contains(notes[i].Body, marker) {
+				return &notes[i], nil
+			}
+		}
+		if len(notes) < perPage {
+			return nil, nil
+		}
+	}
+}
+
+// notesURL is the API URL of the merge request, without the trailing "/notes".
+func (g *GitLab) notesURL() string {
+	base := g.BaseURL
+	if base == "" {
+		base = DefaultGitLabURL
+	}
+	return fmt.Sprintf("%s/projects/%s/merge_requests/%d", strings.TrimSuffix(base, "/"), url.PathEscape(g.Project), g.MergeRequest)
+}
+
+func (g *GitLab) header() http.Header {
+	h := http.Header{}
+	h.Set("PRIVATE-TOKEN", g.Token)
+	return h
+}
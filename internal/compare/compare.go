@@ -0,0 +1,145 @@
+package compare
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+// Kind of difference between the two plans for one resource.
+type Kind int
+
+const (
+	Added         Kind = iota // Changed in the new plan only
+	Removed                   // Changed in the old plan only
+	ActionChanged             // Changed in both, with different actions
+	ValuesChanged             // Same actions, different planned after-values
+)
+
+// NumKinds is the number of Kind values, in display order.
+const NumKinds = 4
+
+func (k Kind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case ActionChanged:
+		return "action_changed"
+	default:
+		return "values_changed"
+	}
+}
+
+// Entry is one resource whose planned change differs between the two plans.
+type Entry struct {
+	Address      string
+	Kind         Kind
+	Old          *models.ResourceChange // nil when Added
+	New          *models.ResourceChange // nil when Removed
+	ChangedPaths []string               // After-value paths that differ, for ValuesChanged
+}
+
+// Resource returns the most recent version of the resource change.
+func (e Entry) Resource() models.ResourceChange {
+	if e.New != nil {
+		return *e.New
+	}
+	return *e.Old
+}
+
+// ValuesChange describes the difference between the old and new after-values
+// as an update, so it can go through the usual diff renderers.
+func (e Entry) ValuesChange() models.ResourceChange {
+	rc := e.Resource()
+	rc.Change = models.Change{Actions: []string{"update"}}
+	if e.Old != nil {
+		rc.Change.Before = e.Old.Change.After
+		rc.Change.BeforeSensitive = e.Old.Change.AfterSensitive
+	}
+	if e.New != nil {
+		rc.Change.After = e.New.Change.After
+		rc.Change.AfterUnknown = e.New.Change.AfterUnknown
+		rc.Change.AfterSensitive = e.New.Change.AfterSensitive
+	}
+	return rc
+}
+
+// Result lists the differences by kind, each sorted by address.
+type Result struct {
+	Entries [NumKinds][]Entry
+}
+
+func (r Result) Total() int {
+	total := 0
+	for _, entries := range r.Entries {
+		total += len(entries)
+	}
+	return total
+}
+
+// Compare reports how the change set of newPlan differs from oldPlan's.
+// No-op resources are not part of a change set.
+func Compare(oldPlan, newPlan models.TfPlan) Result {
+	oldChanges := changeSet(oldPlan)
+	newChanges := changeSet(newPlan)
+
+	var r Result
+	for addr, n := range newChanges {
+		o, ok := oldChanges[addr]
+		switch {
+		case !ok:
+			r.Entries[Added] = append(r.Entries[Added], Entry{Address: addr, Kind: Added, New: n})
+		case !reflect.DeepEqual(o.Change.Actions, n.Change.Actions):
+			r.Entries[ActionChanged] = append(r.Entries[ActionChanged], Entry{Address: addr, Kind: ActionChanged, Old: o, New: n})
+		default:
+			e := Entry{Address: addr, Kind: ValuesChanged, Old: o, New: n}
+			if paths := e.ValuesChange().Change.ChangedPaths(); len(paths) > 0 || !reflect.DeepEqual(o.Change.AfterUnknown, n.Change.AfterUnknown) {
+				e.ChangedPaths = paths
+				r.Entries[ValuesChanged] = append(r.Entries[ValuesChanged], e)
+			}
+		}
+	}
+	for addr, o := range oldChanges {
+		if _, ok := newChanges[addr]; !ok {
+			r.Entries[Removed] = append(r.Entries[Removed], Entry{Address: addr, Kind: Removed, Old: o})
+		}
+	}
+
+	for _, entries := range r.Entries {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Address < entries[j].Address })
+	}
+	return r
+}
+
+func changeSet(plan models.TfPlan) map[string]*models.ResourceChange {
+	set := make(map[string]*models.ResourceChange)
+	for i := range plan.ResourceChanges {
+		rc := &plan.ResourceChanges[i]
+		if !rc.IsNoOp() {
+			set[rc.Key()] = rc
+		}
+	}
+	return set
+}
+
+// Description is a one-line explanation of the difference, e.g.
+// "action changed: update -> delete, create".
+func (e Entry) Description() string {
+	switch e.Kind {
+	case Added:
+		return "newly changed by this plan: " + strings.Join(e.New.Change.Actions, ", ")
+	case Removed:
+		return "no longer changed by this plan (was: " + strings.Join(e.Old.Change.Actions, ", ") + ")"
+	case ActionChanged:
+		return "action changed: " + strings.Join(e.Old.Change.Actions, ", ") + " -> " + strings.Join(e.New.Change.Actions, ", ")
+	default:
+		if len(e.ChangedPaths) == 0 {
+			return "planned values changed"
+		}
+		return "planned values changed: " + strings.Join(e.ChangedPaths, ", ")
+	}
+}
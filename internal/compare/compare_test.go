@@ -0,0 +1,58 @@
+package compare
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+func rc(addr string, actions []string, after map[string]interface{}) models.ResourceChange {
+	return models.ResourceChange{Address: addr, Change: models.Change{Actions: actions, After: after}}
+}
+
+func TestCompare(t *testing.T) {
+	create := []string{"create"}
+	deposed := rc("a.same", []string{"delete"}, nil)
+	deposed.Deposed = "1a2b3c4d"
+	oldPlan := models.TfPlan{ResourceChanges: []models.ResourceChange{
+		rc("a.same", create, map[string]interface{}{"size": "small"}),
+		rc("a.values", create, map[string]interface{}{"size": "small", "name": "x"}),
+		rc("a.action", []string{"update"}, nil),
+		rc("a.removed", create, nil),
+		rc("a.noop", []string{"no-op"}, nil),
+	}}
+	newPlan := models.TfPlan{ResourceChanges: []models.ResourceChange{
+		rc("a.same", create, map[string]interface{}{"size": "small"}),
+		rc("a.values", create, map[string]interface{}{"size": "large", "name": "x"}),
+		rc("a.action", []string{"delete", "create"}, nil),
+		rc("a.added", create, nil),
+		rc("a.removed", []string{"no-op"}, nil),
+		deposed,
+	}}
+
+	r := Compare(oldPlan, newPlan)
+
+	expected := map[Kind][]string{
+		Added:         {"a.added", "a.same/deposed-1a2b3c4d"},
+		Removed:       {"a.removed"},
+		ActionChanged: {"a.action"},
+		ValuesChanged: {"a.values"},
+	}
+	for kind, addrs := range expected {
+		var got []string
+		for _, e := range r.Entries[kind] {
+			got = append(got, e.Address)
+		}
+		if !reflect.DeepEqual(got, addrs) {
+			t.Errorf("%s = %v; want %v", kind, got, addrs)
+		}
+	}
+
+	if paths := r.Entries[ValuesChanged][0].ChangedPaths; !reflect.DeepEqual(paths, []string{"size"}) {
+		t.Errorf("ChangedPaths = %v; want [size]", paths)
+	}
+	if r.Total() != 5 {
+		t.Errorf("Total() = %d; want 5", r.Total())
+	}
+}
@@ -0,0 +1,208 @@
+package config
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+// Location points at a declaration in the configuration files.
+type Location struct {
+	File string // Relative to the root directory when possible, slash separated
+	Line int    // 1-based
+}
+
+func (l Location) String() string {
+	return fmt.Sprintf("%s:%d", l.File, l.Line)
+}
+
+// ModuleNames splits a module address such as `module.a.module.b["x"]` into the
+// module call names ("a", "b"), dropping count/for_each keys.
+func ModuleNames(moduleAddress string) []string {
+	var names []string
+	s := moduleAddress
+	for strings.HasPrefix(s, "module.") {
+		s = s[len("module."):]
+		end := strings.IndexAny(s, ".[")
+		if end < 0 {
+			names = append(names, s)
+			break
+		}
+		names = append(names, s[:end])
+		s = s[end:]
+
+		// Skip the instance key, which may itself contain dots or brackets inside quotes
+		if strings.HasPrefix(s, "[") {
+			inQuotes := false
+			i := 1
+			for ; i < len(s); i++ {
+				if s[i] == '"' && s[i-1] != '\\' {
+					inQuotes = !inQuotes
+				}
+				if s[i] == ']' && !inQuotes {
+					break
+				}
+			}
+			s = s[min(i+1, len(s)):]
+		}
+		s = strings.TrimPrefix(s, ".")
+	}
+	return names
+}
+
+// FindModule returns the configuration of the module at moduleAddress ("" for the root module).
+func FindModule(cfg models.Configuration, moduleAddress string) (models.ConfigModule, bool) {
+	module := cfg.RootModule
+	for _, name := range ModuleNames(moduleAddress) {
+		call, ok := module.ModuleCalls[name]
+		if !ok {
+			return models.ConfigModule{}, false
+		}
+		module = call.Module
+	}
+	return module, true
+}
+
+// ModuleDir returns the directory holding the files of the module at moduleAddress.
+// Local sources are resolved relative to their parent module, other sources through
+// the `.terraform/modules/modules.json` manifest written by `terraform init`.
+func ModuleDir(cfg models.Configuration, root, moduleAddress string) (string, bool) {
+	dir := root
+	module := cfg.RootModule
+	var key []string
+	for _, name := range ModuleNames(moduleAddress) {
+		call, ok := module.ModuleCalls[name]
+		if !ok {
+			return "", false
+		}
+		key = append(key, name)
+
+		if strings.HasPrefix(call.Source, "./") || strings.HasPrefix(call.Source, "../") {
+			dir = filepath.Join(dir, filepath.FromSlash(call.Source))
+		} else {
+			installed, ok := installedModuleDir(root, strings.Join(key, "."))
+			if !ok {
+				return "", false
+			}
+			dir = installed
+		}
+		module = call.Module
+	}
+	return dir, true
+}
+
+type modulesManifest struct {
+	Modules []struct {
+		Key string `json:"Key"`
+		Dir string `json:"Dir"`
+	} `json:"Modules"`
+}
+
+func installedModuleDir(root, key string) (string, bool) {
+	raw, err := os.ReadFile(filepath.Join(root, ".terraform", "modules", "modules.json"))
+	if err != nil {
+		return "", false
+	}
+	var manifest modulesManifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return "", false
+	}
+	for _, m := range manifest.Modules {
+		if m.Key == key {
+			return filepath.Join(root, filepath.FromSlash(m.Dir)), true
+		}
+	}
+	return "", false
+}
+
+// FindResource locates the `resource` (or `data`) block declaring rc in the
+// configuration files under root. To locate many resources, use an Index.
+func FindResource(cfg models.Configuration, root string, rc models.ResourceChange) (Location, bool) {
+	return NewIndex(cfg, root, "").Find(rc)
+}
+
+// blockPattern matches the first line of a resource or data block, capturing its
+// kind, type and name, e.g. `resource "aws_instance" "web" {`.
+var blockPattern = regexp.MustCompile(`^\s*(resource|data)\s+"?([\w-]+)"?\s+"?([\w-]+)"?\s*\{`)
+
+// Index locates the declarations of resources, reading the files of each module
+// directory once.
+type Index struct {
+	cfg    models.Configuration
+	root   string
+	base   string
+	blocks map[string]map[string]Location // By module path (e.g. "vpc.subnets"), then by "<kind>.<type>.<name>"
+}
+
+// NewIndex returns an index of the configuration files under root. Locations are
+// relative to base, e.g. the repository root, or to root when base is empty.
+func NewIndex(cfg models.Configuration, root, base string) *Index {
+	if base == "" {
+		base = root
+	}
+	return &Index{cfg: cfg, root: root, base: base, blocks: make(map[string]map[string]Location)}
+}
+
+// Find locates the `resource` (or `data`) block declaring rc.
+func (idx *Index) Find(rc models.ResourceChange) (Location, bool) {
+	module := strings.Join(ModuleNames(rc.ModuleAddress), ".")
+	blocks, ok := idx.blocks[module]
+	if !ok {
+		if dir, found := ModuleDir(idx.cfg, idx.root, rc.ModuleAddress); found {
+			blocks = idx.scan(dir)
+		}
+		idx.blocks[module] = blocks
+	}
+
+	kind := "resource"
+	if rc.Mode == "data" {
+		kind = "data"
+	}
+	loc, ok := blocks[kind+"."+rc.Type+"."+rc.Name]
+	return loc, ok
+}
+
+// scan lists the blocks declared by the files of a module directory, the first
+// declaration winning.
+func (idx *Index) scan(dir string) map[string]Location {
+	blocks := make(map[string]Location)
+	files, _ := filepath.Glob(filepath.Join(dir, "*.tf"))
+	sort.Strings(files)
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		for line := 1; scanner.Scan(); line++ {
+			m := blockPattern.FindStringSubmatch(scanner.Text())
+			if m == nil {
+				continue
+			}
+			key := m[1] + "." + m[2] + "." + m[3]
+			if _, ok := blocks[key]; !ok {
+				blocks[key] = Location{File: relativePath(idx.base, file), Line: line}
+			}
+		}
+		f.Close()
+	}
+	return blocks
+}
+
+func relativePath(base, file string) string {
+	absBase, baseErr := filepath.Abs(base)
+	absFile, fileErr := filepath.Abs(file)
+	if baseErr == nil && fileErr == nil {
+		if rel, err := filepath.Rel(absBase, absFile); err == nil {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(file)
+}
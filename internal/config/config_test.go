@@ -0,0 +1,151 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+func TestModuleNames(t *testing.T) {
+	tests := []struct {
+		address  string
+		expected []string
+	}{
+		{"", nil},
+		{"module.db", []string{"db"}},
+		{"module.a[0].module.b", []string{"a", "b"}},
+		{`module.a["x.y]"].module.b["k"]`, []string{"a", "b"}},
+	}
+
+	for _, tt := range tests {
+		if got := ModuleNames(tt.address); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("ModuleNames(%q) = %v; want %v", tt.address, got, tt.expected)
+		}
+	}
+}
+
+func TestFindResource(t *testing.T) {
+	root := t.TempDir()
+	write := func(path, content string) {
+		full := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("main.tf", "module \"db\" {\n  source = \"./modules/db\"\n}\n")
+	write("modules/db/main.tf", "variable \"x\" {}\n\nresource \"aws_db_instance\" \"main\" {\n}\n")
+	write("modules/db/data.tf", "data \"aws_db_instance\" \"main\" {\n}\n")
+
+	cfg := models.Configuration{RootModule: models.ConfigModule{
+		ModuleCalls: map[string]models.ModuleCall{"db": {Source: "./modules/db"}},
+	}}
+
+	loc, ok := FindResource(cfg, root, models.ResourceChange{ModuleAddress: "module.db", Mode: "managed", Type: "aws_db_instance", Name: "main"})
+	if !ok || loc.String() != "modules/db/main.tf:3" {
+		t.Errorf("FindResource(managed) = %v, %v; want modules/db/main.tf:3", loc, ok)
+	}
+
+	loc, ok = FindResource(cfg, root, models.ResourceChange{ModuleAddress: "module.db", Mode: "data", Type: "aws_db_instance", Name: "main"})
+	if !ok || loc.String() != "modules/db/data.tf:1" {
+		t.Errorf("FindResource(data) = %v, %v; want modules/db/data.tf:1", loc, ok)
+	}
+
+	if _, ok := FindResource(cfg, root, models.ResourceChange{ModuleAddress: "module.missing", Type: "x", Name: "y"}); ok {
+		t.Errorf("Expected unknown module not to be found")
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "modules", "app"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "modules", "app", "main.tf"), []byte("resource \"aws_instance\" \"web\" {\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := models.Configuration{RootModule: models.ConfigModule{
+		ModuleCalls: map[string]models.ModuleCall{"app": {
+			Source: "./modules/app",
+			Module: models.ConfigModule{Resources: []models.ConfigResource{{
+				Address: "aws_instance.web",
+				Mode:    "managed",
+				Type:    "aws_instance",
+				Name:    "web",
+				Expressions: map[string]interface{}{
+					"instance_type": map[string]interface{}{"constant_value": "t3.micro"},
+					"subnet_id":     map[string]interface{}{"references": []interface{}{"var.subnet_id"}},
+					"tags":          map[string]interface{}{"constant_value": map[string]interface{}{"Name": "<web>"}},
+					"ebs_block_device": []interface{}{
+						map[string]interface{}{"volume_size": map[string]interface{}{"constant_value": 10}},
+						map[string]interface{}{"kms_key_id": map[string]interface{}{"references": []interface{}{"aws_kms_key.a.arn", "aws_kms_key.a"}}},
+					},
+				},
+			}}},
+		}},
+	}}
+	rc := models.ResourceChange{Address: `module.app[0].aws_instance.web`, ModuleAddress: "module.app[0]", Mode: "managed", Type: "aws_instance", Name: "web"}
+
+	src, ok := Describe(cfg, root, rc)
+	if !ok {
+		t.Fatalf("Describe() did not find the resource")
+	}
+	if !reflect.DeepEqual(src.Calls, []string{"./modules/app"}) {
+		t.Errorf("Calls = %v; want [./modules/app]", src.Calls)
+	}
+	expected := []Expression{
+		{"ebs_block_device[0].volume_size", "10"},
+		{"ebs_block_device[1].kms_key_id", "aws_kms_key.a.arn"},
+		{"instance_type", `"t3.micro"`},
+		{"subnet_id", "var.subnet_id"},
+		{"tags", `{"Name":"<web>"}`},
+	}
+	if !reflect.DeepEqual(src.Expressions, expected) {
+		t.Errorf("Expressions = %v; want %v", src.Expressions, expected)
+	}
+	if src.Location.String() != "modules/app/main.tf:1" {
+		t.Errorf("Location = %v; want modules/app/main.tf:1", src.Location)
+	}
+	if lines := src.Lines(); len(lines) != 2+len(expected) ||
+		lines[0].Text != "# Declared in modules/app/main.tf:1" || lines[1].Text != "  module module.app[0] (./modules/app)" {
+		t.Errorf("Lines() = %v", lines)
+	}
+
+	if src, _ := Describe(cfg, "", rc); src.Location != (Location{}) {
+		t.Errorf("Expected no location without a root directory, got %v", src.Location)
+	}
+	rc.Name = "removed"
+	if _, ok := Describe(cfg, root, rc); ok {
+		t.Errorf("Expected a resource missing from the configuration not to be found")
+	}
+}
+
+func TestParseLockFile(t *testing.T) {
+	data := []byte(`# This file is maintained automatically by "terraform init".
+
+provider "registry.terraform.io/hashicorp/aws" {
+  version     = "5.31.0"
+  constraints = "~> 5.0"
+  hashes = [
+    "h1:abc=",
+  ]
+}
+
+provider "registry.terraform.io/hashicorp/random" {
+  version = "3.6.0"
+}
+`)
+	expected := map[string]string{
+		"registry.terraform.io/hashicorp/aws":    "5.31.0",
+		"registry.terraform.io/hashicorp/random": "3.6.0",
+	}
+	if got := ParseLockFile(data); !reflect.DeepEqual(got, expected) {
+		t.Errorf("ParseLockFile() = %v; want %v", got, expected)
+	}
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// LockFile is the dependency lock file `terraform init` writes in the root module.
+const LockFile = ".terraform.lock.hcl"
+
+var (
+	lockProvider = regexp.MustCompile(`^\s*provider\s+"([^"]+)"\s*\{`)
+	lockVersion  = regexp.MustCompile(`^\s*version\s*=\s*"([^"]+)"`)
+)
+
+// ParseLockFile returns the provider versions selected in a dependency lock file,
+// by source address such as registry.terraform.io/hashicorp/aws.
+func ParseLockFile(data []byte) map[string]string {
+	versions := make(map[string]string)
+	provider := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := lockProvider.FindStringSubmatch(line); m != nil {
+			provider = m[1]
+		} else if m := lockVersion.FindStringSubmatch(line); m != nil && provider != "" {
+			versions[provider] = m[1]
+			provider = ""
+		}
+	}
+	return versions
+}
+
+// ReadLockFile returns the provider versions selected in the lock file of the root
+// module in dir, nil when there is none.
+func ReadLockFile(dir string) map[string]string {
+	data, err := os.ReadFile(filepath.Join(dir, LockFile))
+	if err != nil {
+		return nil
+	}
+	return ParseLockFile(data)
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bernard-sh/tfs/internal/diff"
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+// Source is where a resource comes from: its module, the expressions of its
+// declaration and, when the configuration files are at hand, the declaration itself.
+type Source struct {
+	Module      string       // Module address, "" for the root module
+	Calls       []string     // Source of each module call down to Module, e.g. "./modules/vpc"
+	Expressions []Expression // Sorted by attribute
+	Location    Location     // Zero when the declaration was not found
+}
+
+// Expression is how an attribute is set in the declaration.
+type Expression struct {
+	Attribute string // e.g. "instance_type" or "ingress[0].cidr_blocks"
+	Value     string // The references it is computed from, or the JSON of a constant
+}
+
+// Lines describes the source as diff lines: the file and line declaring the resource
+// when known, its module and the expressions of its attributes.
+func (src Source) Lines() []diff.Line {
+	header := "# Configuration"
+	if src.Location.Line > 0 {
+		header = "# Declared in " + src.Location.String()
+	}
+	lines := []diff.Line{{Kind: diff.LineHeader, Text: header}}
+	if src.Module != "" {
+		lines = append(lines, diff.Line{Kind: diff.LinePlain, Text: fmt.Sprintf("  module %s (%s)", src.Module, strings.Join(src.Calls, " > "))})
+	}
+	for _, e := range src.Expressions {
+		lines = append(lines, diff.Line{Kind: diff.LinePlain, Text: fmt.Sprintf("  %s = %s", e.Attribute, e.Value)})
+	}
+	return lines
+}
+
+// Describe returns the source of rc. root is the root module directory, "" to skip
+// looking up the declaration in the files. It returns false when the configuration
+// does not declare rc, e.g. for a resource removed from it.
+func Describe(cfg models.Configuration, root string, rc models.ResourceChange) (Source, bool) {
+	src := Source{Module: rc.ModuleAddress}
+
+	module := cfg.RootModule
+	for _, name := range ModuleNames(rc.ModuleAddress) {
+		call, ok := module.ModuleCalls[name]
+		if !ok {
+			return src, false
+		}
+		src.Calls = append(src.Calls, call.Source)
+		module = call.Module
+	}
+
+	mode := rc.Mode
+	if mode == "" {
+		mode = "managed"
+	}
+	found := false
+	for _, r := range module.Resources {
+		if r.Mode == mode && r.Type == rc.Type && r.Name == rc.Name {
+			src.Expressions = expressions("", r.Expressions)
+			found = true
+			break
+		}
+	}
+	if !found {
+		return src, false
+	}
+
+	if root != "" {
+		src.Location, _ = FindResource(cfg, root, rc)
+	}
+	return src, true
+}
+
+// expressions flattens the expressions of a declaration, nested blocks included.
+func expressions(prefix string, exprs map[string]interface{}) []Expression {
+	keys := make([]string, 0, len(exprs))
+	for k := range exprs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var out []Expression
+	for _, key := range keys {
+		switch v := exprs[key].(type) {
+		case map[string]interface{}:
+			if value, ok := expressionValue(v); ok {
+				out = append(out, Expression{Attribute: prefix + key, Value: value})
+			} else {
+				out = append(out, expressions(prefix+key+".", v)...)
+			}
+		case []interface{}:
+			// Nested blocks
+			for i, item := range v {
+				block, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				name := prefix + key
+				if len(v) > 1 {
+					name += fmt.Sprintf("[%d]", i)
+				}
+				out = append(out, expressions(name+".", block)...)
+			}
+		}
+	}
+	return out
+}
+
+// expressionValue describes an expression: its references, or its constant value.
+// Terraform lists every prefix of a reference too (aws_subnet.a.id, aws_subnet.a),
+// only the most specific ones are kept.
+func expressionValue(expr map[string]interface{}) (string, bool) {
+	if list, ok := expr["references"].([]interface{}); ok {
+		var refs []string
+		for _, ref := range list {
+			if s, ok := ref.(string); ok {
+				refs = append(refs, s)
+			}
+		}
+		var kept []string
+		for _, ref := range refs {
+			redundant := false
+			for _, other := range refs {
+				if other != ref && (strings.HasPrefix(other, ref+".") || strings.HasPrefix(other, ref+"[")) {
+					redundant = true
+					break
+				}
+			}
+			if !redundant {
+				kept = append(kept, ref)
+			}
+		}
+		return strings.Join(kept, ", "), true
+	}
+	if constant, ok := expr["constant_value"]; ok {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(constant); err != nil {
+			return fmt.Sprintf("%v", constant), true
+		}
+		return truncate(strings.TrimSuffix(buf.String(), "\n"), maxValueLen), true
+	}
+	return "", false
+}
+
+// maxValueLen bounds the constants shown, a policy document can take pages.
+const maxValueLen = 80
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,272 @@
+// Package diff renders resource changes as terraform-style diff lines, leaving
+// the colouring to each frontend.
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+// LineKind classifies a diff line so each frontend (TUI, plain text, Markdown, HTML)
+// can colour it its own way.
+type LineKind int
+
+const (
+	LinePlain LineKind = iota
+	LineHeader
+	LineAdd
+	LineDelete
+	LineUpdate
+	LineReplace
+)
+
+// Line is one entry of a resource diff. Text may span several lines when
+// it holds a multi-line value (maps, lists, heredoc strings).
+type Line struct {
+	Kind LineKind
+	Text string
+}
+
+// Lines builds the terraform-style diff of a resource change.
+func Lines(rc models.ResourceChange) []Line {
+	var lines []Line
+
+	// Recursive diff function, the sensitive masks following the values down
+	var stringifyDiff func(key string, valBefore, valAfter interface{}, unknown, sensBefore, sensAfter interface{}, indent int, modKind LineKind)
+	stringifyDiff = func(key string, valBefore, valAfter interface{}, unknown, sensBefore, sensAfter interface{}, indent int, modKind LineKind) {
+		padding := strings.Repeat(" ", indent)
+
+		// Check if "known after apply"
+		isUnknown := false
+		if b, ok := unknown.(bool); ok && b {
+			isUnknown = true
+		}
+
+		// 1. ADDITION (+ key = value)
+		if valBefore == nil && (valAfter != nil || isUnknown) {
+			valStr := "(known after apply)"
+			if !isUnknown {
+				valStr = maskedValue(valAfter, sensAfter, indent)
+			}
+			lines = append(lines, Line{LineAdd, fmt.Sprintf("%s+ %s = %s", padding, key, valStr)})
+			return
+		}
+
+		// 2. DELETION (- key = value)
+		if valBefore != nil && valAfter == nil && !isUnknown {
+			valStr := maskedValue(valBefore, sensBefore, indent)
+			lines = append(lines, Line{LineDelete, fmt.Sprintf("%s- %s = %s", padding, key, valStr)})
+			return
+		}
+
+		// 3. MODIFICATION or UNCHANGED
+		// Handle Maps recursively
+		mapBefore, isMapBefore := valBefore.(map[string]interface{})
+		mapAfter, isMapAfter := valAfter.(map[string]interface{})
+
+		if isMapBefore && isMapAfter {
+			// Header: ~ key = {
+			lines = append(lines, Line{modKind, fmt.Sprintf("%s~ %s = {", padding, key)})
+
+			for _, k := range unionKeys(mapBefore, mapAfter) {
+				stringifyDiff(k, mapBefore[k], mapAfter[k], nil, childMark(sensBefore, k), childMark(sensAfter, k), indent+4, modKind)
+			}
+
+			// Footer: }
+			lines = append(lines, Line{modKind, fmt.Sprintf("%s}", padding)})
+			return
+		}
+
+		// Scalar Update, compared on the actual values so a changed secret still shows
+		if isUnknown || formatValue(valBefore, indent) != formatValue(valAfter, indent) {
+			sBefore := maskedValue(valBefore, sensBefore, indent)
+			sAfter := "(known after apply)"
+			if !isUnknown {
+				sAfter = maskedValue(valAfter, sensAfter, indent)
+			}
+			lines = append(lines, Line{modKind, fmt.Sprintf("%s~ %s = %s -> %s", padding, key, sBefore, sAfter)})
+		}
+	}
+
+	// Main execution based on Action - Check for replace first
+	action := "no-op"
+	if len(rc.Change.Actions) > 0 {
+		action = rc.Change.Actions[0]
+	}
+	if rc.IsReplace() {
+		action = "replace"
+	}
+
+	// e.g. # type.name will be created
+	object := rc.Type + "." + rc.Name
+	if rc.Deposed != "" {
+		object += fmt.Sprintf(" (deposed object %s)", rc.Deposed)
+	}
+	lines = append(lines, Line{LineHeader, fmt.Sprintf("# %s %s", object, actionPhrase(action))})
+
+	// Open Resource Block, e.g. "  + resource "type" "name" {"
+	// Also determine the kind used for modified attributes
+	var parentKind LineKind
+	switch action {
+	case "create":
+		parentKind = LineAdd
+	case "delete":
+		parentKind = LineDelete
+	case "update":
+		parentKind = LineUpdate
+	case "replace":
+		parentKind = LineReplace
+	default:
+		parentKind = LinePlain // No color
+	}
+	lines = append(lines, Line{parentKind, fmt.Sprintf("  %s resource %q %q {", getSymbol(action), rc.Type, rc.Name)})
+
+	// Iterate top level keys
+	for _, k := range unionKeys(rc.Change.Before, rc.Change.After, rc.Change.AfterUnknown) {
+		if k == "id" {
+			continue
+		}
+		stringifyDiff(k, rc.Change.Before[k], rc.Change.After[k], rc.Change.AfterUnknown[k],
+			childMark(rc.Change.BeforeSensitive, k), childMark(rc.Change.AfterSensitive, k), 2, parentKind)
+	}
+
+	lines = append(lines, Line{LinePlain, "}"})
+
+	return lines
+}
+
+func actionPhrase(action string) string {
+	switch action {
+	case "create":
+		return "will be created"
+	case "delete":
+		return "will be destroyed"
+	case "update":
+		return "will be updated in-place"
+	case "replace":
+		return "must be replaced"
+	case "read":
+		return "will be read during apply"
+	default:
+		return "will be imported"
+	}
+}
+
+// unionKeys returns the sorted union of the keys of the given maps.
+func unionKeys(maps ...map[string]interface{}) []string {
+	seen := make(map[string]bool)
+	for _, m := range maps {
+		for k := range m {
+			seen[k] = true
+		}
+	}
+
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func getSymbol(action string) string {
+	switch action {
+	case "create":
+		return "+"
+	case "delete":
+		return "-"
+	case "update":
+		return "~"
+	case "replace":
+		return "-/+"
+	default:
+		return ""
+	}
+}
+
+// IsMarked reports whether Terraform's sensitive or unknown marker covers any part
+// of a value, so a partly sensitive value is hidden entirely.
+func IsMarked(marker interface{}) bool {
+	switch m := marker.(type) {
+	case bool:
+		return m
+	case map[string]interface{}:
+		for _, v := range m {
+			if IsMarked(v) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, v := range m {
+			if IsMarked(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// childMark returns the part of a marker covering the attribute key: the whole
+// marker when it marks the parent as a whole.
+func childMark(marker interface{}, key string) interface{} {
+	switch m := marker.(type) {
+	case bool:
+		return m
+	case map[string]interface{}:
+		return m[key]
+	}
+	return nil
+}
+
+// maskedValue formats a value for display, unless its marker says it is sensitive.
+func maskedValue(v, sensitive interface{}, indent int) string {
+	if IsMarked(sensitive) {
+		return "(sensitive value)"
+	}
+	return formatValue(v, indent)
+}
+
+// Helper to format a value for display
+func formatValue(v interface{}, indent int) string {
+	if v == nil {
+		return "null"
+	}
+	switch val := v.(type) {
+	case map[string]interface{}:
+		var sb strings.Builder
+		sb.WriteString("{\n")
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		padding := strings.Repeat(" ", indent+2)
+		for _, k := range keys {
+			sb.WriteString(fmt.Sprintf("%s%s = %s\n", padding, k, formatValue(val[k], indent+2)))
+		}
+		sb.WriteString(strings.Repeat(" ", indent) + "}")
+		return sb.String()
+	case []interface{}:
+		if len(val) == 0 {
+			return "[]"
+		}
+		var sb strings.Builder
+		sb.WriteString("[\n")
+		padding := strings.Repeat(" ", indent+2)
+		for _, item := range val {
+			sb.WriteString(fmt.Sprintf("%s%s,\n", padding, formatValue(item, indent+2)))
+		}
+		sb.WriteString(strings.Repeat(" ", indent) + "]")
+		return sb.String()
+	case string:
+		return fmt.Sprintf("%q", val)
+	case json.Number:
+		return val.String()
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
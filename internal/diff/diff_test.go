@@ -0,0 +1,114 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+func TestLines_Update(t *testing.T) {
+	rc := models.ResourceChange{
+		Type: "res",
+		Name: "update",
+		Change: models.Change{
+			Actions:      []string{"update"},
+			Before:       map[string]interface{}{"id": "1", "name": "old", "gone": "x", "same": "s"},
+			After:        map[string]interface{}{"id": "1", "name": "new", "same": "s"},
+			AfterUnknown: map[string]interface{}{"arn": true},
+		},
+	}
+
+	expected := []Line{
+		{LineHeader, "# res.update will be updated in-place"},
+		{LineUpdate, `  ~ resource "res" "update" {`},
+		{LineAdd, "  + arn = (known after apply)"},
+		{LineDelete, `  - gone = "x"`},
+		{LineUpdate, `  ~ name = "old" -> "new"`},
+		{LinePlain, "}"},
+	}
+
+	got := Lines(rc)
+	if len(got) != len(expected) {
+		t.Fatalf("Lines() returned %d lines; want %d: %#v", len(got), len(expected), got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("line %d = %#v; want %#v", i, got[i], expected[i])
+		}
+	}
+}
+
+func TestLines_Sensitive(t *testing.T) {
+	rc := models.ResourceChange{
+		Type: "res",
+		Name: "db",
+		Change: models.Change{
+			Actions:         []string{"update"},
+			Before:          map[string]interface{}{"password": "old", "tags": map[string]interface{}{"token": "a"}},
+			After:           map[string]interface{}{"password": "new", "tags": map[string]interface{}{"token": "b"}, "key": "k"},
+			BeforeSensitive: map[string]interface{}{"password": true, "tags": true},
+			AfterSensitive:  map[string]interface{}{"password": true, "tags": true, "key": true},
+		},
+	}
+
+	expected := []Line{
+		{LineHeader, "# res.db will be updated in-place"},
+		{LineUpdate, `  ~ resource "res" "db" {`},
+		{LineAdd, "  + key = (sensitive value)"},
+		{LineUpdate, "  ~ password = (sensitive value) -> (sensitive value)"},
+		{LineUpdate, "  ~ tags = {"},
+		{LineUpdate, "      ~ token = (sensitive value) -> (sensitive value)"},
+		{LineUpdate, "  }"},
+		{LinePlain, "}"},
+	}
+
+	got := Lines(rc)
+	if len(got) != len(expected) {
+		t.Fatalf("Lines() returned %d lines; want %d: %#v", len(got), len(expected), got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("line %d = %#v; want %#v", i, got[i], expected[i])
+		}
+	}
+}
+
+func TestGetSymbol(t *testing.T) {
+	tests := []struct {
+		action   string
+		expected string
+	}{
+		{"create", "+"},
+		{"delete", "-"},
+		{"update", "~"},
+		{"replace", "-/+"},
+		{"unknown", ""},
+	}
+
+	for _, tt := range tests {
+		got := getSymbol(tt.action)
+		if got != tt.expected {
+			t.Errorf("getSymbol(%q) = %q; want %q", tt.action, got, tt.expected)
+		}
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    interface{}
+		expected string // Partial match check usually easier for complex strings
+	}{
+		{"String", "hello", "\"hello\""},
+		{"Int", 123, "123"},
+		{"Nil", nil, "null"},
+		{"List", []interface{}{"a", "b"}, "[\n  \"a\",\n  \"b\",\n]"},
+	}
+
+	for _, tt := range tests {
+		got := formatValue(tt.input, 0)
+		if got != tt.expected {
+			t.Errorf("formatValue(%s) = %q; want %q", tt.name, got, tt.expected)
+		}
+	}
+}
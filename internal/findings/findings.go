@@ -0,0 +1,46 @@
+package findings
+
+import (
+	"github.com/bernard-sh/tfs/internal/config"
+	"github.com/bernard-sh/tfs/internal/models"
+	"github.com/bernard-sh/tfs/internal/risk"
+)
+
+// Result is the outcome of one risk rule against one resource change.
+type Result struct {
+	Rule     risk.Rule
+	Resource models.ResourceChange
+	Message  string           // Empty when the rule passed
+	Location *config.Location // Declaration of the resource, if found
+}
+
+func (r Result) Failed() bool {
+	return r.Message != ""
+}
+
+// Evaluate runs every risk rule against every changed resource of the plan.
+// root is the directory of the root module, used to locate declarations, and
+// repoRoot the directory their paths are relative to ("" for root): code scanning
+// places annotations by their path in the repository.
+func Evaluate(plan models.TfPlan, root, repoRoot string) []Result {
+	var results []Result
+	index := config.NewIndex(plan.Configuration, root, repoRoot)
+	lists := models.Partition(plan)
+	for i := 0; i < models.NumCategories; i++ {
+		for _, rc := range lists[models.Category(i)] {
+			var loc *config.Location
+			if l, ok := index.Find(rc); ok {
+				loc = &l
+			}
+			for _, rule := range risk.Rules {
+				results = append(results, Result{
+					Rule:     rule,
+					Resource: rc,
+					Message:  rule.Check(rc),
+					Location: loc,
+				})
+			}
+		}
+	}
+	return results
+}
@@ -0,0 +1,90 @@
+package findings
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+func testPlan(t *testing.T) (models.TfPlan, string) {
+	repo := t.TempDir()
+	root := filepath.Join(repo, "infra")
+	if err := os.Mkdir(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "main.tf"), []byte("\nresource \"aws_s3_bucket\" \"logs\" {\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	plan := models.TfPlan{ResourceChanges: []models.ResourceChange{
+		{Address: "aws_s3_bucket.logs", Mode: "managed", Type: "aws_s3_bucket", Name: "logs", Change: models.Change{Actions: []string{"delete"}}},
+		{Address: "aws_instance.web", Mode: "managed", Type: "aws_instance", Name: "web", Change: models.Change{Actions: []string{"create"}}},
+	}}
+	return plan, root
+}
+
+func testResults(t *testing.T) []Result {
+	plan, root := testPlan(t)
+	return Evaluate(plan, root, "")
+}
+
+func TestEvaluate_RepoRoot(t *testing.T) {
+	plan, root := testPlan(t)
+	for _, r := range Evaluate(plan, root, filepath.Dir(root)) {
+		if r.Resource.Address == "aws_s3_bucket.logs" && (r.Location == nil || r.Location.String() != "infra/main.tf:2") {
+			t.Errorf("%s: Location = %v; want infra/main.tf:2", r.Rule.ID, r.Location)
+		}
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, testResults(t)); err != nil {
+		t.Fatalf("WriteJUnit failed: %v", err)
+	}
+
+	var doc junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Output is not valid XML: %v\n%s", err, buf.String())
+	}
+
+	// 2 resources x every rule, the bucket fails "destroyed" and "stateful"
+	if doc.Tests != 2*len(doc.Suites) || doc.Failures != 2 {
+		t.Errorf("Got %d tests and %d failures, want %d and 2", doc.Tests, doc.Failures, 2*len(doc.Suites))
+	}
+	// Resources follow the tab order: the create comes before the destroy
+	tc := doc.Suites[0].TestCases[1]
+	if tc.Name != "aws_s3_bucket.logs" || tc.Failure == nil || tc.File != "main.tf" || tc.Line != 2 {
+		t.Errorf("Unexpected bucket test case: %+v", tc)
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, testResults(t)); err != nil {
+		t.Fatalf("WriteSARIF failed: %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	results := log.Runs[0].Results
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	for _, r := range results {
+		loc := r.Locations[0]
+		if loc.PhysicalLocation == nil || loc.PhysicalLocation.ArtifactLocation.URI != "main.tf" || loc.PhysicalLocation.Region.StartLine != 2 {
+			t.Errorf("%s: unexpected location %+v", r.RuleID, loc.PhysicalLocation)
+		}
+		if r.Level != "error" {
+			t.Errorf("%s: level = %q; want error", r.RuleID, r.Level)
+		}
+	}
+}
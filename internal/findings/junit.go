@@ -0,0 +1,86 @@
+package findings
+
+import (
+	"encoding/xml"
+	"io"
+
+	"github.com/bernard-sh/tfs/internal/risk"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the results as JUnit XML: one test suite per rule,
+// one test case per resource, failing when the rule matched.
+func WriteJUnit(w io.Writer, results []Result) error {
+	doc := junitTestSuites{Name: "tfs"}
+
+	suites := make(map[string]*junitTestSuite)
+	for _, rule := range risk.Rules {
+		doc.Suites = append(doc.Suites, junitTestSuite{Name: rule.ID + " " + rule.Name})
+	}
+	for i, rule := range risk.Rules {
+		suites[rule.ID] = &doc.Suites[i]
+	}
+
+	for _, r := range results {
+		suite := suites[r.Rule.ID]
+		tc := junitTestCase{
+			Name:      r.Resource.Address,
+			ClassName: r.Rule.ID + "." + r.Rule.Name,
+		}
+		if r.Location != nil {
+			tc.File = r.Location.File
+			tc.Line = r.Location.Line
+		}
+		if r.Failed() {
+			tc.Failure = &junitFailure{
+				Message: r.Message,
+				Type:    r.Rule.Severity.String(),
+				Text:    r.Rule.Description,
+			}
+			suite.Failures++
+			doc.Failures++
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+		suite.Tests++
+		doc.Tests++
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,138 @@
+package findings
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/bernard-sh/tfs/internal/risk"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// sarifLevel maps a severity to the SARIF result levels shown by code-scanning UIs.
+func sarifLevel(s risk.Severity) string {
+	switch {
+	case s >= risk.SeverityHigh:
+		return "error"
+	case s >= risk.SeverityMedium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// WriteSARIF writes the failed results as a SARIF 2.1.0 log. Results point at the
+// resource declaration when it was found, and always carry the resource address.
+func WriteSARIF(w io.Writer, results []Result) error {
+	driver := sarifDriver{
+		Name:           "tfs",
+		InformationURI: "https://github.com/bernard-sh/tfs",
+	}
+	ruleIndex := make(map[string]int)
+	for i, rule := range risk.Rules {
+		ruleIndex[rule.ID] = i
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   rule.ID,
+			Name:                 rule.Name,
+			ShortDescription:     sarifMessage{Text: rule.Description},
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevel(rule.Severity)},
+		})
+	}
+
+	run := sarifRun{Tool: sarifTool{Driver: driver}, Results: []sarifResult{}}
+	for _, r := range results {
+		if !r.Failed() {
+			continue
+		}
+		loc := sarifLocation{
+			LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: r.Resource.Address, Kind: "resource"}},
+		}
+		if r.Location != nil {
+			loc.PhysicalLocation = &sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: r.Location.File},
+				Region:           sarifRegion{StartLine: r.Location.Line},
+			}
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    r.Rule.ID,
+			RuleIndex: ruleIndex[r.Rule.ID],
+			Level:     sarifLevel(r.Rule.Severity),
+			Message:   sarifMessage{Text: r.Message},
+			Locations: []sarifLocation{loc},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{Version: sarifVersion, Schema: sarifSchema, Runs: []sarifRun{run}})
+}
@@ -0,0 +1,246 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bernard-sh/tfs/internal/diff"
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+// Effects of a destroy or replace on the resources and outputs depending on it.
+const (
+	EffectNone    = ""        // The plan leaves it as is
+	EffectReplace = "replace" // Replaced as well
+	EffectDestroy = "destroy" // Destroyed as well
+	EffectUnknown = "unknown" // Some of its values will only be known after apply
+)
+
+// Impact is a resource or root module output depending, directly or not, on a
+// destroyed or replaced resource.
+type Impact struct {
+	Address string // Resource address, or output.<name>
+	Output  bool
+	Direct  bool // References the resource itself rather than one of its dependents
+	Effect  string
+}
+
+// Blast is the blast radius of a destroyed or replaced resource.
+type Blast struct {
+	Address string
+	Action  string   // "destroy" or "replace"
+	Impacts []Impact // Resources sorted by address, then outputs by name
+
+	// Unknown is set when the plan source could not read the configuration: only the
+	// dependencies recorded in the prior state are known.
+	Unknown bool
+	// Locals is set when the configuration references locals, which the plan does not
+	// describe: dependents referencing the resource through a local are missing.
+	Locals bool
+}
+
+// Affected counts the impacts the plan replaces, destroys or makes unknown.
+func (b Blast) Affected() int {
+	n := 0
+	for _, i := range b.Impacts {
+		if i.Effect != EffectNone {
+			n++
+		}
+	}
+	return n
+}
+
+// BlastRadius returns the blast radius of every resource the plan destroys or
+// replaces, by address.
+func BlastRadius(plan models.TfPlan) map[string]Blast {
+	g := Build(plan)
+	next := g.successors()
+	changes := make(map[string]models.ResourceChange, len(plan.ResourceChanges))
+	for _, rc := range plan.ResourceChanges {
+		if rc.Deposed == "" {
+			changes[rc.Address] = rc
+		}
+	}
+	outputs := outputDeps(plan, g)
+	unknown := plan.Lacks(models.SectionConfiguration)
+	locals := usesLocals(plan.Configuration.RootModule)
+
+	blasts := make(map[string]Blast)
+	for _, n := range g.Nodes {
+		if n.Action != EffectDestroy && n.Action != EffectReplace {
+			continue
+		}
+		b := Blast{Address: n.Address, Action: n.Action, Unknown: unknown, Locals: locals}
+
+		direct := make(map[string]bool)
+		for _, to := range next[n.Address] {
+			direct[to] = true
+		}
+		reached := map[string]bool{n.Address: true}
+		queue := []string{n.Address}
+		for len(queue) > 0 {
+			cur := queue[0]
+			queue = queue[1:]
+			for _, to := range next[cur] {
+				if reached[to] {
+					continue
+				}
+				reached[to] = true
+				queue = append(queue, to)
+				b.Impacts = append(b.Impacts, Impact{Address: to, Direct: direct[to], Effect: resourceEffect(changes[to])})
+			}
+		}
+		sort.Slice(b.Impacts, func(i, j int) bool { return b.Impacts[i].Address < b.Impacts[j].Address })
+
+		names := make([]string, 0, len(outputs))
+		for name := range outputs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			isDirect, depends := false, false
+			for _, dep := range outputs[name] {
+				isDirect = isDirect || dep == n.Address
+				depends = depends || reached[dep]
+			}
+			if depends {
+				b.Impacts = append(b.Impacts, Impact{Address: "output." + name, Output: true, Direct: isDirect, Effect: outputEffect(plan.OutputChanges[name])})
+			}
+		}
+		blasts[n.Address] = b
+	}
+	return blasts
+}
+
+// outputDeps maps the root module outputs to the resource instances they reference.
+func outputDeps(plan models.TfPlan, g Graph) map[string][]string {
+	instances := make(map[string][]string)
+	for _, n := range g.Nodes {
+		c := configAddress(n.Address)
+		instances[c] = append(instances[c], n.Address)
+	}
+
+	root := &scope{module: plan.Configuration.RootModule}
+	deps := make(map[string][]string)
+	for name, output := range plan.Configuration.RootModule.Outputs {
+		refs := append(references(output.Expression), output.DependsOn...)
+		for _, c := range root.resolve(refs, 0) {
+			deps[name] = append(deps[name], instances[c]...)
+		}
+	}
+	return deps
+}
+
+// usesLocals reports whether anything in the module or its children references a
+// local value.
+func usesLocals(m models.ConfigModule) bool {
+	var refs []string
+	for _, r := range m.Resources {
+		refs = append(refs, references(r.Expressions)...)
+		refs = append(refs, references(r.CountExpression)...)
+		refs = append(refs, references(r.ForEachExpression)...)
+	}
+	for _, output := range m.Outputs {
+		refs = append(refs, references(output.Expression)...)
+	}
+	for _, call := range m.ModuleCalls {
+		if usesLocals(call.Module) {
+			return true
+		}
+		refs = append(refs, references(call.Expressions)...)
+	}
+	for _, ref := range refs {
+		if strings.HasPrefix(ref, "local.") {
+			return true
+		}
+	}
+	return false
+}
+
+func resourceEffect(rc models.ResourceChange) string {
+	if rc.Address == "" || rc.IsNoOp() {
+		return EffectNone // Unchanged, or only in the prior state
+	}
+	switch models.Categorize(rc) {
+	case models.CategoryReplace:
+		return EffectReplace
+	case models.CategoryDestroy:
+		return EffectDestroy
+	}
+	if anyTrue(rc.Change.AfterUnknown) {
+		return EffectUnknown
+	}
+	return EffectNone
+}
+
+func outputEffect(oc models.OutputChange) string {
+	switch {
+	case len(oc.Actions) == 1 && oc.Actions[0] == "delete":
+		return EffectDestroy
+	case anyTrue(oc.AfterUnknown):
+		return EffectUnknown
+	default:
+		return EffectNone
+	}
+}
+
+// anyTrue reports whether an after_unknown marker flags any part of a value.
+func anyTrue(v interface{}) bool {
+	switch val := v.(type) {
+	case bool:
+		return val
+	case map[string]interface{}:
+		for _, item := range val {
+			if anyTrue(item) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range val {
+			if anyTrue(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Lines describes the blast radius as diff lines: every resource and output
+// depending on the resource, and what the plan does to them.
+func (b Blast) Lines() []diff.Line {
+	var lines []diff.Line
+	switch {
+	case b.Unknown:
+		lines = append(lines, diff.Line{Kind: diff.LineHeader, Text: "# Blast radius: dependencies unknown, the plan has no configuration"})
+		if len(b.Impacts) > 0 {
+			lines = append(lines, diff.Line{Kind: diff.LinePlain, Text: "  Dependents recorded in the prior state:"})
+		}
+	case len(b.Impacts) == 0 && b.Locals:
+		lines = append(lines, diff.Line{Kind: diff.LineHeader, Text: "# Blast radius: no dependents found for " + b.Address})
+	case len(b.Impacts) == 0:
+		return []diff.Line{{Kind: diff.LineHeader, Text: "# Blast radius: nothing depends on " + b.Address}}
+	default:
+		lines = append(lines, diff.Line{Kind: diff.LineHeader, Text: fmt.Sprintf("# Blast radius: %d dependents, %d replaced, destroyed or made unknown", len(b.Impacts), b.Affected())})
+	}
+
+	for _, i := range b.Impacts {
+		kind, symbol, effect := diff.LinePlain, "   ", "unchanged"
+		switch i.Effect {
+		case EffectReplace:
+			kind, symbol, effect = diff.LineReplace, "-/+", "replaced"
+		case EffectDestroy:
+			kind, symbol, effect = diff.LineDelete, " - ", "destroyed"
+		case EffectUnknown:
+			kind, symbol, effect = diff.LineUpdate, " ~ ", "known after apply"
+		}
+		if !i.Direct {
+			effect += ", through other dependents"
+		}
+		lines = append(lines, diff.Line{Kind: kind, Text: fmt.Sprintf("  %s %s (%s)", symbol, i.Address, effect)})
+	}
+	if b.Locals && !b.Unknown {
+		lines = append(lines, diff.Line{Kind: diff.LinePlain, Text: "  Dependents referencing it through locals are not recorded in the plan and not shown"})
+	}
+	return lines
+}
@@ -0,0 +1,80 @@
+package graph
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Colors of the actions, the TUI's tab colors. Unchanged nodes are grey.
+var colors = map[string]string{
+	"create":  "#00AF00",
+	"destroy": "#D70000",
+	"replace": "#FFAF00",
+	"update":  "#AE00FF",
+	"import":  "#00AFFF",
+	"":        "#414868",
+}
+
+// WriteDOT writes the graph in Graphviz DOT, e.g. for `dot -Tsvg`. Nodes are colored
+// by action and indirect edges are dashed.
+func WriteDOT(w io.Writer, g Graph) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph tfs {")
+	fmt.Fprintln(bw, "  rankdir=LR;")
+	fmt.Fprintln(bw, `  node [shape=box, style="rounded,filled", fontname="Helvetica", fontcolor="#FFFFFF"];`)
+	for _, n := range g.Nodes {
+		fmt.Fprintf(bw, "  %s [fillcolor=%q];\n", dotID(n.Address), colors[n.Action])
+	}
+	for _, e := range g.Edges {
+		attrs := ""
+		if e.Indirect {
+			attrs = " [style=dashed]"
+		}
+		fmt.Fprintf(bw, "  %s -> %s%s;\n", dotID(e.From), dotID(e.To), attrs)
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// dotID quotes an address as a DOT identifier.
+func dotID(address string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(address) + `"`
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart, which GitHub and GitLab
+// render in Markdown. Nodes are colored by action and indirect edges are dotted.
+func WriteMermaid(w io.Writer, g Graph) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "flowchart LR")
+	ids := make(map[string]string, len(g.Nodes))
+	for i, n := range g.Nodes {
+		ids[n.Address] = fmt.Sprintf("n%d", i)
+		class := n.Action
+		if class == "" {
+			class = "unchanged"
+		}
+		fmt.Fprintf(bw, "  %s[\"%s\"]:::%s\n", ids[n.Address], mermaidLabel(n.Address), class)
+	}
+	for _, e := range g.Edges {
+		arrow := "-->"
+		if e.Indirect {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(bw, "  %s %s %s\n", ids[e.From], arrow, ids[e.To])
+	}
+	for _, action := range []string{"create", "destroy", "replace", "update", "import", ""} {
+		class := action
+		if class == "" {
+			class = "unchanged"
+		}
+		fmt.Fprintf(bw, "  classDef %s fill:%s,stroke:%s,color:#FFFFFF\n", class, colors[action], colors[action])
+	}
+	return bw.Flush()
+}
+
+// mermaidLabel escapes the characters ending a quoted Mermaid label.
+func mermaidLabel(address string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(address)
+}
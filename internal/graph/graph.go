@@ -0,0 +1,420 @@
+// Package graph builds the dependency graph of a plan's resources, to show how a
+// change cascades: replacing a subnet replaces the instances placed in it.
+//
+// Dependencies come from the references in the plan's configuration, followed
+// through module inputs and outputs, and from the depends_on recorded in the prior
+// state for resources no longer in the configuration.
+package graph
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+// Node is a resource instance.
+type Node struct {
+	Address string
+	Action  string // Category of its change, e.g. "replace"; "" when unchanged
+}
+
+// Changed reports whether the plan changes the resource.
+func (n Node) Changed() bool {
+	return n.Action != ""
+}
+
+// Edge is a dependency: To depends on From, so changes to From can cascade into To.
+type Edge struct {
+	From, To string
+	Indirect bool // Through unchanged resources collapsed by Collapse
+}
+
+// Graph holds the nodes sorted by address and the edges sorted by From, then To.
+type Graph struct {
+	Nodes []Node
+	Edges []Edge
+}
+
+// Build returns the dependency graph of every resource of the plan, changed or not.
+func Build(plan models.TfPlan) Graph {
+	actions := make(map[string]string)
+	for _, rc := range plan.ResourceChanges {
+		if rc.Deposed != "" {
+			continue // Left over by a replacement, nothing depends on it
+		}
+		action := ""
+		if !rc.IsNoOp() && !isRead(rc) {
+			action = models.Categorize(rc).String()
+		}
+		actions[rc.Address] = action
+	}
+	deps := make(map[string]map[string]bool)
+	if plan.PriorState != nil {
+		addState(plan.PriorState.Values.RootModule, actions, deps)
+	}
+	root := &scope{module: plan.Configuration.RootModule}
+	root.addResources(deps, nil)
+
+	// Configuration addresses cover every instance of a resource
+	instances := make(map[string][]string)
+	var g Graph
+	for address, action := range actions {
+		g.Nodes = append(g.Nodes, Node{Address: address, Action: action})
+		c := configAddress(address)
+		instances[c] = append(instances[c], address)
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].Address < g.Nodes[j].Address })
+
+	seen := make(map[Edge]bool)
+	for _, n := range g.Nodes {
+		for dep := range deps[configAddress(n.Address)] {
+			for _, from := range instances[dep] {
+				e := Edge{From: from, To: n.Address}
+				if from != n.Address && !seen[e] {
+					seen[e] = true
+					g.Edges = append(g.Edges, e)
+				}
+			}
+		}
+	}
+	sortEdges(g.Edges)
+	return g
+}
+
+// isRead reports whether the change only reads a data source.
+func isRead(rc models.ResourceChange) bool {
+	return len(rc.Change.Actions) == 1 && rc.Change.Actions[0] == "read"
+}
+
+// addState adds the resources of the state module m and its children as unchanged
+// nodes, unless the plan changes them, with their recorded dependencies.
+func addState(m models.StateModule, actions map[string]string, deps map[string]map[string]bool) {
+	for _, r := range m.Resources {
+		if _, ok := actions[r.Address]; !ok {
+			actions[r.Address] = ""
+		}
+		for _, dep := range r.DependsOn {
+			addDep(deps, configAddress(r.Address), configAddress(dep))
+		}
+	}
+	for _, child := range m.ChildModules {
+		addState(child, actions, deps)
+	}
+}
+
+func addDep(deps map[string]map[string]bool, from, to string) {
+	if deps[from] == nil {
+		deps[from] = make(map[string]bool)
+	}
+	deps[from][to] = true
+}
+
+// scope is a module of the configuration, where references are resolved.
+type scope struct {
+	prefix string // Module address prefix of its resources, e.g. "module.vpc."
+	module models.ConfigModule
+	call   *models.ModuleCall // Call instantiating the module, nil for the root module
+	parent *scope
+}
+
+// addResources records the dependencies of the resources declared in s and its child
+// modules. inherited are the dependencies of the calls to s, from their depends_on.
+func (s *scope) addResources(deps map[string]map[string]bool, inherited []string) {
+	for _, r := range s.module.Resources {
+		from := s.prefix + r.Address
+		refs := references(r.Expressions)
+		refs = append(refs, references(r.CountExpression)...)
+		refs = append(refs, references(r.ForEachExpression)...)
+		refs = append(refs, r.DependsOn...)
+		for _, to := range s.resolve(refs, 0) {
+			addDep(deps, from, to)
+		}
+		for _, to := range inherited {
+			addDep(deps, from, to)
+		}
+	}
+
+	names := make([]string, 0, len(s.module.ModuleCalls))
+	for name := range s.module.ModuleCalls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		call := s.module.ModuleCalls[name]
+		child := &scope{prefix: s.prefix + "module." + name + ".", module: call.Module, call: &call, parent: s}
+		child.addResources(deps, append(s.resolve(call.DependsOn, 0), inherited...))
+	}
+}
+
+// maxDepth bounds reference resolution through module inputs and outputs.
+const maxDepth = 32
+
+// resolve maps references such as "aws_subnet.a.id", "var.subnet_id" or
+// "module.vpc.subnet_ids" to the configuration addresses of the resources they end
+// up pointing at.
+func (s *scope) resolve(refs []string, depth int) []string {
+	if depth > maxDepth {
+		return nil
+	}
+	var out []string
+	for _, ref := range refs {
+		parts := strings.Split(configAddress(ref), ".")
+		switch parts[0] {
+		case "var":
+			// An input: whatever the calling module passes to it
+			if s.call != nil && len(parts) > 1 {
+				out = append(out, s.parent.resolve(references(s.call.Expressions[parts[1]]), depth+1)...)
+			}
+		case "module":
+			if len(parts) < 2 {
+				continue
+			}
+			call, ok := s.module.ModuleCalls[parts[1]]
+			if !ok {
+				continue
+			}
+			child := &scope{prefix: s.prefix + "module." + parts[1] + ".", module: call.Module, call: &call, parent: s}
+			if len(parts) > 2 {
+				if output, ok := call.Module.Outputs[parts[2]]; ok {
+					out = append(out, child.resolve(references(output.Expression), depth+1)...)
+					out = append(out, child.resolve(output.DependsOn, depth+1)...)
+					continue
+				}
+			}
+			// The whole module, e.g. depends_on = [module.vpc], unless listed along with
+			// one of its outputs, as Terraform does for module.vpc.subnet_ids
+			if !hasPrefix(refs, "module."+parts[1]+".") {
+				out = append(out, child.all()...)
+			}
+		case "data":
+			if len(parts) > 2 {
+				out = append(out, s.prefix+strings.Join(parts[:3], "."))
+			}
+		case "local", "each", "count", "path", "terraform", "self":
+			// Locals are not part of the plan's configuration; the others are not resources
+		default:
+			if len(parts) > 1 {
+				out = append(out, s.prefix+strings.Join(parts[:2], "."))
+			}
+		}
+	}
+	return out
+}
+
+// hasPrefix reports whether any of refs starts with prefix.
+func hasPrefix(refs []string, prefix string) bool {
+	for _, ref := range refs {
+		if strings.HasPrefix(configAddress(ref), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// all lists the resources of the module and its descendants.
+func (s *scope) all() []string {
+	var out []string
+	for _, r := range s.module.Resources {
+		out = append(out, s.prefix+r.Address)
+	}
+	for name, call := range s.module.ModuleCalls {
+		child := &scope{prefix: s.prefix + "module." + name + ".", module: call.Module}
+		out = append(out, child.all()...)
+	}
+	return out
+}
+
+// references collects the "references" lists of an expression, or of a map of
+// expressions including nested blocks.
+func references(v interface{}) []string {
+	var refs []string
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for key, item := range val {
+			switch key {
+			case "references":
+				list, _ := item.([]interface{})
+				for _, ref := range list {
+					if s, ok := ref.(string); ok {
+						refs = append(refs, s)
+					}
+				}
+			case "constant_value":
+				// A literal, even when shaped like an expression
+			default:
+				refs = append(refs, references(item)...)
+			}
+		}
+	case []interface{}:
+		for _, item := range val {
+			refs = append(refs, references(item)...)
+		}
+	}
+	return refs
+}
+
+// configAddress drops the instance keys of an address, e.g.
+// module.a["x"].aws_instance.b[0] becomes module.a.aws_instance.b.
+func configAddress(address string) string {
+	var sb strings.Builder
+	depth, inQuotes := 0, false
+	for i := 0; i < len(address); i++ {
+		c := address[i]
+		switch {
+		case inQuotes:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inQuotes = false
+			}
+		case c == '"' && depth > 0:
+			inQuotes = true
+		case c == '[':
+			depth++
+		case c == ']' && depth > 0:
+			depth--
+		case depth == 0:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+func sortEdges(edges []Edge) {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+}
+
+// Collapse drops the unchanged nodes. Changed resources depending on each other
+// through unchanged ones stay connected, by an indirect edge.
+func (g Graph) Collapse() Graph {
+	changed := make(map[string]bool)
+	var out Graph
+	for _, n := range g.Nodes {
+		if n.Changed() {
+			changed[n.Address] = true
+			out.Nodes = append(out.Nodes, n)
+		}
+	}
+
+	next := g.successors()
+	for _, n := range out.Nodes {
+		direct := make(map[string]bool)
+		for _, to := range next[n.Address] {
+			if changed[to] {
+				direct[to] = true
+				out.Edges = append(out.Edges, Edge{From: n.Address, To: to})
+			}
+		}
+		// Walk the unchanged resources in between
+		visited := map[string]bool{n.Address: true}
+		queue := []string{}
+		for _, to := range next[n.Address] {
+			if !changed[to] {
+				visited[to] = true
+				queue = append(queue, to)
+			}
+		}
+		for len(queue) > 0 {
+			cur := queue[0]
+			queue = queue[1:]
+			for _, to := range next[cur] {
+				if visited[to] {
+					continue
+				}
+				visited[to] = true
+				if !changed[to] {
+					queue = append(queue, to)
+				} else if !direct[to] {
+					out.Edges = append(out.Edges, Edge{From: n.Address, To: to, Indirect: true})
+				}
+			}
+		}
+	}
+	sortEdges(out.Edges)
+	return out
+}
+
+// successors maps each node to the nodes depending on it.
+func (g Graph) successors() map[string][]string {
+	next := make(map[string][]string)
+	for _, e := range g.Edges {
+		next[e.From] = append(next[e.From], e.To)
+	}
+	return next
+}
+
+// Layers ranks the nodes for drawing: every node is placed one layer after the
+// last of its dependencies. Within a layer, nodes are ordered by the average
+// position of their dependencies, to limit crossing edges. Nodes on a dependency
+// cycle, which Terraform would reject, are left in the layer reached so far.
+func (g Graph) Layers() [][]string {
+	next := g.successors()
+	indegree := make(map[string]int)
+	for _, e := range g.Edges {
+		indegree[e.To]++
+	}
+
+	rank := make(map[string]int)
+	var queue []string
+	for _, n := range g.Nodes {
+		if indegree[n.Address] == 0 {
+			queue = append(queue, n.Address)
+		}
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, to := range next[cur] {
+			if rank[cur]+1 > rank[to] {
+				rank[to] = rank[cur] + 1
+			}
+			indegree[to]--
+			if indegree[to] == 0 {
+				queue = append(queue, to)
+			}
+		}
+	}
+
+	var layers [][]string
+	for _, n := range g.Nodes {
+		r := rank[n.Address]
+		for len(layers) <= r {
+			layers = append(layers, nil)
+		}
+		layers[r] = append(layers[r], n.Address)
+	}
+
+	prev := make(map[string][]string)
+	for _, e := range g.Edges {
+		prev[e.To] = append(prev[e.To], e.From)
+	}
+	position := make(map[string]float64)
+	for _, layer := range layers {
+		weight := make(map[string]float64)
+		for i, address := range layer {
+			weight[address] = float64(i) // Keep the address order without placed dependencies
+			var sum float64
+			count := 0
+			for _, from := range prev[address] {
+				if p, ok := position[from]; ok {
+					sum += p
+					count++
+				}
+			}
+			if count > 0 {
+				weight[address] = sum / float64(count)
+			}
+		}
+		sort.SliceStable(layer, func(i, j int) bool { return weight[layer[i]] < weight[layer[j]] })
+		for i, address := range layer {
+			position[address] = float64(i)
+		}
+	}
+	return layers
+}
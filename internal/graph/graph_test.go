@@ -0,0 +1,246 @@
+package graph
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+// testPlan replaces a subnet used by two instances, one of them in a module through
+// an input variable, and a load balancer depending on the module through an output.
+const testPlan = `{
+	"resource_changes": [
+		{"address": "aws_subnet.a", "type": "aws_subnet", "name": "a", "change": {"actions": ["delete", "create"]}},
+		{"address": "aws_instance.web[0]", "type": "aws_instance", "name": "web", "change": {"actions": ["delete", "create"]}},
+		{"address": "aws_instance.web[1]", "type": "aws_instance", "name": "web", "change": {"actions": ["delete", "create"]}},
+		{"address": "module.app.aws_instance.app", "module_address": "module.app", "type": "aws_instance", "name": "app", "change": {"actions": ["no-op"]}},
+		{"address": "module.app.aws_iam_role.app", "module_address": "module.app", "type": "aws_iam_role", "name": "app", "change": {"actions": ["create"]}},
+		{"address": "aws_lb.main", "type": "aws_lb", "name": "main", "change": {"actions": ["update"]}}
+	],
+	"output_changes": {"lb_dns": {"actions": ["update"], "before": "old", "after": null, "after_unknown": true}},
+	"prior_state": {"values": {"root_module": {"resources": [
+		{"address": "aws_eip.old", "mode": "managed", "type": "aws_eip", "name": "old", "depends_on": ["aws_instance.web"]}
+	]}}},
+	"configuration": {"root_module": {
+		"resources": [
+			{"address": "aws_subnet.a", "mode": "managed", "type": "aws_subnet", "name": "a"},
+			{"address": "aws_instance.web", "mode": "managed", "type": "aws_instance", "name": "web",
+				"expressions": {"subnet_id": {"references": ["aws_subnet.a.id", "aws_subnet.a"]}},
+				"count_expression": {"constant_value": 2}},
+			{"address": "aws_lb.main", "mode": "managed", "type": "aws_lb", "name": "main",
+				"expressions": {"subnets": {"references": ["module.app.subnet", "module.app"]}}}
+		],
+		"outputs": {"lb_dns": {"expression": {"references": ["aws_lb.main.dns_name", "aws_lb.main"]}}},
+		"module_calls": {"app": {
+			"source": "./app",
+			"expressions": {"subnet_id": {"references": ["aws_subnet.a.id", "aws_subnet.a"]}},
+			"module": {
+				"resources": [
+					{"address": "aws_instance.app", "mode": "managed", "type": "aws_instance", "name": "app",
+						"expressions": {"network_interface": [{"subnet_id": {"references": ["var.subnet_id"]}}]}},
+					{"address": "aws_iam_role.app", "mode": "managed", "type": "aws_iam_role", "name": "app"}
+				],
+				"outputs": {"subnet": {"expression": {"references": ["aws_instance.app.subnet_id", "aws_instance.app"]}}}
+			}
+		}}
+	}}
+}`
+
+func loadTestPlan(t *testing.T) models.TfPlan {
+	t.Helper()
+	plan, err := models.ParsePlan(testPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return plan
+}
+
+func TestBuild(t *testing.T) {
+	g := Build(loadTestPlan(t))
+
+	wantNodes := []Node{
+		{"aws_eip.old", ""},
+		{"aws_instance.web[0]", "replace"},
+		{"aws_instance.web[1]", "replace"},
+		{"aws_lb.main", "update"},
+		{"aws_subnet.a", "replace"},
+		{"module.app.aws_iam_role.app", "create"},
+		{"module.app.aws_instance.app", ""},
+	}
+	if !reflect.DeepEqual(g.Nodes, wantNodes) {
+		t.Errorf("Nodes = %v, want %v", g.Nodes, wantNodes)
+	}
+	wantEdges := []Edge{
+		{From: "aws_instance.web[0]", To: "aws_eip.old"},
+		{From: "aws_instance.web[1]", To: "aws_eip.old"},
+		{From: "aws_subnet.a", To: "aws_instance.web[0]"},
+		{From: "aws_subnet.a", To: "aws_instance.web[1]"},
+		{From: "aws_subnet.a", To: "module.app.aws_instance.app"},
+		{From: "module.app.aws_instance.app", To: "aws_lb.main"},
+	}
+	if !reflect.DeepEqual(g.Edges, wantEdges) {
+		t.Errorf("Edges = %v, want %v", g.Edges, wantEdges)
+	}
+}
+
+func TestCollapse(t *testing.T) {
+	g := Build(loadTestPlan(t)).Collapse()
+
+	if len(g.Nodes) != 5 {
+		t.Errorf("Collapse() kept %v, want the 5 changed resources", g.Nodes)
+	}
+	wantEdges := []Edge{
+		{From: "aws_subnet.a", To: "aws_instance.web[0]"},
+		{From: "aws_subnet.a", To: "aws_instance.web[1]"},
+		{From: "aws_subnet.a", To: "aws_lb.main", Indirect: true},
+	}
+	if !reflect.DeepEqual(g.Edges, wantEdges) {
+		t.Errorf("Edges = %v, want %v", g.Edges, wantEdges)
+	}
+}
+
+func TestLayers(t *testing.T) {
+	layers := Build(loadTestPlan(t)).Layers()
+	want := [][]string{
+		{"aws_subnet.a", "module.app.aws_iam_role.app"},
+		{"aws_instance.web[0]", "aws_instance.web[1]", "module.app.aws_instance.app"},
+		{"aws_eip.old", "aws_lb.main"},
+	}
+	if !reflect.DeepEqual(layers, want) {
+		t.Errorf("Layers() = %v, want %v", layers, want)
+	}
+}
+
+func TestConfigAddress(t *testing.T) {
+	tests := map[string]string{
+		"aws_instance.web":                               "aws_instance.web",
+		`module.a["x.y"].aws_instance.b[0]`:              "module.a.aws_instance.b",
+		`aws_instance.web["a]b"].id`:                     "aws_instance.web.id",
+		`module.a[0].module.b["k\"]"].data.aws_ami.x[1]`: "module.a.module.b.data.aws_ami.x",
+	}
+	for in, want := range tests {
+		if got := configAddress(in); got != want {
+			t.Errorf("configAddress(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	g := Build(loadTestPlan(t)).Collapse()
+
+	var dot bytes.Buffer
+	if err := WriteDOT(&dot, g); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"aws_subnet.a" [fillcolor="#FFAF00"];`,
+		`"aws_subnet.a" -> "aws_instance.web[0]";`,
+		`"aws_subnet.a" -> "aws_lb.main" [style=dashed];`,
+	} {
+		if !strings.Contains(dot.String(), want) {
+			t.Errorf("DOT output missing %q:\n%s", want, dot.String())
+		}
+	}
+
+	var mermaid bytes.Buffer
+	if err := WriteMermaid(&mermaid, Graph{
+		Nodes: []Node{{Address: `aws_instance.web["a"]`, Action: "create"}, {Address: "aws_vpc.main"}},
+		Edges: []Edge{{From: "aws_vpc.main", To: `aws_instance.web["a"]`, Indirect: true}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"flowchart LR",
+		`n0["aws_instance.web[#quot;a#quot;]"]:::create`,
+		`n1["aws_vpc.main"]:::unchanged`,
+		"n1 -.-> n0",
+		"classDef replace fill:#FFAF00",
+	} {
+		if !strings.Contains(mermaid.String(), want) {
+			t.Errorf("Mermaid output missing %q:\n%s", want, mermaid.String())
+		}
+	}
+}
+
+func TestBlastRadius(t *testing.T) {
+	blasts := BlastRadius(loadTestPlan(t))
+
+	if len(blasts) != 3 {
+		t.Errorf("BlastRadius() covers %d resources, want the 3 replaced ones", len(blasts))
+	}
+	b := blasts["aws_subnet.a"]
+	want := Blast{Address: "aws_subnet.a", Action: "replace", Impacts: []Impact{
+		{Address: "aws_eip.old", Effect: EffectNone},
+		{Address: "aws_instance.web[0]", Direct: true, Effect: EffectReplace},
+		{Address: "aws_instance.web[1]", Direct: true, Effect: EffectReplace},
+		{Address: "aws_lb.main", Effect: EffectNone},
+		{Address: "module.app.aws_instance.app", Direct: true, Effect: EffectNone},
+		{Address: "output.lb_dns", Output: true, Effect: EffectUnknown},
+	}}
+	if !reflect.DeepEqual(b, want) {
+		t.Errorf("BlastRadius()[aws_subnet.a] = %+v, want %+v", b, want)
+	}
+	if b.Affected() != 3 {
+		t.Errorf("Affected() = %d, want 3", b.Affected())
+	}
+
+	if b := blasts["aws_instance.web[1]"]; len(b.Impacts) != 1 || b.Impacts[0].Address != "aws_eip.old" {
+		t.Errorf("BlastRadius()[aws_instance.web[1]] = %+v", b)
+	}
+}
+
+func TestBlastRadius_Caveats(t *testing.T) {
+	plan, err := models.ParsePlan(`{
+		"resource_changes": [{"address": "aws_subnet.a", "type": "aws_subnet", "name": "a", "change": {"actions": ["delete"]}}],
+		"configuration": {"root_module": {"module_calls": {"app": {"source": "./app", "module": {"resources": [
+			{"address": "aws_instance.web", "mode": "managed", "type": "aws_instance", "name": "web",
+				"expressions": {"subnet_id": {"references": ["local.subnet_id"]}}}
+		]}}}}}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := BlastRadius(plan)["aws_subnet.a"]; !b.Locals || b.Unknown || len(b.Impacts) != 0 {
+		t.Errorf("BlastRadius() = %+v; want no impacts, with the locals caveat", b)
+	}
+
+	// Without configuration, dependencies are unknown, not absent
+	plan.Configuration = models.Configuration{}
+	plan.Meta.Missing = []string{models.SectionConfiguration}
+	if b := BlastRadius(plan)["aws_subnet.a"]; !b.Unknown || b.Locals {
+		t.Errorf("BlastRadius() = %+v; want unknown dependencies", b)
+	}
+}
+
+func TestBlast_Lines(t *testing.T) {
+	impact := Impact{Address: "aws_instance.web", Direct: true, Effect: EffectReplace}
+	tests := []struct {
+		name     string
+		blast    Blast
+		expected []string
+	}{
+		{"Nothing", Blast{Address: "aws_subnet.a"}, []string{"# Blast radius: nothing depends on aws_subnet.a"}},
+		{"Locals", Blast{Address: "aws_subnet.a", Locals: true}, []string{
+			"# Blast radius: no dependents found for aws_subnet.a",
+			"  Dependents referencing it through locals are not recorded in the plan and not shown",
+		}},
+		{"Unknown", Blast{Address: "aws_subnet.a", Unknown: true}, []string{"# Blast radius: dependencies unknown, the plan has no configuration"}},
+		{"UnknownWithState", Blast{Address: "aws_subnet.a", Unknown: true, Impacts: []Impact{impact}}, []string{
+			"# Blast radius: dependencies unknown, the plan has no configuration",
+			"  Dependents recorded in the prior state:",
+			"  -/+ aws_instance.web (replaced)",
+		}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, line := range tt.blast.Lines() {
+			got = append(got, line.Text)
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: Lines() = %q; want %q", tt.name, got, tt.expected)
+		}
+	}
+}
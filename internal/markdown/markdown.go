@@ -0,0 +1,166 @@
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bernard-sh/tfs/internal/diff"
+	"github.com/bernard-sh/tfs/internal/models"
+	"github.com/bernard-sh/tfs/internal/summary"
+)
+
+// DefaultMaxLength stays just under GitHub's 65536 character limit for comments.
+const DefaultMaxLength = 65000
+
+type Options struct {
+	// ReportURL links to the full HTML report, if one was uploaded.
+	ReportURL string
+	// MaxLength truncates the per-resource sections so the report fits in a comment.
+	// Zero means DefaultMaxLength, a negative value disables truncation.
+	MaxLength int
+}
+
+var categoryTitles = []string{"Create", "Destroy", "Replace", "Update", "Import"}
+var categorySymbols = []string{"+", "-", "-/+", "~", ""}
+
+// Generate renders the plan as Markdown suitable for a pull/merge request comment:
+// a table of counts per action followed by a collapsible diff per resource.
+func Generate(plan models.TfPlan, opts Options) string {
+	maxLength := opts.MaxLength
+	if maxLength == 0 {
+		maxLength = DefaultMaxLength
+	}
+
+	// 1. Summary table
+	var head strings.Builder
+	head.WriteString("## Terraform plan\n\n")
+	s := summary.New(plan)
+	head.WriteString(s.String() + "\n\n")
+	head.WriteString("| Action | Count |\n|---|---:|\n")
+	for i, title := range categoryTitles {
+		label := strings.TrimSpace(categorySymbols[i] + " " + title)
+		head.WriteString(fmt.Sprintf("| %s | %d |\n", label, s.Counts[i]))
+	}
+	head.WriteString("\n")
+	if opts.ReportURL != "" {
+		head.WriteString(fmt.Sprintf("[View the full report](%s)\n\n", opts.ReportURL))
+	}
+
+	// 2. Per-resource sections, in tab order. A category header goes with its first
+	// resource so truncation never leaves it empty.
+	lists := models.Partition(plan)
+	var sections []string
+	for i, title := range categoryTitles {
+		rcs := lists[models.Category(i)]
+		if len(rcs) == 0 {
+			continue
+		}
+		header := fmt.Sprintf("### %s (%d)\n\n", title, len(rcs))
+		for j, rc := range rcs {
+			section := resourceSection(rc)
+			if j == 0 {
+				section = header + section
+			}
+			sections = append(sections, section)
+		}
+	}
+
+	// 3. Assemble, dropping every section once the size limit is reached so no
+	// resource lands under the header of another category. The sections get what
+	// the head leaves of the limit.
+	budget := maxLength - head.Len() - len(truncationNote(len(sections), opts.ReportURL))
+	var body strings.Builder
+	omitted := 0
+	for _, section := range sections {
+		if omitted > 0 || (maxLength > 0 && body.Len()+len(section) > budget) {
+			omitted++
+			continue
+		}
+		body.WriteString(section)
+	}
+	if omitted > 0 {
+		body.WriteString(truncationNote(omitted, opts.ReportURL))
+	}
+
+	out := head.String() + body.String()
+	if maxLength > 0 && len(out) > maxLength {
+		// Only a head longer than the limit gets here, e.g. with a very long report URL
+		out = truncate(out, maxLength)
+	}
+	return out
+}
+
+// truncate cuts s to at most n bytes without splitting a UTF-8 character.
+func truncate(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func resourceSection(rc models.ResourceChange) string {
+	body := diffBlock(diff.Lines(rc))
+
+	// Use a fence longer than any backtick run in the diff so values can't close it
+	fence := "```"
+	for strings.Contains(body, fence) {
+		fence += "`"
+	}
+
+	return fmt.Sprintf("<details><summary><code>%s</code></summary>\n\n%sdiff\n%s%s\n\n</details>\n\n",
+		html.EscapeString(rc.Key()), fence, body, fence)
+}
+
+// diffBlock moves each line's action symbol to the first column, where GitHub and
+// GitLab expect it to colour a ```diff block. In-place changes use "!".
+func diffBlock(lines []diff.Line) string {
+	var sb strings.Builder
+	for _, line := range lines {
+		if line.Kind == diff.LineHeader {
+			// Already starts with "#"
+			sb.WriteString(line.Text + "\n")
+			continue
+		}
+
+		marker := " "
+		switch line.Kind {
+		case diff.LineAdd:
+			marker = "+"
+		case diff.LineDelete:
+			marker = "-"
+		case diff.LineUpdate, diff.LineReplace:
+			marker = "!"
+		}
+
+		for i, text := range strings.Split(line.Text, "\n") {
+			if i == 0 {
+				text = stripSymbol(text)
+			}
+			// The marker takes the place of the first indentation column
+			sb.WriteString(marker + strings.TrimPrefix(text, " ") + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// stripSymbol blanks the leading "+", "-", "~" or "-/+" of a line, keeping indentation.
+func stripSymbol(text string) string {
+	trimmed := strings.TrimLeft(text, " ")
+	padding := len(text) - len(trimmed)
+	for _, sym := range []string{"-/+ ", "+ ", "- ", "~ "} {
+		if strings.HasPrefix(trimmed, sym) {
+			return strings.Repeat(" ", padding+len(sym)) + trimmed[len(sym):]
+		}
+	}
+	return text
+}
+
+func truncationNote(omitted int, reportURL string) string {
+	note := fmt.Sprintf("_%d resource(s) omitted to fit the comment size limit.", omitted)
+	if reportURL != "" {
+		note += fmt.Sprintf(" See the [full report](%s).", reportURL)
+	}
+	return note + "_\n"
+}
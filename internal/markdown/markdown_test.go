@@ -0,0 +1,119 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+func testPlan(n int) models.TfPlan {
+	var plan models.TfPlan
+	for i := 0; i < n; i++ {
+		plan.ResourceChanges = append(plan.ResourceChanges, models.ResourceChange{
+			Address: "res.create",
+			Type:    "res",
+			Name:    "create",
+			Change: models.Change{
+				Actions: []string{"create"},
+				After:   map[string]interface{}{"name": "a```b"},
+			},
+		})
+	}
+	return plan
+}
+
+func TestGenerate(t *testing.T) {
+	md := Generate(testPlan(1), Options{ReportURL: "https://example.com/report.html"})
+
+	for _, want := range []string{
+		"| + Create | 1 |",
+		"| - Destroy | 0 |",
+		"<details><summary><code>res.create</code></summary>",
+		"````diff\n",
+		`+   resource "res" "create" {`,
+		`+   name = "a` + "```" + `b"`,
+		"[View the full report](https://example.com/report.html)",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown output missing %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestGenerate_SensitiveValues(t *testing.T) {
+	plan := models.TfPlan{ResourceChanges: []models.ResourceChange{{
+		Address: "aws_db_instance.main",
+		Type:    "aws_db_instance",
+		Name:    "main",
+		Change: models.Change{
+			Actions:         []string{"update"},
+			Before:          map[string]interface{}{"password": "old-secret", "engine": "postgres"},
+			After:           map[string]interface{}{"password": "hunter2", "engine": "postgres"},
+			BeforeSensitive: map[string]interface{}{"password": true},
+			AfterSensitive:  map[string]interface{}{"password": true},
+		},
+	}}}
+	md := Generate(plan, Options{})
+
+	if want := "password = (sensitive value) -> (sensitive value)"; !strings.Contains(md, want) {
+		t.Errorf("Markdown output missing %q:\n%s", want, md)
+	}
+	if strings.Contains(md, "hunter2") || strings.Contains(md, "old-secret") {
+		t.Errorf("Markdown output shows a sensitive value:\n%s", md)
+	}
+}
+
+func TestGenerate_Truncation(t *testing.T) {
+	full := Generate(testPlan(10), Options{MaxLength: -1})
+	truncated := Generate(testPlan(10), Options{MaxLength: 1500, ReportURL: "https://example.com/r"})
+
+	if len(truncated) > 1500 {
+		t.Errorf("Truncated output is %d characters; want at most 1500", len(truncated))
+	}
+	if len(truncated) >= len(full) {
+		t.Errorf("Expected truncated output to be shorter than the full output")
+	}
+	if !strings.Contains(truncated, "omitted to fit the comment size limit. See the [full report](https://example.com/r).") {
+		t.Errorf("Truncated output is missing the truncation note:\n%s", truncated)
+	}
+}
+
+func TestGenerate_TruncationCountsHead(t *testing.T) {
+	url := "https://example.com/report.html?X-Signature=" + strings.Repeat("a", 1000)
+	head := len(Generate(models.TfPlan{}, Options{ReportURL: url}))
+
+	for _, maxLength := range []int{head / 2, head + 10, head + 300, head + 1000} {
+		md := Generate(testPlan(10), Options{MaxLength: maxLength, ReportURL: url})
+		if len(md) > maxLength {
+			t.Errorf("MaxLength %d: output is %d characters", maxLength, len(md))
+		}
+	}
+}
+
+func TestGenerate_TruncationKeepsCategories(t *testing.T) {
+	plan := testPlan(3)
+	for i := 0; i < 3; i++ {
+		plan.ResourceChanges = append(plan.ResourceChanges, models.ResourceChange{
+			Address: "res.delete",
+			Type:    "res",
+			Name:    "delete",
+			Change:  models.Change{Actions: []string{"delete"}},
+		})
+	}
+	full := Generate(plan, Options{MaxLength: -1})
+
+	for max := 200; max < len(full); max += 10 {
+		got := Generate(plan, Options{MaxLength: max})
+		kept := got
+		if i := strings.Index(got, "\n_"); i >= 0 {
+			kept = got[:i+1]
+		}
+		if !strings.HasPrefix(full, kept) {
+			t.Fatalf("MaxLength %d: truncated output is not a prefix of the full output:\n%s", max, got)
+		}
+		if i := strings.LastIndex(kept, "### "); i >= 0 && !strings.Contains(kept[i:], "<details>") {
+			t.Errorf("MaxLength %d: category header kept without any of its resources:\n%s", max, got)
+		}
+	}
+}
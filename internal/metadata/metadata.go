@@ -0,0 +1,216 @@
+// Package metadata describes how a plan was made: the Terraform and provider
+// versions, the workspace, the input variables and whether the plan can be applied,
+// so reviewers can tell a staging plan from a production one.
+package metadata
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bernard-sh/tfs/internal/config"
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+// maxValue is the number of characters of a variable value shown before truncating.
+const maxValue = 80
+
+// Metadata is what a plan records about the run that made it.
+type Metadata struct {
+	TerraformVersion string
+	FormatVersion    string // Version of the JSON plan format, "" for binary plans
+	Timestamp        string // When the plan was made, in UTC; "" when not recorded
+	Workspace        string // "" when unknown
+	Applyable        *bool
+	Complete         *bool
+	Errored          bool
+	Variables        []Variable // Sorted by name
+	Providers        []Provider // Sorted by source address
+}
+
+// Variable is a root module input variable.
+type Variable struct {
+	Name      string
+	Value     string // Compact JSON, "(sensitive value)" when declared sensitive, "(hidden)" without declarations
+	Sensitive bool
+}
+
+// Provider is a provider the plan uses.
+type Provider struct {
+	Source     string // e.g. registry.terraform.io/hashicorp/aws
+	Version    string // Selected in the dependency lock file, "" when unknown
+	Constraint string // Version constraints of its configurations, e.g. "~> 5.0"
+}
+
+// Name is the short name of the provider, e.g. "aws".
+func (p Provider) Name() string {
+	return p.Source[strings.LastIndex(p.Source, "/")+1:]
+}
+
+// New collects the metadata of plan. dir is its root module directory, where the
+// workspace and the lock file are looked up when the plan does not record them; ""
+// when unknown.
+func New(plan models.TfPlan, dir string) Metadata {
+	m := Metadata{
+		TerraformVersion: plan.TerraformVersion,
+		FormatVersion:    plan.FormatVersion,
+		Timestamp:        FormatTimestamp(plan.Timestamp),
+		Workspace:        plan.Meta.Workspace,
+		Applyable:        plan.Applyable,
+		Complete:         plan.Complete,
+		Errored:          plan.Errored,
+	}
+	if m.Workspace == "" && dir != "" {
+		m.Workspace = Workspace(dir)
+	}
+
+	// Without declarations, e.g. in natively decoded plans, any value may be sensitive
+	declared := plan.Configuration.RootModule.Variables
+	for name, v := range plan.Variables {
+		variable := Variable{Name: name, Sensitive: declared[name].Sensitive}
+		switch {
+		case variable.Sensitive:
+			variable.Value = "(sensitive value)"
+		case len(declared) == 0:
+			variable.Value = "(hidden)"
+		default:
+			variable.Value = compact(v.Value)
+		}
+		m.Variables = append(m.Variables, variable)
+	}
+	sort.Slice(m.Variables, func(i, j int) bool { return m.Variables[i].Name < m.Variables[j].Name })
+
+	versions := plan.Meta.ProviderVersions
+	if versions == nil && dir != "" {
+		versions = config.ReadLockFile(dir)
+	}
+	m.Providers = providers(plan, versions)
+	return m
+}
+
+// providers lists the providers of the configuration and the resource changes, with
+// their selected versions.
+func providers(plan models.TfPlan, versions map[string]string) []Provider {
+	constraints := make(map[string]map[string]bool)
+	add := func(source, constraint string) {
+		if source == "" {
+			return
+		}
+		if constraints[source] == nil {
+			constraints[source] = make(map[string]bool)
+		}
+		if constraint != "" {
+			constraints[source][constraint] = true
+		}
+	}
+	for _, pc := range plan.Configuration.ProviderConfig {
+		add(pc.FullName, pc.VersionConstraint)
+	}
+	for _, rc := range plan.ResourceChanges {
+		add(rc.ProviderName, "")
+	}
+
+	out := make([]Provider, 0, len(constraints))
+	for source, set := range constraints {
+		list := make([]string, 0, len(set))
+		for c := range set {
+			list = append(list, c)
+		}
+		sort.Strings(list)
+		out = append(out, Provider{Source: source, Version: versions[source], Constraint: strings.Join(list, ", ")})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Source < out[j].Source })
+	return out
+}
+
+// Warnings lists what makes the plan unsafe to apply as is: "errored", "not
+// applyable" and "incomplete".
+func (m Metadata) Warnings() []string {
+	var w []string
+	if m.Errored {
+		w = append(w, "errored")
+	} else if m.Applyable != nil && !*m.Applyable {
+		w = append(w, "not applyable")
+	}
+	if m.Complete != nil && !*m.Complete {
+		w = append(w, "incomplete")
+	}
+	return w
+}
+
+// Ready reports whether the plan records that it is applyable and complete.
+func (m Metadata) Ready() bool {
+	return !m.Errored && m.Applyable != nil && *m.Applyable && m.Complete != nil && *m.Complete
+}
+
+// Summary is the metadata on one line, e.g.
+// "Terraform 1.9.5 · workspace prod · aws 5.31.0 · 3 variables · planned 2024-05-01 10:00:00 UTC".
+func (m Metadata) Summary() string {
+	var parts []string
+	if m.TerraformVersion != "" {
+		parts = append(parts, "Terraform "+m.TerraformVersion)
+	}
+	if m.Workspace != "" {
+		parts = append(parts, "workspace "+m.Workspace)
+	}
+	for _, p := range m.Providers {
+		if p.Version != "" {
+			parts = append(parts, p.Name()+" "+p.Version)
+		}
+	}
+	switch len(m.Variables) {
+	case 0:
+	case 1:
+		parts = append(parts, "1 variable")
+	default:
+		parts = append(parts, fmt.Sprintf("%d variables", len(m.Variables)))
+	}
+	if m.Timestamp != "" {
+		parts = append(parts, "planned "+m.Timestamp)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// FormatTimestamp shows a plan's RFC 3339 timestamp in UTC, or as given when it does
+// not parse.
+func FormatTimestamp(ts string) string {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return ts
+	}
+	return t.UTC().Format("2006-01-02 15:04:05 UTC")
+}
+
+// Workspace is the Terraform workspace selected in dir: TF_WORKSPACE, the one
+// recorded by `terraform workspace select`, or "default".
+func Workspace(dir string) string {
+	if ws := os.Getenv("TF_WORKSPACE"); ws != "" {
+		return ws
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, ".terraform", "environment")); err == nil {
+		if ws := strings.TrimSpace(string(data)); ws != "" {
+			return ws
+		}
+	}
+	return "default"
+}
+
+// compact is the compact JSON of a value, truncated to maxValue characters.
+func compact(v interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	s := strings.TrimSuffix(buf.String(), "\n")
+	if r := []rune(s); len(r) > maxValue {
+		s = string(r[:maxValue-1]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,141 @@
+package metadata
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bernard-sh/tfs/internal/models"
+	"github.com/bernard-sh/tfs/internal/planfile"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+	lock := "provider \"registry.terraform.io/hashicorp/aws\" {\n  version = \"5.31.0\"\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, ".terraform.lock.hcl"), []byte(lock), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TF_WORKSPACE", "")
+
+	plan, err := models.ParsePlan(`{
+		"format_version": "1.2",
+		"terraform_version": "1.9.5",
+		"timestamp": "2024-05-01T10:00:00Z",
+		"complete": false,
+		"variables": {"env": {"value": "prod"}, "db_password": {"value": "hunter2"}, "tags": {"value": {"team": "core"}}},
+		"resource_changes": [
+			{"address": "random_id.x", "type": "random_id", "name": "x", "provider_name": "registry.terraform.io/hashicorp/random", "change": {"actions": ["create"]}}
+		],
+		"configuration": {
+			"provider_config": {
+				"aws": {"name": "aws", "full_name": "registry.terraform.io/hashicorp/aws", "version_constraint": "~> 5.0"},
+				"aws.east": {"name": "aws", "full_name": "registry.terraform.io/hashicorp/aws", "alias": "east", "version_constraint": ">= 5.10"}
+			},
+			"root_module": {"variables": {"db_password": {"sensitive": true}}}
+		}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(plan, dir)
+	if m.TerraformVersion != "1.9.5" || m.FormatVersion != "1.2" || m.Timestamp != "2024-05-01 10:00:00 UTC" || m.Workspace != "default" {
+		t.Errorf("New() = %+v", m)
+	}
+	wantVars := []Variable{
+		{Name: "db_password", Value: "(sensitive value)", Sensitive: true},
+		{Name: "env", Value: `"prod"`},
+		{Name: "tags", Value: `{"team":"core"}`},
+	}
+	if !reflect.DeepEqual(m.Variables, wantVars) {
+		t.Errorf("Variables = %v; want %v", m.Variables, wantVars)
+	}
+	wantProviders := []Provider{
+		{Source: "registry.terraform.io/hashicorp/aws", Version: "5.31.0", Constraint: ">= 5.10, ~> 5.0"},
+		{Source: "registry.terraform.io/hashicorp/random"},
+	}
+	if !reflect.DeepEqual(m.Providers, wantProviders) {
+		t.Errorf("Providers = %v; want %v", m.Providers, wantProviders)
+	}
+	if got := m.Warnings(); !reflect.DeepEqual(got, []string{"incomplete"}) {
+		t.Errorf("Warnings() = %v; want [incomplete]", got)
+	}
+	want := "Terraform 1.9.5 · workspace default · aws 5.31.0 · 3 variables · planned 2024-05-01 10:00:00 UTC"
+	if got := m.Summary(); got != want {
+		t.Errorf("Summary() = %q; want %q", got, want)
+	}
+
+	// Recorded in binary plans, the workspace and versions win over the directory
+	plan.Meta = models.PlanMeta{Workspace: "prod", ProviderVersions: map[string]string{"registry.terraform.io/hashicorp/aws": "5.40.0"}}
+	m = New(plan, dir)
+	if m.Workspace != "prod" || m.Providers[0].Version != "5.40.0" {
+		t.Errorf("New() ignored the plan's metadata: %+v", m)
+	}
+	if m := New(models.TfPlan{}, ""); m.Workspace != "" || m.Summary() != "" {
+		t.Errorf("Expected no workspace without a directory, got %+v", m)
+	}
+}
+
+func TestNew_NativePlan(t *testing.T) {
+	// A binary plan with the variable db_password = "hunter2": variables (field 2) map
+	// names to a DynamicValue, msgpack (field 1) of [type JSON, value]
+	value := []byte{0x92, 0xc4, 0x08}
+	value = append(value, `"string"`...)
+	value = append(value, 0xa7)
+	value = append(value, "hunter2"...)
+	dynamic := protowire.AppendBytes(protowire.AppendTag(nil, 1, protowire.BytesType), value)
+	entry := protowire.AppendString(protowire.AppendTag(nil, 1, protowire.BytesType), "db_password")
+	entry = protowire.AppendBytes(protowire.AppendTag(entry, 2, protowire.BytesType), dynamic)
+	tfplan := protowire.AppendBytes(protowire.AppendTag(nil, 2, protowire.BytesType), entry)
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	f, err := w.Create("tfplan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write(tfplan)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	plan, err := planfile.Decode(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Variables["db_password"].Value != "hunter2" {
+		t.Fatalf("Decoded variables = %v", plan.Variables)
+	}
+
+	// The plan does not say which variables are sensitive
+	m := New(plan, "")
+	if len(m.Variables) != 1 || strings.Contains(m.Variables[0].Value, "hunter2") || m.Variables[0].Value != "(hidden)" {
+		t.Errorf("Variables = %v; want the value hidden", m.Variables)
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	no := false
+	tests := []struct {
+		m        Metadata
+		expected []string
+	}{
+		{Metadata{}, nil},
+		{Metadata{Applyable: &no}, []string{"not applyable"}},
+		{Metadata{Errored: true, Applyable: &no, Complete: &no}, []string{"errored", "incomplete"}},
+	}
+	for _, tt := range tests {
+		if got := tt.m.Warnings(); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Warnings() of %+v = %v; want %v", tt.m, got, tt.expected)
+		}
+	}
+
+	yes := true
+	if (Metadata{Applyable: &yes}).Ready() || !(Metadata{Applyable: &yes, Complete: &yes}).Ready() {
+		t.Errorf("Ready() should need the plan to record that it is both applyable and complete")
+	}
+}
@@ -0,0 +1,85 @@
+package models
+
+// Category is the bucket a resource change is shown under (TUI tab, report tab, summary count).
+type Category int
+
+// Fixed order, shared by the TUI tabs and the HTML report.
+const (
+	CategoryCreate Category = iota
+	CategoryDestroy
+	CategoryReplace
+	CategoryUpdate
+	CategoryImport
+)
+
+// NumCategories is the number of buckets returned by Categorize.
+const NumCategories = 5
+
+func (c Category) String() string {
+	switch c {
+	case CategoryCreate:
+		return "create"
+	case CategoryDestroy:
+		return "destroy"
+	case CategoryReplace:
+		return "replace"
+	case CategoryUpdate:
+		return "update"
+	default:
+		return "import"
+	}
+}
+
+// IsNoOp reports whether the change does nothing (and is not an import), so it can be skipped.
+func (rc ResourceChange) IsNoOp() bool {
+	if rc.Change.Importing != nil {
+		return false
+	}
+	actions := rc.Change.Actions
+	return len(actions) == 0 || (len(actions) == 1 && actions[0] == "no-op")
+}
+
+// IsReplace reports whether the actions describe a replacement, in either order
+// (delete-then-create or create_before_destroy).
+func (rc ResourceChange) IsReplace() bool {
+	actions := rc.Change.Actions
+	if len(actions) != 2 {
+		return false
+	}
+	return (actions[0] == "delete" && actions[1] == "create") ||
+		(actions[0] == "create" && actions[1] == "delete")
+}
+
+// Categorize maps a resource change to its bucket.
+func Categorize(rc ResourceChange) Category {
+	// Check for Replace first (delete, create)
+	if rc.IsReplace() {
+		return CategoryReplace
+	}
+	if len(rc.Change.Actions) == 0 {
+		return CategoryImport
+	}
+	switch rc.Change.Actions[0] {
+	case "create":
+		return CategoryCreate
+	case "delete":
+		return CategoryDestroy
+	case "update":
+		return CategoryUpdate
+	default:
+		return CategoryImport // Import or other
+	}
+}
+
+// Partition buckets the plan's resource changes by category, skipping no-ops.
+func Partition(plan TfPlan) map[Category][]ResourceChange {
+	lists := make(map[Category][]ResourceChange)
+	for _, rc := range plan.ResourceChanges {
+		if rc.IsNoOp() {
+			continue
+		}
+		cat := Categorize(rc)
+		lists[cat] = append(lists[cat], rc)
+	}
+	return lists
+}
@@ -0,0 +1,55 @@
+package models
+
+// Configuration is the `configuration` section of the plan JSON: the module tree
+// and the expressions each resource was declared with.
+type Configuration struct {
+	ProviderConfig map[string]ProviderConfig `json:"provider_config,omitempty"`
+	RootModule     ConfigModule              `json:"root_module"`
+}
+
+// ProviderConfig is a provider configuration, keyed by e.g. "aws" or "module.db:aws.east".
+type ProviderConfig struct {
+	Name              string `json:"name"`
+	FullName          string `json:"full_name,omitempty"` // Source address, e.g. registry.terraform.io/hashicorp/aws
+	Alias             string `json:"alias,omitempty"`
+	ModuleAddress     string `json:"module_address,omitempty"`
+	VersionConstraint string `json:"version_constraint,omitempty"`
+}
+
+type ConfigModule struct {
+	Resources   []ConfigResource          `json:"resources,omitempty"`
+	ModuleCalls map[string]ModuleCall     `json:"module_calls,omitempty"`
+	Outputs     map[string]ConfigOutput   `json:"outputs,omitempty"`
+	Variables   map[string]ConfigVariable `json:"variables,omitempty"`
+}
+
+type ConfigResource struct {
+	Address           string                 `json:"address"`
+	Mode              string                 `json:"mode"`
+	Type              string                 `json:"type"`
+	Name              string                 `json:"name"`
+	ProviderConfigKey string                 `json:"provider_config_key,omitempty"`
+	Expressions       map[string]interface{} `json:"expressions,omitempty"`
+	CountExpression   map[string]interface{} `json:"count_expression,omitempty"`
+	ForEachExpression map[string]interface{} `json:"for_each_expression,omitempty"`
+	DependsOn         []string               `json:"depends_on,omitempty"`
+}
+
+type ModuleCall struct {
+	Source      string                 `json:"source"`
+	Expressions map[string]interface{} `json:"expressions,omitempty"`
+	Module      ConfigModule           `json:"module"`
+	DependsOn   []string               `json:"depends_on,omitempty"`
+}
+
+type ConfigOutput struct {
+	Expression map[string]interface{} `json:"expression,omitempty"`
+	Sensitive  bool                   `json:"sensitive,omitempty"`
+	DependsOn  []string               `json:"depends_on,omitempty"`
+}
+
+type ConfigVariable struct {
+	Default     interface{} `json:"default,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Sensitive   bool        `json:"sensitive,omitempty"`
+}
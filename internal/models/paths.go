@@ -0,0 +1,77 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ChangedPaths lists the attribute paths that differ between before and after, e.g.
+// "tags.Name" or "ingress[0].cidr_blocks". Values unknown until apply count as changed.
+// Paths stop at the first level where one side is missing, so a new block is reported
+// once rather than leaf by leaf.
+func (c Change) ChangedPaths() []string {
+	var paths []string
+	diffPaths("", toMap(c.Before), toMap(c.After), c.AfterUnknown, &paths)
+	sort.Strings(paths)
+	return paths
+}
+
+func toMap(m map[string]interface{}) interface{} {
+	if m == nil {
+		return map[string]interface{}{}
+	}
+	return m
+}
+
+func diffPaths(path string, before, after, unknown interface{}, paths *[]string) {
+	if b, ok := unknown.(bool); ok && b {
+		*paths = append(*paths, path)
+		return
+	}
+
+	switch b := before.(type) {
+	case map[string]interface{}:
+		a, ok := after.(map[string]interface{})
+		if !ok {
+			break
+		}
+		u, _ := unknown.(map[string]interface{})
+		keys := make(map[string]bool)
+		for k := range b {
+			keys[k] = true
+		}
+		for k := range a {
+			keys[k] = true
+		}
+		for k := range u {
+			keys[k] = true
+		}
+		for k := range keys {
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			diffPaths(child, b[k], a[k], u[k], paths)
+		}
+		return
+
+	case []interface{}:
+		a, ok := after.([]interface{})
+		if !ok || len(a) != len(b) {
+			break
+		}
+		u, _ := unknown.([]interface{})
+		for i := range b {
+			var ui interface{}
+			if i < len(u) {
+				ui = u[i]
+			}
+			diffPaths(fmt.Sprintf("%s[%d]", path, i), b[i], a[i], ui, paths)
+		}
+		return
+	}
+
+	if fmt.Sprintf("%#v", before) != fmt.Sprintf("%#v", after) {
+		*paths = append(*paths, path)
+	}
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// TfPlan mirrors the subset of `terraform show -json` output that tfs uses.
+type TfPlan struct {
+	FormatVersion    string                  `json:"format_version,omitempty"`
+	TerraformVersion string                  `json:"terraform_version,omitempty"`
+	Timestamp        string                  `json:"timestamp,omitempty"` // RFC 3339, Terraform 1.5+
+	Applyable        *bool                   `json:"applyable,omitempty"` // nil when not recorded, e.g. by older Terraform versions
+	Complete         *bool                   `json:"complete,omitempty"`  // Whether every resource was planned; nil when not recorded
+	Errored          bool                    `json:"errored,omitempty"`
+	Variables        map[string]Variable     `json:"variables,omitempty"`
+	ResourceChanges  []ResourceChange        `json:"resource_changes"`
+	ResourceDrift    []ResourceChange        `json:"resource_drift,omitempty"` // Changes made outside of Terraform
+	OutputChanges    map[string]OutputChange `json:"output_changes,omitempty"`
+	PriorState       *State                  `json:"prior_state,omitempty"`
+	Configuration    Configuration           `json:"configuration"`
+
+	// Meta holds what binary plan files record beyond the JSON output.
+	Meta PlanMeta `json:"-"`
+}
+
+// Variable is the value of a root module input variable the plan was made with.
+type Variable struct {
+	Value interface{} `json:"value"`
+}
+
+// PlanMeta are details of a binary plan file missing from `terraform show -json`.
+type PlanMeta struct {
+	Workspace        string            // Workspace of the backend the plan was made against
+	ProviderVersions map[string]string // Versions selected in the dependency lock file, by source address
+	Missing          []string          // Sections the plan source could not read, e.g. SectionConfiguration
+	InputHash        string            // HashInput of the file the plan was read from, see Hash
+}
+
+// Sections of the JSON plan a plan source may be unable to read, see PlanMeta.Missing.
+const (
+	SectionConfiguration = "configuration"
+	SectionPriorState    = "prior_state"
+	SectionOutputChanges = "output_changes"
+	SectionResourceDrift = "resource_drift"
+)
+
+// Lacks reports whether the plan source could not read the section, so its absence
+// from the plan says nothing: no configuration is not an empty configuration.
+func (p TfPlan) Lacks(section string) bool {
+	for _, s := range p.Meta.Missing {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
+
+type ResourceChange struct {
+	Address       string `json:"address"`
+	ModuleAddress string `json:"module_address,omitempty"`
+	Mode          string `json:"mode,omitempty"`
+	Type          string `json:"type"`
+	Name          string `json:"name"`
+	ProviderName  string `json:"provider_name,omitempty"`
+	Deposed       string `json:"deposed,omitempty"` // Deposed key, set for an object a create_before_destroy replacement left behind
+	Change        Change `json:"change"`
+}
+
+// Key identifies the change within the plan. It is the address, except for deposed
+// objects, which share the address of the current object: their deposed key is added,
+// e.g. aws_instance.web/deposed-1a2b3c4d.
+func (rc ResourceChange) Key() string {
+	if rc.Deposed == "" {
+		return rc.Address
+	}
+	return rc.Address + "/deposed-" + rc.Deposed
+}
+
+type Change struct {
+	Actions      []string               `json:"actions"`
+	Before       map[string]interface{} `json:"before"`
+	After        map[string]interface{} `json:"after"`
+	AfterUnknown map[string]interface{} `json:"after_unknown"`
+	// Sensitive values are in Before and After in plain text, these masks mirror
+	// them with true marking the sensitive parts
+	BeforeSensitive interface{}            `json:"before_sensitive,omitempty"`
+	AfterSensitive  interface{}            `json:"after_sensitive,omitempty"`
+	Importing       map[string]interface{} `json:"importing,omitempty"`
+}
+
+// OutputChange is the planned change of a root module output. Unlike resources,
+// values can be of any type, and each side can be marked sensitive as a whole.
+type OutputChange struct {
+	Actions         []string    `json:"actions"`
+	Before          interface{} `json:"before"`
+	After           interface{} `json:"after"`
+	AfterUnknown    interface{} `json:"after_unknown,omitempty"`
+	BeforeSensitive interface{} `json:"before_sensitive,omitempty"`
+	AfterSensitive  interface{} `json:"after_sensitive,omitempty"`
+}
+
+// ParsePlan decodes plan JSON, keeping numbers as json.Number to preserve formatting.
+func ParsePlan(jsonContent string) (TfPlan, error) {
+	var plan TfPlan
+	dec := json.NewDecoder(strings.NewReader(jsonContent))
+	dec.UseNumber()
+	if err := dec.Decode(&plan); err != nil {
+		return TfPlan{}, fmt.Errorf("failed to decode plan JSON: %w", err)
+	}
+	plan.Meta.InputHash = HashInput([]byte(jsonContent))
+	return plan, nil
+}
+
+// Hash identifies the plan content, e.g. to key review progress: the hash of the
+// input the plan was read from, so the same plan file hashes the same whether it is
+// decoded natively or through show. Plans built in memory hash their JSON encoding.
+func (p TfPlan) Hash() string {
+	if p.Meta.InputHash != "" {
+		return p.Meta.InputHash
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		return ""
+	}
+	return HashInput(b)
+}
+
+// HashInput is the first 16 hex digits of the SHA-256 of a plan file, see Hash.
+func HashInput(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
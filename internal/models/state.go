@@ -0,0 +1,27 @@
+package models
+
+// State is the `prior_state` section of the plan JSON: the state the plan was made
+// against. Only the resource addresses and their recorded dependencies are kept.
+type State struct {
+	Values StateValues `json:"values"`
+}
+
+type StateValues struct {
+	RootModule StateModule `json:"root_module"`
+}
+
+type StateModule struct {
+	Address      string          `json:"address,omitempty"`
+	Resources    []StateResource `json:"resources,omitempty"`
+	ChildModules []StateModule   `json:"child_modules,omitempty"`
+}
+
+type StateResource struct {
+	Address string `json:"address"`
+	Mode    string `json:"mode"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	// DependsOn lists the resources this one depended on when last applied, as
+	// configuration addresses without instance keys, e.g. module.vpc.aws_subnet.a.
+	DependsOn []string `json:"depends_on,omitempty"`
+}
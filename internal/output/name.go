@@ -0,0 +1,123 @@
+package output
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bernard-sh/tfs/internal/metadata"
+	"github.com/bernard-sh/tfs/internal/stack"
+)
+
+// DefaultName keeps the object keys of earlier versions, e.g. tfs-plan-1700000000.html.
+const DefaultName = "tfs-plan-{timestamp}"
+
+// Vars are the values of the name template placeholders.
+type Vars struct {
+	Workspace string    // {workspace}
+	Branch    string    // {branch}
+	SHA       string    // {sha}, abbreviated to 12 characters
+	Hash      string    // {hash}, see models.TfPlan.Hash
+	Time      time.Time // {timestamp}, in Unix seconds
+}
+
+var (
+	placeholder = regexp.MustCompile(`\{([a-z]+)\}`)
+	// unsafe runs are replaced in values so a branch like "feature/x" stays one path segment
+	unsafe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+// Expand replaces the placeholders of the name template tmpl. The template itself
+// may contain slashes, e.g. "plans/{branch}/{sha}" to group uploads by prefix.
+func Expand(tmpl string, v Vars) (string, error) {
+	values := map[string]string{
+		"workspace": v.Workspace,
+		"branch":    v.Branch,
+		"sha":       v.SHA,
+		"hash":      v.Hash,
+		"timestamp": strconv.FormatInt(v.Time.Unix(), 10),
+	}
+
+	var unknown []string
+	name := placeholder.ReplaceAllStringFunc(tmpl, func(match string) string {
+		key := match[1 : len(match)-1]
+		value, ok := values[key]
+		if !ok {
+			unknown = append(unknown, match)
+			return match
+		}
+		value = strings.Trim(unsafe.ReplaceAllString(value, "-"), "-")
+		if value == "" {
+			return "unknown"
+		}
+		return value
+	})
+	if len(unknown) > 0 {
+		return "", fmt.Errorf("unknown placeholder %s (expected {workspace}, {branch}, {sha}, {hash} or {timestamp})", strings.Join(unknown, ", "))
+	}
+	if name == "" {
+		return "", fmt.Errorf("empty name")
+	}
+	return name, nil
+}
+
+// DetectVars collects the placeholder values of a run over stacks in dir. The branch
+// and commit come from the CI environment (GitHub Actions, GitLab CI), then git.
+func DetectVars(dir string, stacks []stack.Stack, now time.Time) Vars {
+	v := Vars{
+		Workspace: metadata.Workspace(dir),
+		Branch:    firstEnv("GITHUB_HEAD_REF", "GITHUB_REF_NAME", "CI_COMMIT_REF_NAME"),
+		SHA:       firstEnv("GITHUB_SHA", "CI_COMMIT_SHA"),
+		Hash:      stacksHash(stacks),
+		Time:      now,
+	}
+	if v.Branch == "" {
+		v.Branch = git(dir, "rev-parse", "--abbrev-ref", "HEAD")
+		if v.Branch == "HEAD" {
+			v.Branch = "" // Detached
+		}
+	}
+	if v.SHA == "" {
+		v.SHA = git(dir, "rev-parse", "HEAD")
+	}
+	if len(v.SHA) > 12 {
+		v.SHA = v.SHA[:12]
+	}
+	return v
+}
+
+// stacksHash is the plan hash, combined over every stack of a multi-plan report.
+func stacksHash(stacks []stack.Stack) string {
+	if len(stacks) == 1 {
+		return stacks[0].Plan.Hash()
+	}
+	h := sha256.New()
+	for _, st := range stacks {
+		fmt.Fprintf(h, "%s=%s\n", st.Label, st.Plan.Hash())
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// git returns the trimmed output of a git command, empty outside a repository.
+func git(dir string, args ...string) string {
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,172 @@
+// Package output renders the reports written by `tfs web` and decides where they go:
+// the formats, the destination path and the name template shared by files and
+// uploaded objects.
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bernard-sh/tfs/internal/markdown"
+	"github.com/bernard-sh/tfs/internal/stack"
+	"github.com/bernard-sh/tfs/internal/summary"
+	"github.com/bernard-sh/tfs/internal/web"
+)
+
+// Format is a report format.
+type Format string
+
+const (
+	HTML     Format = "html"
+	Markdown Format = "markdown"
+	JSON     Format = "json" // The machine-readable summary of `tfs summary --format json`
+)
+
+// Formats lists the supported formats, in the order they are produced.
+var Formats = []Format{HTML, Markdown, JSON}
+
+// Stdout is the --output value writing the report to standard output.
+const Stdout = "-"
+
+// ParseFormats validates format names, dropping duplicates. The result is in the
+// order of Formats, so the HTML report is uploaded before the Markdown linking to it.
+func ParseFormats(names []string) ([]Format, error) {
+	wanted := make(map[Format]bool)
+	for _, name := range names {
+		f := Format(strings.ToLower(strings.TrimSpace(name)))
+		if f == "md" {
+			f = Markdown
+		}
+		valid := false
+		for _, known := range Formats {
+			valid = valid || f == known
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid format %q (expected html, markdown or json)", name)
+		}
+		wanted[f] = true
+	}
+
+	var formats []Format
+	for _, f := range Formats {
+		if wanted[f] {
+			formats = append(formats, f)
+		}
+	}
+	if len(formats) == 0 {
+		return nil, fmt.Errorf("no format selected")
+	}
+	return formats, nil
+}
+
+// Ext is the file extension of the format.
+func (f Format) Ext() string {
+	switch f {
+	case Markdown:
+		return ".md"
+	case JSON:
+		return ".json"
+	default:
+		return ".html"
+	}
+}
+
+// ContentType is the MIME type uploaded objects are served with.
+func (f Format) ContentType() string {
+	switch f {
+	case Markdown:
+		return "text/markdown; charset=utf-8"
+	case JSON:
+		return "application/json"
+	default:
+		return "text/html; charset=utf-8"
+	}
+}
+
+// stackReport is one entry of the JSON summary of several plans.
+type stackReport struct {
+	Label  string         `json:"label"`
+	Report summary.Report `json:"report"`
+}
+
+// Options are the run details reports show besides the plans.
+type Options struct {
+	ReportURL string // Linked from Markdown reports, usually the uploaded HTML report
+	Workspace string // Shown on the HTML overview
+}
+
+// Render writes the report of stacks in format f.
+func Render(w io.Writer, f Format, stacks []stack.Stack, opts Options) error {
+	if len(stacks) == 0 {
+		return fmt.Errorf("no plan to report")
+	}
+	single := len(stacks) == 1
+
+	switch f {
+	case HTML:
+		webOpts := web.Options{Workspace: opts.Workspace}
+		if single {
+			webOpts.ConfigDir = stacks[0].Dir
+			return web.Render(w, stacks[0].Plan, webOpts)
+		}
+		return web.RenderStacks(w, stacks, webOpts)
+	case Markdown:
+		// Files have no size limit, unlike comments
+		mdOpts := markdown.Options{ReportURL: opts.ReportURL, MaxLength: -1}
+		if single {
+			_, err := io.WriteString(w, markdown.Generate(stacks[0].Plan, mdOpts))
+			return err
+		}
+		for _, st := range stacks {
+			if _, err := fmt.Fprintf(w, "# %s\n\n%s\n", st.Label, markdown.Generate(st.Plan, mdOpts)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case JSON:
+		if single {
+			return summary.NewReport(stacks[0].Plan).WriteJSON(w)
+		}
+		reports := make([]stackReport, 0, len(stacks))
+		for _, st := range stacks {
+			reports = append(reports, stackReport{Label: st.Label, Report: summary.NewReport(st.Plan)})
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(reports)
+	}
+	return fmt.Errorf("unsupported format %q", f)
+}
+
+// Destination is the file the report in format f is written to, "" for stdout:
+//   - "-": stdout
+//   - "": tfs.<ext> in the current directory
+//   - an existing directory, or a path ending in a separator: name.<ext> inside it
+//   - a file: the path itself when writing a single format, otherwise the path with
+//     the extension of each format
+func Destination(output, name string, f Format, single bool) string {
+	switch {
+	case output == Stdout:
+		return ""
+	case output == "":
+		return "tfs" + f.Ext()
+	case isDir(output):
+		return filepath.Join(output, name+f.Ext())
+	case single:
+		return output
+	default:
+		return strings.TrimSuffix(output, filepath.Ext(output)) + f.Ext()
+	}
+}
+
+func isDir(path string) bool {
+	if strings.HasSuffix(path, "/") || strings.HasSuffix(path, string(filepath.Separator)) {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
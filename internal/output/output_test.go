@@ -0,0 +1,145 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bernard-sh/tfs/internal/models"
+	"github.com/bernard-sh/tfs/internal/stack"
+)
+
+var testPlan = models.TfPlan{ResourceChanges: []models.ResourceChange{
+	{Address: "aws_instance.web", Type: "aws_instance", Name: "web", Change: models.Change{Actions: []string{"create"}}},
+}}
+
+func TestParseFormats(t *testing.T) {
+	formats, err := ParseFormats([]string{"json", "md", "HTML", "markdown"})
+	if err != nil {
+		t.Fatalf("ParseFormats() error = %v", err)
+	}
+	if want := []Format{HTML, Markdown, JSON}; !reflect.DeepEqual(formats, want) {
+		t.Errorf("ParseFormats() = %v, want %v", formats, want)
+	}
+
+	if _, err := ParseFormats([]string{"pdf"}); err == nil {
+		t.Error("ParseFormats(pdf) should fail")
+	}
+	if _, err := ParseFormats(nil); err == nil {
+		t.Error("ParseFormats() without formats should fail")
+	}
+}
+
+func TestRender(t *testing.T) {
+	single := []stack.Stack{{Label: "prod", Plan: testPlan}}
+	multi := []stack.Stack{{Label: "dev", Plan: models.TfPlan{}}, {Label: "prod", Plan: testPlan}}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, Markdown, single, Options{ReportURL: "https://example.com/report.html"}); err != nil {
+		t.Fatalf("Render(markdown) error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "## Terraform plan") || !strings.Contains(buf.String(), "(https://example.com/report.html)") {
+		t.Errorf("Markdown report is missing the summary or report link:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := Render(&buf, JSON, multi, Options{}); err != nil {
+		t.Fatalf("Render(json) error = %v", err)
+	}
+	var reports []struct {
+		Label  string `json:"label"`
+		Report struct {
+			Counts struct {
+				Total int `json:"total"`
+			} `json:"counts"`
+		} `json:"report"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &reports); err != nil {
+		t.Fatalf("JSON report does not decode: %v", err)
+	}
+	if len(reports) != 2 || reports[1].Label != "prod" || reports[1].Report.Counts.Total != 1 {
+		t.Errorf("JSON report = %+v", reports)
+	}
+
+	buf.Reset()
+	if err := Render(&buf, HTML, multi, Options{Workspace: "staging"}); err != nil {
+		t.Fatalf("Render(html) error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `<a href="#prod">prod</a>`) {
+		t.Error("HTML report of several plans is missing the stack index")
+	}
+	if !strings.Contains(buf.String(), "<tr><th>Workspace</th><td>staging</td></tr>") {
+		t.Error("HTML report is missing the workspace")
+	}
+}
+
+func TestDestination(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		output string
+		format Format
+		single bool
+		want   string
+	}{
+		{"-", HTML, true, ""},
+		{"", Markdown, false, "tfs.md"},
+		{dir, JSON, false, filepath.Join(dir, "main/abc.json")},
+		{"new-dir/", HTML, false, "new-dir/main/abc.html"},
+		{"report.htm", HTML, true, "report.htm"},
+		{"out/report.html", Markdown, false, "out/report.md"},
+	}
+	for _, tt := range tests {
+		if got := Destination(tt.output, "main/abc", tt.format, tt.single); got != tt.want {
+			t.Errorf("Destination(%q, %s) = %q, want %q", tt.output, tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	v := Vars{Workspace: "prod", Branch: "feature/new vpc", SHA: "0123456789ab", Hash: "deadbeef", Time: time.Unix(1700000000, 0)}
+
+	got, err := Expand("plans/{branch}/{sha}-{workspace}-{hash}", v)
+	if err != nil || got != "plans/feature-new-vpc/0123456789ab-prod-deadbeef" {
+		t.Errorf("Expand() = %q, %v", got, err)
+	}
+	if got, _ := Expand(DefaultName, v); got != "tfs-plan-1700000000" {
+		t.Errorf("Expand(DefaultName) = %q", got)
+	}
+	if got, _ := Expand("{branch}", Vars{}); got != "unknown" {
+		t.Errorf("Expand() of an empty value = %q, want unknown", got)
+	}
+	if _, err := Expand("{user}-{sha}", v); err == nil || !strings.Contains(err.Error(), "{user}") {
+		t.Errorf("Expand() of an unknown placeholder error = %v", err)
+	}
+}
+
+func TestDetectVars(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, ".terraform"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".terraform", "environment"), []byte("staging\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TF_WORKSPACE", "")
+	t.Setenv("GITHUB_HEAD_REF", "")
+	t.Setenv("GITHUB_REF_NAME", "main")
+	t.Setenv("GITHUB_SHA", "0123456789abcdef0123456789abcdef01234567")
+
+	stacks := []stack.Stack{{Label: "prod", Plan: testPlan}}
+	v := DetectVars(dir, stacks, time.Unix(1, 0))
+	want := Vars{Workspace: "staging", Branch: "main", SHA: "0123456789ab", Hash: testPlan.Hash(), Time: time.Unix(1, 0)}
+	if v != want {
+		t.Errorf("DetectVars() = %+v, want %+v", v, want)
+	}
+
+	t.Setenv("TF_WORKSPACE", "prod")
+	if v := DetectVars(dir, stacks, time.Now()); v.Workspace != "prod" {
+		t.Errorf("DetectVars() ignored TF_WORKSPACE, got %q", v.Workspace)
+	}
+}
@@ -0,0 +1,193 @@
+package planfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// unknownValue stands for a value only known after apply, which cty encodes as a
+// msgpack extension (code 0, or 12 when it carries refinements).
+type unknownValue struct{}
+
+var errShortMsgpack = errors.New("msgpack: unexpected end of data")
+
+// decodeMsgpack decodes a cty value serialised as msgpack into the same shapes
+// encoding/json produces for the JSON plan: maps, slices, strings, bools, nil and
+// json.Number, plus unknownValue for values not yet known.
+func decodeMsgpack(b []byte) (interface{}, error) {
+	d := msgpackDecoder{buf: b}
+	v, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.buf) {
+		return nil, fmt.Errorf("msgpack: %d trailing bytes", len(d.buf)-d.pos)
+	}
+	return v, nil
+}
+
+type msgpackDecoder struct {
+	buf []byte
+	pos int
+}
+
+func (d *msgpackDecoder) next(n int) ([]byte, error) {
+	if n < 0 || d.pos+n > len(d.buf) {
+		return nil, errShortMsgpack
+	}
+	b := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// uint reads a big-endian unsigned integer of n bytes.
+func (d *msgpackDecoder) uint(n int) (uint64, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
+
+func (d *msgpackDecoder) value() (interface{}, error) {
+	tag, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	c := tag[0]
+
+	switch {
+	case c <= 0x7f:
+		return json.Number(strconv.Itoa(int(c))), nil
+	case c >= 0xe0:
+		return json.Number(strconv.Itoa(int(int8(c)))), nil
+	case c >= 0xa0 && c <= 0xbf:
+		return d.str(int(c & 0x1f))
+	case c >= 0x90 && c <= 0x9f:
+		return d.array(int(c & 0x0f))
+	case c >= 0x80 && c <= 0x8f:
+		return d.mapping(int(c & 0x0f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		v, err := d.uint(1 << (c - 0xcc))
+		return json.Number(strconv.FormatUint(v, 10)), err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		v, err := d.uint(size)
+		// Sign-extend from the encoded width
+		shift := 64 - 8*size
+		return json.Number(strconv.FormatInt(int64(v<<shift)>>shift, 10)), err
+	case 0xca:
+		v, err := d.uint(4)
+		return floatNumber(float64(math.Float32frombits(uint32(v)))), err
+	case 0xcb:
+		v, err := d.uint(8)
+		return floatNumber(math.Float64frombits(v)), err
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.uint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(int(n))
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.uint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.next(int(n))
+		return append([]byte(nil), b...), err
+	case 0xdc, 0xdd:
+		n, err := d.uint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.array(int(n))
+	case 0xde, 0xdf:
+		n, err := d.uint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.mapping(int(n))
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		// fixext: type byte plus 1, 2, 4, 8 or 16 bytes of data
+		_, err := d.next(1 + 1<<(c-0xd4))
+		return unknownValue{}, err
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.uint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		_, err = d.next(1 + int(n))
+		return unknownValue{}, err
+	}
+	return nil, fmt.Errorf("msgpack: unsupported type byte 0x%02x at offset %d", c, d.pos-1)
+}
+
+func (d *msgpackDecoder) str(n int) (interface{}, error) {
+	b, err := d.next(n)
+	return string(b), err
+}
+
+func (d *msgpackDecoder) array(n int) (interface{}, error) {
+	if n > len(d.buf)-d.pos {
+		return nil, errShortMsgpack
+	}
+	items := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+	}
+	// Values of dynamic type are written as [type JSON as bytes, value]; cty
+	// never encodes strings as bytes otherwise, so unwrap them to the value
+	if len(items) == 2 {
+		if _, ok := items[0].([]byte); ok {
+			return items[1], nil
+		}
+	}
+	return items, nil
+}
+
+func (d *msgpackDecoder) mapping(n int) (interface{}, error) {
+	if n > len(d.buf)-d.pos {
+		return nil, errShortMsgpack
+	}
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		k, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			key = fmt.Sprint(k)
+		}
+		m[key] = v
+	}
+	return m, nil
+}
+
+func floatNumber(f float64) json.Number {
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64))
+}
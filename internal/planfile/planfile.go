@@ -0,0 +1,98 @@
+// Package planfile reads Terraform's binary plan file, the zip archive written by
+// `terraform plan -out`, directly into the plan model without the terraform binary,
+// an initialised working directory or provider plugins.
+package planfile
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bernard-sh/tfs/internal/config"
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+// Archive members read by tfs. The configuration snapshot ("tfconfig/") is HCL,
+// which tfs does not parse: natively decoded plans have no configuration.
+const (
+	planEntry  = "tfplan"  // The plan protobuf
+	stateEntry = "tfstate" // The prior state
+)
+
+// zipMagic starts every zip archive, and so every binary plan file.
+var zipMagic = []byte("PK\x03\x04")
+
+// IsPlanFile reports whether data looks like a binary plan file rather than JSON.
+func IsPlanFile(data []byte) bool {
+	return bytes.HasPrefix(data, zipMagic)
+}
+
+// Read decodes the binary plan file at path.
+func Read(path string) (models.TfPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return models.TfPlan{}, err
+	}
+	return Decode(data)
+}
+
+// Decode decodes the content of a binary plan file.
+func Decode(data []byte) (models.TfPlan, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return models.TfPlan{}, fmt.Errorf("not a plan file: %w", err)
+	}
+
+	var raw, state, lock []byte
+	for _, f := range archive.File {
+		switch f.Name {
+		case planEntry:
+			if raw, err = readEntry(f); err != nil {
+				return models.TfPlan{}, err
+			}
+		case stateEntry:
+			if state, err = readEntry(f); err != nil {
+				return models.TfPlan{}, err
+			}
+		case config.LockFile:
+			if lock, err = readEntry(f); err != nil {
+				return models.TfPlan{}, err
+			}
+		}
+	}
+	if raw == nil {
+		return models.TfPlan{}, fmt.Errorf("not a plan file: no %s entry in archive", planEntry)
+	}
+
+	plan, err := decodePlan(raw)
+	if err != nil {
+		return models.TfPlan{}, fmt.Errorf("failed to decode %s: %w", planEntry, err)
+	}
+	if lock != nil {
+		plan.Meta.ProviderVersions = config.ParseLockFile(lock)
+	}
+	plan.Meta.Missing = append(plan.Meta.Missing, models.SectionConfiguration)
+	// The prior state only adds dependencies, a plan without it is still worth showing
+	if state != nil {
+		plan.PriorState, _ = decodeState(state)
+	}
+	if plan.PriorState == nil {
+		plan.Meta.Missing = append(plan.Meta.Missing, models.SectionPriorState)
+	}
+	return plan, nil
+}
+
+func readEntry(f *zip.File) ([]byte, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", f.Name, err)
+	}
+	defer r.Close()
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	return raw, nil
+}
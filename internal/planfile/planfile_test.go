@@ -0,0 +1,427 @@
+package planfile
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/bernard-sh/tfs/internal/models"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Minimal msgpack encoders for the shapes cty produces.
+
+func mpStr(s string) []byte {
+	return append([]byte{0xd9, byte(len(s))}, s...)
+}
+
+func mpMap(kv ...[]byte) []byte {
+	b := []byte{0x80 | byte(len(kv)/2)}
+	for _, part := range kv {
+		b = append(b, part...)
+	}
+	return b
+}
+
+func mpArray(items ...[]byte) []byte {
+	b := []byte{0x90 | byte(len(items))}
+	for _, item := range items {
+		b = append(b, item...)
+	}
+	return b
+}
+
+var (
+	mpNil     = []byte{0xc0}
+	mpTrue    = []byte{0xc3}
+	mpUnknown = []byte{0xd4, 0, 0}
+)
+
+func mpInt(n int8) []byte { return []byte{0xd0, byte(n)} }
+
+// mpTyped is cty's msgpack encoding of a value of any type: [type JSON as bin, value].
+func mpTyped(typeJSON string, value []byte) []byte {
+	return mpArray(append([]byte{0xc4, byte(len(typeJSON))}, typeJSON...), value)
+}
+
+func dynamicValue(msgpack []byte) []byte {
+	return protowire.AppendBytes(protowire.AppendTag(nil, dynamicMsgpack, protowire.BytesType), msgpack)
+}
+
+func change(action uint64, values ...[]byte) []byte {
+	b := protowire.AppendTag(nil, changeAction, protowire.VarintType)
+	b = protowire.AppendVarint(b, action)
+	for _, v := range values {
+		b = protowire.AppendTag(b, changeValues, protowire.BytesType)
+		b = protowire.AppendBytes(b, dynamicValue(v))
+	}
+	return b
+}
+
+func resourceChange(addr, provider string, change []byte) []byte {
+	b := protowire.AppendTag(nil, rcAddr, protowire.BytesType)
+	b = protowire.AppendString(b, addr)
+	b = protowire.AppendTag(b, rcProvider, protowire.BytesType)
+	b = protowire.AppendString(b, provider)
+	b = protowire.AppendTag(b, rcChange, protowire.BytesType)
+	return protowire.AppendBytes(b, change)
+}
+
+func planArchive(t *testing.T, changes ...[]byte) []byte {
+	t.Helper()
+	plan := protowire.AppendTag(nil, 1, protowire.VarintType) // version
+	plan = protowire.AppendVarint(plan, 3)
+	for _, c := range changes {
+		plan = protowire.AppendTag(plan, planResourceChanges, protowire.BytesType)
+		plan = protowire.AppendBytes(plan, c)
+	}
+
+	return zipArchive(t, map[string][]byte{planEntry: plan, "tfstate": []byte("{}")})
+}
+
+func zipArchive(t *testing.T, entries map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range entries {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write(content)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+const awsProvider = `provider["registry.terraform.io/hashicorp/aws"]`
+
+func TestDecode(t *testing.T) {
+	data := planArchive(t,
+		resourceChange("aws_instance.web", awsProvider, change(actionCreate,
+			mpMap(mpStr("ami"), mpStr("ami-123"), mpStr("id"), mpUnknown, mpStr("tags"), mpMap(mpStr("Name"), mpStr("web"))),
+		)),
+		resourceChange(`module.db["main"].aws_db_instance.this[0]`, awsProvider+".east", change(actionDeleteThenCreate,
+			mpMap(mpStr("engine"), mpStr("postgres"), mpStr("port"), mpInt(-1)),
+			mpMap(mpStr("engine"), mpStr("mysql"), mpStr("port"), mpUnknown),
+		)),
+		resourceChange("data.aws_ami.ubuntu", awsProvider, change(actionRead, mpNil, mpMap(mpStr("id"), mpUnknown))),
+		resourceChange("aws_s3_bucket.logs", awsProvider, change(actionDelete, mpMap(mpStr("bucket"), mpStr("logs")))),
+		protowire.AppendString(protowire.AppendTag(
+			resourceChange("aws_instance.web", awsProvider, change(actionDelete, mpMap(mpStr("ami"), mpStr("ami-old")))),
+			rcDeposedKey, protowire.BytesType), "1a2b3c4d"),
+	)
+
+	if !IsPlanFile(data) {
+		t.Fatal("IsPlanFile() = false for a plan archive")
+	}
+	plan, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if len(plan.ResourceChanges) != 5 {
+		t.Fatalf("got %d resource changes, want 5", len(plan.ResourceChanges))
+	}
+
+	web := plan.ResourceChanges[0]
+	if web.Type != "aws_instance" || web.Name != "web" || web.Mode != "managed" || web.ModuleAddress != "" {
+		t.Errorf("web address fields = %+v", web)
+	}
+	if web.ProviderName != "registry.terraform.io/hashicorp/aws" {
+		t.Errorf("ProviderName = %q", web.ProviderName)
+	}
+	if web.Change.Before != nil {
+		t.Errorf("create Before = %v, want nil", web.Change.Before)
+	}
+	if web.Change.After["ami"] != "ami-123" || web.Change.After["id"] != nil {
+		t.Errorf("create After = %v", web.Change.After)
+	}
+	if web.Change.AfterUnknown["id"] != true {
+		t.Errorf("AfterUnknown = %v, want id unknown", web.Change.AfterUnknown)
+	}
+	if tags, _ := web.Change.After["tags"].(map[string]interface{}); tags["Name"] != "web" {
+		t.Errorf("nested tags = %v", web.Change.After["tags"])
+	}
+
+	db := plan.ResourceChanges[1]
+	if db.ModuleAddress != `module.db["main"]` || db.Type != "aws_db_instance" || db.Name != "this" {
+		t.Errorf("db address fields = %+v", db)
+	}
+	if !reflect.DeepEqual(db.Change.Actions, []string{"delete", "create"}) || models.Categorize(db) != models.CategoryReplace {
+		t.Errorf("db actions = %v", db.Change.Actions)
+	}
+	if db.Change.Before["port"] != json.Number("-1") || db.Change.After["engine"] != "mysql" {
+		t.Errorf("db values = %v -> %v", db.Change.Before, db.Change.After)
+	}
+	if got := db.Change.ChangedPaths(); !reflect.DeepEqual(got, []string{"engine", "port"}) {
+		t.Errorf("ChangedPaths() = %v", got)
+	}
+
+	ami := plan.ResourceChanges[2]
+	if ami.Mode != "data" || ami.Type != "aws_ami" || ami.Name != "ubuntu" {
+		t.Errorf("data source address fields = %+v", ami)
+	}
+
+	logs := plan.ResourceChanges[3]
+	if models.Categorize(logs) != models.CategoryDestroy || logs.Change.Before["bucket"] != "logs" || logs.Change.After != nil {
+		t.Errorf("delete change = %+v", logs.Change)
+	}
+
+	deposed := plan.ResourceChanges[4]
+	if deposed.Address != "aws_instance.web" || deposed.Deposed != "1a2b3c4d" || deposed.Key() == web.Key() {
+		t.Errorf("deposed change = %+v, key %s", deposed, deposed.Key())
+	}
+}
+
+// path encodes a Path message; string steps are attribute names, others element keys.
+func path(steps ...interface{}) []byte {
+	var b []byte
+	for _, step := range steps {
+		var s []byte
+		switch v := step.(type) {
+		case string:
+			s = protowire.AppendTag(nil, stepAttributeName, protowire.BytesType)
+			s = protowire.AppendString(s, v)
+		case []byte:
+			s = protowire.AppendTag(nil, stepElementKey, protowire.BytesType)
+			s = protowire.AppendBytes(s, dynamicValue(v))
+		}
+		b = protowire.AppendTag(b, pathSteps, protowire.BytesType)
+		b = protowire.AppendBytes(b, s)
+	}
+	return b
+}
+
+func TestDecode_SensitivePaths(t *testing.T) {
+	c := change(actionUpdate,
+		mpMap(mpStr("password"), mpStr("old"), mpStr("users"), mpArray(mpStr("a"), mpStr("b"))),
+		mpMap(mpStr("password"), mpStr("hunter2"), mpStr("users"), mpArray(mpStr("a"), mpStr("b"))),
+	)
+	c = protowire.AppendTag(c, changeBeforeSensitive, protowire.BytesType)
+	c = protowire.AppendBytes(c, path("password"))
+	c = protowire.AppendTag(c, changeAfterSensitive, protowire.BytesType)
+	c = protowire.AppendBytes(c, path("password"))
+	c = protowire.AppendTag(c, changeAfterSensitive, protowire.BytesType)
+	c = protowire.AppendBytes(c, path("users", mpInt(1)))
+
+	plan, err := Decode(planArchive(t, resourceChange("aws_db_instance.main", awsProvider, c)))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	got := plan.ResourceChanges[0].Change
+	if want := map[string]interface{}{"password": true}; !reflect.DeepEqual(got.BeforeSensitive, want) {
+		t.Errorf("BeforeSensitive = %v; want %v", got.BeforeSensitive, want)
+	}
+	want := map[string]interface{}{"password": true, "users": []interface{}{false, true}}
+	if !reflect.DeepEqual(got.AfterSensitive, want) {
+		t.Errorf("AfterSensitive = %v; want %v", got.AfterSensitive, want)
+	}
+}
+
+func TestDecode_Metadata(t *testing.T) {
+	variable := func(name string, value []byte) []byte {
+		b := protowire.AppendTag(nil, mapKey, protowire.BytesType)
+		b = protowire.AppendString(b, name)
+		b = protowire.AppendTag(b, mapValue, protowire.BytesType)
+		return protowire.AppendBytes(b, dynamicValue(value))
+	}
+	plan := protowire.AppendTag(nil, planVariables, protowire.BytesType)
+	plan = protowire.AppendBytes(plan, variable("env", mpTyped(`"string"`, mpStr("prod"))))
+	plan = protowire.AppendTag(plan, planVariables, protowire.BytesType)
+	plan = protowire.AppendBytes(plan, variable("replicas", mpTyped(`"number"`, mpInt(3))))
+	plan = protowire.AppendTag(plan, planTerraformVersion, protowire.BytesType)
+	plan = protowire.AppendString(plan, "1.9.5")
+	backend := protowire.AppendTag(nil, backendWorkspace, protowire.BytesType)
+	backend = protowire.AppendString(backend, "staging")
+	plan = protowire.AppendTag(plan, planBackend, protowire.BytesType)
+	plan = protowire.AppendBytes(plan, backend)
+	plan = protowire.AppendTag(plan, planErrored, protowire.VarintType)
+	plan = protowire.AppendVarint(plan, 1)
+	plan = protowire.AppendTag(plan, planTimestamp, protowire.BytesType)
+	plan = protowire.AppendString(plan, "2023-11-14T22:13:20Z")
+	plan = protowire.AppendTag(plan, planApplyable, protowire.VarintType)
+	plan = protowire.AppendVarint(plan, 1)
+
+	lock := "provider \"registry.terraform.io/hashicorp/aws\" {\n  version = \"5.31.0\"\n}\n"
+	got, err := Decode(zipArchive(t, map[string][]byte{planEntry: plan, ".terraform.lock.hcl": []byte(lock)}))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	want := map[string]models.Variable{"env": {Value: "prod"}, "replicas": {Value: json.Number("3")}}
+	if !reflect.DeepEqual(got.Variables, want) {
+		t.Errorf("Variables = %v; want %v", got.Variables, want)
+	}
+	if got.TerraformVersion != "1.9.5" || got.Timestamp != "2023-11-14T22:13:20Z" || !got.Errored {
+		t.Errorf("TerraformVersion, Timestamp, Errored = %q, %q, %v", got.TerraformVersion, got.Timestamp, got.Errored)
+	}
+	// Complete is false, left out by protobuf
+	if got.Applyable == nil || !*got.Applyable || got.Complete == nil || *got.Complete {
+		t.Errorf("Applyable, Complete = %v, %v; want true, false", got.Applyable, got.Complete)
+	}
+	if got.Meta.Workspace != "staging" {
+		t.Errorf("Meta.Workspace = %q; want staging", got.Meta.Workspace)
+	}
+	if v := got.Meta.ProviderVersions["registry.terraform.io/hashicorp/aws"]; v != "5.31.0" {
+		t.Errorf("Meta.ProviderVersions = %v", got.Meta.ProviderVersions)
+	}
+}
+
+func TestDecode_Sections(t *testing.T) {
+	output := func(name string, sensitive bool, change []byte) []byte {
+		b := protowire.AppendTag(nil, outputName, protowire.BytesType)
+		b = protowire.AppendString(b, name)
+		b = protowire.AppendTag(b, outputChange, protowire.BytesType)
+		b = protowire.AppendBytes(b, change)
+		if sensitive {
+			b = protowire.AppendTag(b, outputSensitive, protowire.VarintType)
+			b = protowire.AppendVarint(b, 1)
+		}
+		return b
+	}
+	appendMessage := func(b []byte, num protowire.Number, message []byte) []byte {
+		return protowire.AppendBytes(protowire.AppendTag(b, num, protowire.BytesType), message)
+	}
+
+	plan := appendMessage(nil, planResourceChanges, resourceChange("aws_instance.web", awsProvider, change(actionCreate, mpMap(mpStr("ami"), mpStr("ami-1")))))
+	plan = appendMessage(plan, planResourceDrift, resourceChange("aws_s3_bucket.logs", awsProvider, change(actionUpdate,
+		mpMap(mpStr("acl"), mpStr("private")), mpMap(mpStr("acl"), mpStr("public-read")))))
+	plan = appendMessage(plan, planOutputChanges, output("ip", false, change(actionCreate, mpUnknown)))
+	plan = appendMessage(plan, planOutputChanges, output("password", true, change(actionUpdate, mpTyped(`"string"`, mpStr("a")), mpTyped(`"string"`, mpStr("b")))))
+	state := `{"version": 4, "resources": [
+		{"mode": "managed", "type": "aws_subnet", "name": "a", "instances": [{"index_key": 0}]},
+		{"module": "module.app", "mode": "managed", "type": "aws_instance", "name": "web", "instances": [{"index_key": "blue", "dependencies": ["aws_subnet.a"]}]}
+	]}`
+
+	got, err := Decode(zipArchive(t, map[string][]byte{planEntry: plan, stateEntry: []byte(state)}))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if got.Applyable != nil || got.Complete != nil {
+		t.Errorf("Applyable, Complete = %v, %v; want nil when not recorded", got.Applyable, got.Complete)
+	}
+	if len(got.ResourceChanges) != 1 || len(got.ResourceDrift) != 1 || got.ResourceDrift[0].Change.After["acl"] != "public-read" {
+		t.Errorf("ResourceChanges, ResourceDrift = %+v, %+v", got.ResourceChanges, got.ResourceDrift)
+	}
+	wantOutputs := map[string]models.OutputChange{
+		"ip":       {Actions: []string{"create"}, AfterUnknown: true, BeforeSensitive: false, AfterSensitive: false},
+		"password": {Actions: []string{"update"}, Before: "a", After: "b", AfterUnknown: false, BeforeSensitive: true, AfterSensitive: true},
+	}
+	if !reflect.DeepEqual(got.OutputChanges, wantOutputs) {
+		t.Errorf("OutputChanges = %#v; want %#v", got.OutputChanges, wantOutputs)
+	}
+	wantState := models.StateModule{
+		Resources: []models.StateResource{{Address: "aws_subnet.a[0]", Mode: "managed", Type: "aws_subnet", Name: "a"}},
+		ChildModules: []models.StateModule{{Address: "module.app", Resources: []models.StateResource{
+			{Address: `module.app.aws_instance.web["blue"]`, Mode: "managed", Type: "aws_instance", Name: "web", DependsOn: []string{"aws_subnet.a"}},
+		}}},
+	}
+	if got.PriorState == nil || !reflect.DeepEqual(got.PriorState.Values.RootModule, wantState) {
+		t.Errorf("PriorState = %+v; want %+v", got.PriorState, wantState)
+	}
+	if !reflect.DeepEqual(got.Meta.Missing, []string{models.SectionConfiguration}) || !got.Lacks(models.SectionConfiguration) {
+		t.Errorf("Meta.Missing = %v; want only the configuration", got.Meta.Missing)
+	}
+
+	// Sections that cannot be decoded are reported missing, the resource changes kept
+	broken := appendMessage(nil, planResourceChanges, resourceChange("aws_instance.web", awsProvider, change(actionCreate, mpMap())))
+	broken = appendMessage(broken, planOutputChanges, output("ip", false, change(99)))
+	got, err = Decode(zipArchive(t, map[string][]byte{planEntry: broken}))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	want := []string{models.SectionOutputChanges, models.SectionConfiguration, models.SectionPriorState}
+	if len(got.ResourceChanges) != 1 || got.OutputChanges != nil || !reflect.DeepEqual(got.Meta.Missing, want) {
+		t.Errorf("Decode() of a plan with a broken output = %+v; want Missing %v", got, want)
+	}
+}
+
+func TestDecode_NotAPlan(t *testing.T) {
+	if IsPlanFile([]byte(`{"resource_changes": []}`)) {
+		t.Error("IsPlanFile() = true for JSON")
+	}
+	if _, err := Decode([]byte(`{}`)); err == nil {
+		t.Error("Decode() of JSON should fail")
+	}
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	w.Create("other")
+	w.Close()
+	if _, err := Decode(buf.Bytes()); err == nil {
+		t.Error("Decode() of an archive without tfplan should fail")
+	}
+}
+
+func TestDecodeMsgpack(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		want interface{}
+	}{
+		{"positive fixint", []byte{0x2a}, json.Number("42")},
+		{"negative fixint", []byte{0xff}, json.Number("-1")},
+		{"uint16", []byte{0xcd, 0x01, 0x00}, json.Number("256")},
+		{"int32", []byte{0xd2, 0xff, 0xff, 0xff, 0x00}, json.Number("-256")},
+		{"float64", []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}, json.Number("1.5")},
+		{"fixstr", []byte{0xa2, 'h', 'i'}, "hi"},
+		{"bool", mpTrue, true},
+		{"nil", mpNil, nil},
+		{"list", mpArray(mpStr("a"), mpStr("b")), []interface{}{"a", "b"}},
+		{"dynamic", mpArray([]byte{0xc4, 0x08, '"', 's', 't', 'r', 'i', 'n', 'g', '"'}, mpStr("x")), "x"},
+		{"refined unknown", []byte{0xc7, 0x02, 0x0c, 0x81, 0x01}, unknownValue{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeMsgpack(tt.in)
+			if err != nil {
+				t.Fatalf("decodeMsgpack() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeMsgpack() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := decodeMsgpack([]byte{0x92, 0xc0}); err == nil {
+		t.Error("truncated array should fail")
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		addr                    string
+		module, mode, typ, name string
+	}{
+		{"aws_instance.web", "", "managed", "aws_instance", "web"},
+		{"aws_instance.web[0]", "", "managed", "aws_instance", "web"},
+		{"data.aws_ami.ubuntu", "", "data", "aws_ami", "ubuntu"},
+		{"module.vpc.aws_subnet.private[\"a\"]", "module.vpc", "managed", "aws_subnet", "private"},
+		{"module.a[0].module.b.data.x_y.z", "module.a[0].module.b", "data", "x_y", "z"},
+		{"module.m[\"k.with]dot\"].null_resource.n", "module.m[\"k.with]dot\"]", "managed", "null_resource", "n"},
+	}
+	for _, tt := range tests {
+		module, mode, typ, name := parseAddress(tt.addr)
+		if module != tt.module || mode != tt.mode || typ != tt.typ || name != tt.name {
+			t.Errorf("parseAddress(%q) = %q, %q, %q, %q", tt.addr, module, mode, typ, name)
+		}
+	}
+}
+
+func TestProviderName(t *testing.T) {
+	tests := map[string]string{
+		`provider["registry.terraform.io/hashicorp/aws"]`:            "registry.terraform.io/hashicorp/aws",
+		`module.x.provider["registry.terraform.io/hashicorp/aws"].b`: "registry.terraform.io/hashicorp/aws",
+		`provider.google`:      "google",
+		`provider.google.beta`: "google",
+	}
+	for in, want := range tests {
+		if got := providerName(in); got != want {
+			t.Errorf("providerName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
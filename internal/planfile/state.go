@@ -0,0 +1,72 @@
+package planfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+// stateFile is the subset of the state snapshot (format version 4) the plan was
+// made against that tfs uses: the resource instances and their dependencies.
+type stateFile struct {
+	Resources []struct {
+		Module    string `json:"module"` // e.g. module.vpc, "" for the root module
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Instances []struct {
+			IndexKey     interface{} `json:"index_key"` // json.Number or string, nil without count or for_each
+			Dependencies []string    `json:"dependencies"`
+		} `json:"instances"`
+	} `json:"resources"`
+}
+
+// decodeState decodes the tfstate entry into the prior_state section of the JSON
+// plan. Resources of child modules are listed under one child module per module
+// instance, not nested like `show -json` does.
+func decodeState(b []byte) (*models.State, error) {
+	var sf stateFile
+	dec := json.NewDecoder(strings.NewReader(string(b)))
+	dec.UseNumber()
+	if err := dec.Decode(&sf); err != nil {
+		return nil, err
+	}
+
+	state := &models.State{}
+	children := make(map[string]int) // Module address to its index in ChildModules
+	for _, r := range sf.Resources {
+		prefix := ""
+		if r.Module != "" {
+			prefix = r.Module + "."
+		}
+		if r.Mode == "data" {
+			prefix += "data."
+		}
+		for _, inst := range r.Instances {
+			address := prefix + r.Type + "." + r.Name
+			switch key := inst.IndexKey.(type) {
+			case json.Number:
+				address += "[" + key.String() + "]"
+			case string:
+				address += fmt.Sprintf("[%q]", key)
+			}
+			res := models.StateResource{Address: address, Mode: r.Mode, Type: r.Type, Name: r.Name, DependsOn: inst.Dependencies}
+
+			if r.Module == "" {
+				state.Values.RootModule.Resources = append(state.Values.RootModule.Resources, res)
+				continue
+			}
+			i, ok := children[r.Module]
+			if !ok {
+				i = len(state.Values.RootModule.ChildModules)
+				children[r.Module] = i
+				state.Values.RootModule.ChildModules = append(state.Values.RootModule.ChildModules, models.StateModule{Address: r.Module})
+			}
+			child := &state.Values.RootModule.ChildModules[i]
+			child.Resources = append(child.Resources, res)
+		}
+	}
+	return state, nil
+}
@@ -0,0 +1,587 @@
+package planfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bernard-sh/tfs/internal/models"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers of the messages in Terraform's tfplan.proto that tfs reads.
+const (
+	planVariables        = 2
+	planResourceChanges  = 3
+	planOutputChanges    = 4
+	planBackend          = 13
+	planTerraformVersion = 14
+	planResourceDrift    = 18
+	planErrored          = 20
+	planTimestamp        = 21
+	planApplyable        = 25
+	planComplete         = 26
+
+	mapKey   = 1
+	mapValue = 2
+
+	backendWorkspace = 3
+
+	rcDeposedKey = 7
+	rcProvider   = 8
+	rcChange     = 9
+	rcAddr       = 13
+
+	changeAction          = 1
+	changeValues          = 2
+	changeBeforeSensitive = 3
+	changeAfterSensitive  = 4
+	changeImporting       = 5
+
+	pathSteps = 1
+
+	stepAttributeName = 1
+	stepElementKey    = 2
+
+	dynamicMsgpack = 1
+	dynamicJSON    = 2
+
+	importingID = 1
+
+	outputName      = 1
+	outputChange    = 2
+	outputSensitive = 3
+)
+
+// Values of the tfplan.proto Action enum.
+const (
+	actionNoop             = 0
+	actionCreate           = 1
+	actionRead             = 2
+	actionUpdate           = 3
+	actionDelete           = 5
+	actionDeleteThenCreate = 6
+	actionCreateThenDelete = 7
+	actionForget           = 8
+)
+
+// field is one decoded protobuf field; varint or bytes is set depending on its wire type.
+type field struct {
+	varint uint64
+	bytes  []byte
+}
+
+// eachField calls fn for every field of the protobuf message b, in wire order.
+func eachField(b []byte, fn func(num protowire.Number, f field) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		var f field
+		switch typ {
+		case protowire.VarintType:
+			f.varint, n = protowire.ConsumeVarint(b)
+		case protowire.BytesType:
+			f.bytes, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		if err := fn(num, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodePlan decodes the Plan message. Output changes and drift that fail to decode
+// are left out and listed in Meta.Missing, the resource changes are what matters.
+func decodePlan(b []byte) (models.TfPlan, error) {
+	var (
+		plan                 models.TfPlan
+		outputsErr, driftErr error
+		applyable, complete  *bool
+	)
+	err := eachField(b, func(num protowire.Number, f field) error {
+		switch num {
+		case planResourceChanges:
+			rc, err := decodeResourceChange(f.bytes)
+			if err != nil {
+				return err
+			}
+			plan.ResourceChanges = append(plan.ResourceChanges, rc)
+		case planResourceDrift:
+			rc, err := decodeResourceChange(f.bytes)
+			if err != nil {
+				driftErr = err
+				return nil
+			}
+			plan.ResourceDrift = append(plan.ResourceDrift, rc)
+		case planOutputChanges:
+			name, oc, err := decodeOutputChange(f.bytes)
+			if err != nil {
+				outputsErr = err
+				return nil
+			}
+			if plan.OutputChanges == nil {
+				plan.OutputChanges = make(map[string]models.OutputChange)
+			}
+			plan.OutputChanges[name] = oc
+		case planVariables:
+			name, value, err := decodeVariable(f.bytes)
+			if err != nil {
+				return fmt.Errorf("variable %s: %w", name, err)
+			}
+			if plan.Variables == nil {
+				plan.Variables = make(map[string]models.Variable)
+			}
+			plan.Variables[name] = models.Variable{Value: value}
+		case planTerraformVersion:
+			plan.TerraformVersion = string(f.bytes)
+		case planBackend:
+			_ = eachField(f.bytes, func(num protowire.Number, f field) error {
+				if num == backendWorkspace {
+					plan.Meta.Workspace = string(f.bytes)
+				}
+				return nil
+			})
+		case planErrored:
+			plan.Errored = f.varint != 0
+		case planTimestamp:
+			plan.Timestamp = string(f.bytes) // RFC 3339
+		case planApplyable:
+			applyable = boolPtr(f.varint != 0)
+		case planComplete:
+			complete = boolPtr(f.varint != 0)
+		}
+		return nil
+	})
+	// Protobuf leaves false out: once the plan records one flag, the other one missing
+	// is false. Older Terraform versions record neither.
+	if applyable != nil || complete != nil {
+		plan.Applyable, plan.Complete = applyable, complete
+		if plan.Applyable == nil {
+			plan.Applyable = boolPtr(false)
+		}
+		if plan.Complete == nil {
+			plan.Complete = boolPtr(false)
+		}
+	}
+	if outputsErr != nil {
+		plan.OutputChanges = nil
+		plan.Meta.Missing = append(plan.Meta.Missing, models.SectionOutputChanges)
+	}
+	if driftErr != nil {
+		plan.ResourceDrift = nil
+		plan.Meta.Missing = append(plan.Meta.Missing, models.SectionResourceDrift)
+	}
+	return plan, err
+}
+
+// decodeVariable decodes an entry of the variables map. Values are stored with their
+// type, as cty does for values of any type: a [type, value] pair.
+func decodeVariable(b []byte) (string, interface{}, error) {
+	var (
+		name  string
+		value interface{}
+	)
+	err := eachField(b, func(num protowire.Number, f field) error {
+		var err error
+		switch num {
+		case mapKey:
+			name = string(f.bytes)
+		case mapValue:
+			value, err = decodeDynamicValue(f.bytes)
+		}
+		return err
+	})
+	known, _ := splitUnknown(unwrapTyped(value))
+	return name, known, err
+}
+
+// unwrapTyped drops the type stored along values of any type, as cty does: a
+// [type, value] pair in msgpack, {"value": ..., "type": ...} in JSON.
+func unwrapTyped(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		if len(v) == 2 {
+			if _, typed := v[0].([]byte); typed {
+				return v[1]
+			}
+		}
+	case map[string]interface{}:
+		if inner, ok := v["value"]; ok && len(v) == 2 && v["type"] != nil {
+			return inner
+		}
+	}
+	return value
+}
+
+// decodeOutputChange decodes an OutputChange message. Outputs can be of any type,
+// so their values carry their type.
+func decodeOutputChange(b []byte) (string, models.OutputChange, error) {
+	var (
+		name      string
+		sensitive bool
+		raw       rawChange
+	)
+	err := eachField(b, func(num protowire.Number, f field) error {
+		var err error
+		switch num {
+		case outputName:
+			name = string(f.bytes)
+		case outputChange:
+			raw, err = decodeRawChange(f.bytes)
+		case outputSensitive:
+			sensitive = f.varint != 0
+		}
+		return err
+	})
+	if err != nil {
+		return name, models.OutputChange{}, fmt.Errorf("output %s: %w", name, err)
+	}
+
+	oc := models.OutputChange{Actions: raw.actions, BeforeSensitive: sensitive, AfterSensitive: sensitive}
+	oc.Before, _ = splitUnknown(unwrapTyped(raw.before))
+	after, unknown := splitUnknown(unwrapTyped(raw.after))
+	oc.After, oc.AfterUnknown = after, false
+	if unknown != nil {
+		oc.AfterUnknown = unknown
+	}
+	return name, oc, nil
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func decodeResourceChange(b []byte) (models.ResourceChange, error) {
+	var rc models.ResourceChange
+	err := eachField(b, func(num protowire.Number, f field) error {
+		switch num {
+		case rcAddr:
+			rc.Address = string(f.bytes)
+		case rcDeposedKey:
+			rc.Deposed = string(f.bytes)
+		case rcProvider:
+			rc.ProviderName = providerName(string(f.bytes))
+		case rcChange:
+			change, err := decodeChange(f.bytes)
+			if err != nil {
+				return err
+			}
+			rc.Change = change
+		}
+		return nil
+	})
+	if err != nil {
+		return rc, fmt.Errorf("resource change %s: %w", rc.Address, err)
+	}
+
+	rc.ModuleAddress, rc.Mode, rc.Type, rc.Name = parseAddress(rc.Address)
+	return rc, nil
+}
+
+// rawChange is a decoded Change message, its values as decoded from msgpack or JSON.
+type rawChange struct {
+	actions       []string
+	before, after interface{} // nil when the action has no such side
+	// Sensitive paths as before_sensitive/after_sensitive-style masks, nil when none
+	beforeSensitive, afterSensitive interface{}
+	importing                       map[string]interface{}
+}
+
+func decodeRawChange(b []byte) (rawChange, error) {
+	var (
+		change rawChange
+		action uint64
+		values []interface{}
+	)
+	err := eachField(b, func(num protowire.Number, f field) error {
+		switch num {
+		case changeAction:
+			action = f.varint
+		case changeValues:
+			v, err := decodeDynamicValue(f.bytes)
+			if err != nil {
+				return err
+			}
+			values = append(values, v)
+		case changeBeforeSensitive:
+			steps, err := decodePath(f.bytes)
+			if err != nil {
+				return err
+			}
+			change.beforeSensitive = markPath(change.beforeSensitive, steps)
+		case changeAfterSensitive:
+			steps, err := decodePath(f.bytes)
+			if err != nil {
+				return err
+			}
+			change.afterSensitive = markPath(change.afterSensitive, steps)
+		case changeImporting:
+			change.importing = decodeImporting(f.bytes)
+		}
+		return nil
+	})
+	if err != nil {
+		return change, err
+	}
+
+	// The values are [before, after] for changes with both sides, otherwise the
+	// single side that exists
+	beforeIdx, afterIdx := -1, -1
+	switch action {
+	case actionNoop:
+		change.actions = []string{"no-op"}
+		beforeIdx, afterIdx = 0, 0
+	case actionCreate:
+		change.actions = []string{"create"}
+		afterIdx = 0
+	case actionRead:
+		change.actions = []string{"read"}
+		beforeIdx, afterIdx = 0, 1
+	case actionUpdate:
+		change.actions = []string{"update"}
+		beforeIdx, afterIdx = 0, 1
+	case actionDelete:
+		change.actions = []string{"delete"}
+		beforeIdx = 0
+	case actionDeleteThenCreate:
+		change.actions = []string{"delete", "create"}
+		beforeIdx, afterIdx = 0, 1
+	case actionCreateThenDelete:
+		change.actions = []string{"create", "delete"}
+		beforeIdx, afterIdx = 0, 1
+	case actionForget:
+		change.actions = []string{"forget"}
+		beforeIdx = 0
+	default:
+		return change, fmt.Errorf("unsupported action %d", action)
+	}
+
+	if beforeIdx >= 0 && beforeIdx < len(values) {
+		change.before = values[beforeIdx]
+	}
+	if afterIdx >= 0 && afterIdx < len(values) {
+		change.after = values[afterIdx]
+	}
+	return change, nil
+}
+
+// decodeChange decodes the Change message of a resource, whose values are objects.
+func decodeChange(b []byte) (models.Change, error) {
+	raw, err := decodeRawChange(b)
+	if err != nil {
+		return models.Change{}, err
+	}
+
+	change := models.Change{Actions: raw.actions, Importing: raw.importing,
+		BeforeSensitive: raw.beforeSensitive, AfterSensitive: raw.afterSensitive}
+	before, _ := splitUnknown(raw.before)
+	change.Before, _ = before.(map[string]interface{})
+	after, unknown := splitUnknown(raw.after)
+	change.After, _ = after.(map[string]interface{})
+	change.AfterUnknown = map[string]interface{}{}
+	if u, ok := unknown.(map[string]interface{}); ok {
+		change.AfterUnknown = u
+	}
+	return change, nil
+}
+
+// decodeDynamicValue decodes a DynamicValue message, stored as msgpack by current
+// Terraform versions and as JSON by some older ones.
+func decodeDynamicValue(b []byte) (interface{}, error) {
+	var v interface{}
+	err := eachField(b, func(num protowire.Number, f field) error {
+		var err error
+		switch num {
+		case dynamicMsgpack:
+			v, err = decodeMsgpack(f.bytes)
+		case dynamicJSON:
+			dec := json.NewDecoder(strings.NewReader(string(f.bytes)))
+			dec.UseNumber()
+			err = dec.Decode(&v)
+		}
+		return err
+	})
+	return v, err
+}
+
+// decodePath decodes a Path message into its steps: attribute names and map keys as
+// strings, list indexes as json.Number.
+func decodePath(b []byte) ([]interface{}, error) {
+	var steps []interface{}
+	err := eachField(b, func(num protowire.Number, f field) error {
+		if num != pathSteps {
+			return nil
+		}
+		return eachField(f.bytes, func(num protowire.Number, f field) error {
+			switch num {
+			case stepAttributeName:
+				steps = append(steps, string(f.bytes))
+			case stepElementKey:
+				key, err := decodeDynamicValue(f.bytes)
+				if err != nil {
+					return err
+				}
+				steps = append(steps, key)
+			}
+			return nil
+		})
+	})
+	return steps, err
+}
+
+// markPath sets the path in an after_sensitive-style mask to true, growing the mask
+// as needed, and returns the updated mask.
+func markPath(mask interface{}, steps []interface{}) interface{} {
+	if marked, _ := mask.(bool); marked || len(steps) == 0 {
+		return true
+	}
+	if n, ok := steps[0].(json.Number); ok {
+		i, err := n.Int64()
+		if err == nil && i >= 0 {
+			list, _ := mask.([]interface{})
+			for int64(len(list)) <= i {
+				list = append(list, false)
+			}
+			list[i] = markPath(list[i], steps[1:])
+			return list
+		}
+	}
+	m, ok := mask.(map[string]interface{})
+	if !ok {
+		m = map[string]interface{}{}
+	}
+	key := fmt.Sprint(steps[0])
+	m[key] = markPath(m[key], steps[1:])
+	return m
+}
+
+func decodeImporting(b []byte) map[string]interface{} {
+	importing := map[string]interface{}{}
+	_ = eachField(b, func(num protowire.Number, f field) error {
+		if num == importingID {
+			importing["id"] = string(f.bytes)
+		}
+		return nil
+	})
+	return importing
+}
+
+// splitUnknown separates a decoded value into its known part, with unknown values
+// replaced by nil, and an after_unknown-style mirror marking the unknown leaves with
+// true. The mirror is nil when nothing in v is unknown.
+func splitUnknown(v interface{}) (known, unknown interface{}) {
+	switch val := v.(type) {
+	case unknownValue:
+		return nil, true
+	case map[string]interface{}:
+		marks := map[string]interface{}{}
+		for k, child := range val {
+			known, u := splitUnknown(child)
+			val[k] = known
+			if u != nil {
+				marks[k] = u
+			}
+		}
+		if len(marks) == 0 {
+			return val, nil
+		}
+		return val, marks
+	case []interface{}:
+		marks := make([]interface{}, len(val))
+		hasUnknown := false
+		for i, child := range val {
+			known, u := splitUnknown(child)
+			val[i] = known
+			if u != nil {
+				marks[i] = u
+				hasUnknown = true
+			} else {
+				marks[i] = false
+			}
+		}
+		if !hasUnknown {
+			return val, nil
+		}
+		return val, marks
+	default:
+		return v, nil
+	}
+}
+
+// parseAddress splits a resource instance address such as
+// `module.net["a"].data.aws_subnet.this[0]` into its module address, mode, type and name.
+func parseAddress(addr string) (module, mode, typ, name string) {
+	rest := addr
+	for strings.HasPrefix(rest, "module.") {
+		end := len("module.")
+		for end < len(rest) && rest[end] != '.' && rest[end] != '[' {
+			end++
+		}
+		if end < len(rest) && rest[end] == '[' {
+			end = skipIndex(rest, end)
+		}
+		if end >= len(rest) {
+			break
+		}
+		rest = rest[end+1:]
+	}
+	if rest != addr {
+		module = addr[:len(addr)-len(rest)-1]
+	}
+
+	mode = "managed"
+	if strings.HasPrefix(rest, "data.") {
+		mode = "data"
+		rest = strings.TrimPrefix(rest, "data.")
+	}
+
+	typ, name, _ = strings.Cut(rest, ".")
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i]
+	}
+	return module, mode, typ, name
+}
+
+// skipIndex returns the position just after the `[...]` instance key starting at
+// s[start], honouring quoted string keys that may contain brackets.
+func skipIndex(s string, start int) int {
+	inString := false
+	for i := start + 1; i < len(s); i++ {
+		switch {
+		case inString && s[i] == '\\':
+			i++
+		case s[i] == '"':
+			inString = !inString
+		case !inString && s[i] == ']':
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// providerName extracts the provider source address from a provider configuration
+// address such as `provider["registry.terraform.io/hashicorp/aws"].east`.
+func providerName(config string) string {
+	if _, rest, ok := strings.Cut(config, `provider["`); ok {
+		if name, _, ok := strings.Cut(rest, `"]`); ok {
+			return name
+		}
+	}
+	// Legacy form: provider.aws or module.x.provider.aws.alias
+	if i := strings.LastIndex(config, "provider."); i >= 0 {
+		name, _, _ := strings.Cut(config[i+len("provider."):], ".")
+		return name
+	}
+	return config
+}
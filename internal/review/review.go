@@ -0,0 +1,83 @@
+// Package review persists which resources of a plan have been marked as reviewed,
+// keyed by plan hash, so a long review can be resumed where it was left.
+package review
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+// Store keeps one JSON file per plan in Dir.
+type Store struct {
+	Dir string
+}
+
+// DefaultStore keeps review state in the user cache directory.
+func DefaultStore() (Store, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return Store{}, err
+	}
+	return Store{Dir: filepath.Join(cache, "tfs", "reviews")}, nil
+}
+
+type state struct {
+	Reviewed []string `json:"reviewed"`
+}
+
+func (s Store) path(plan models.TfPlan) string {
+	return filepath.Join(s.Dir, plan.Hash()+".json")
+}
+
+// Load returns the addresses reviewed in plan, empty for a plan never reviewed.
+func (s Store) Load(plan models.TfPlan) (map[string]bool, error) {
+	reviewed := make(map[string]bool)
+	data, err := os.ReadFile(s.path(plan))
+	if os.IsNotExist(err) {
+		return reviewed, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("failed to decode review state: %w", err)
+	}
+	for _, addr := range st.Reviewed {
+		reviewed[addr] = true
+	}
+	return reviewed, nil
+}
+
+// Save records the addresses reviewed in plan.
+func (s Store) Save(plan models.TfPlan, reviewed map[string]bool) error {
+	st := state{Reviewed: []string{}}
+	for addr, ok := range reviewed {
+		if ok {
+			st.Reviewed = append(st.Reviewed, addr)
+		}
+	}
+	sort.Strings(st.Reviewed)
+
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+
+	// Write then rename, so an interrupted save never leaves a truncated file
+	path := s.path(plan)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
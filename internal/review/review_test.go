@@ -0,0 +1,41 @@
+package review
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+func TestStore(t *testing.T) {
+	store := Store{Dir: t.TempDir()}
+	plan := models.TfPlan{ResourceChanges: []models.ResourceChange{
+		{Address: "aws_instance.web", Change: models.Change{Actions: []string{"create"}}},
+		{Address: "aws_instance.db", Change: models.Change{Actions: []string{"delete"}}},
+	}}
+
+	reviewed, err := store.Load(plan)
+	if err != nil || len(reviewed) != 0 {
+		t.Fatalf("Load() of an unreviewed plan = %v, %v", reviewed, err)
+	}
+
+	if err := store.Save(plan, map[string]bool{"aws_instance.web": true, "aws_instance.db": false}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	reviewed, err = store.Load(plan)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(reviewed, map[string]bool{"aws_instance.web": true}) {
+		t.Errorf("Load() = %v", reviewed)
+	}
+
+	// Another plan has its own state
+	other := models.TfPlan{ResourceChanges: plan.ResourceChanges[:1]}
+	if other.Hash() == plan.Hash() {
+		t.Fatal("different plans share a hash")
+	}
+	if reviewed, _ := store.Load(other); len(reviewed) != 0 {
+		t.Errorf("Load() of another plan = %v", reviewed)
+	}
+}
@@ -0,0 +1,267 @@
+package risk
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+// Severity of a finding. The numeric value is its weight in the risk score.
+type Severity int
+
+const (
+	SeverityLow      Severity = 10
+	SeverityMedium   Severity = 25
+	SeverityHigh     Severity = 50
+	SeverityCritical Severity = 80
+)
+
+func (s Severity) String() string {
+	switch {
+	case s >= SeverityCritical:
+		return "critical"
+	case s >= SeverityHigh:
+		return "high"
+	case s >= SeverityMedium:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// Rule is a single check run against every resource change.
+type Rule struct {
+	ID          string
+	Name        string
+	Description string
+	Severity    Severity
+	// Check returns a message describing the problem, or "" when the rule passes.
+	Check func(rc models.ResourceChange) string
+}
+
+type Finding struct {
+	RuleID   string   `json:"rule_id"`
+	Severity Severity `json:"-"`
+	Level    string   `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// Assessment is the risk analysis of one resource change.
+type Assessment struct {
+	Score    int       `json:"score"` // 0 (harmless) to 100
+	Level    string    `json:"level"` // none, low, medium, high
+	Findings []Finding `json:"findings"`
+}
+
+// HighThreshold is the score from which a resource is considered high risk.
+const HighThreshold = 50
+
+// IsHigh reports whether the resource needs extra care when reviewing.
+func (a Assessment) IsHigh() bool {
+	return a.Score >= HighThreshold
+}
+
+// Assess runs every rule against rc.
+func Assess(rc models.ResourceChange) Assessment {
+	a := Assessment{Findings: []Finding{}}
+	for _, rule := range Rules {
+		msg := rule.Check(rc)
+		if msg == "" {
+			continue
+		}
+		a.Findings = append(a.Findings, Finding{
+			RuleID:   rule.ID,
+			Severity: rule.Severity,
+			Level:    rule.Severity.String(),
+			Message:  msg,
+		})
+		a.Score += int(rule.Severity)
+	}
+	if a.Score > 100 {
+		a.Score = 100
+	}
+
+	switch {
+	case a.Score >= HighThreshold:
+		a.Level = "high"
+	case a.Score >= int(SeverityMedium):
+		a.Level = "medium"
+	case a.Score > 0:
+		a.Level = "low"
+	default:
+		a.Level = "none"
+	}
+	return a
+}
+
+// Rules are evaluated in order; IDs are stable and used by the JUnit/SARIF outputs.
+var Rules = []Rule{
+	{
+		ID:          "TFS001",
+		Name:        "resource-destroyed",
+		Description: "The resource will be destroyed.",
+		Severity:    SeverityHigh,
+		Check: func(rc models.ResourceChange) string {
+			if models.Categorize(rc) == models.CategoryDestroy {
+				return fmt.Sprintf("%s will be destroyed", rc.Address)
+			}
+			return ""
+		},
+	},
+	{
+		ID:          "TFS002",
+		Name:        "resource-replaced",
+		Description: "The resource will be destroyed and re-created.",
+		Severity:    SeverityHigh,
+		Check: func(rc models.ResourceChange) string {
+			if rc.IsReplace() {
+				return fmt.Sprintf("%s must be replaced", rc.Address)
+			}
+			return ""
+		},
+	},
+	{
+		ID:          "TFS003",
+		Name:        "stateful-resource-removed",
+		Description: "A resource holding data (database, bucket, volume...) is destroyed or replaced.",
+		Severity:    SeverityCritical,
+		Check: func(rc models.ResourceChange) string {
+			cat := models.Categorize(rc)
+			if (cat == models.CategoryDestroy || cat == models.CategoryReplace) && isStateful(rc.Type) {
+				return fmt.Sprintf("%s holds data that may be lost", rc.Address)
+			}
+			return ""
+		},
+	},
+	{
+		ID:          "TFS004",
+		Name:        "access-control-change",
+		Description: "An IAM role, policy or permission is changed.",
+		Severity:    SeverityMedium,
+		Check: func(rc models.ResourceChange) string {
+			if hasTypeSuffix(rc.Type, accessControlTypes) {
+				return fmt.Sprintf("%s changes access control", rc.Address)
+			}
+			return ""
+		},
+	},
+	{
+		ID:          "TFS005",
+		Name:        "network-exposure-change",
+		Description: "A security group, firewall or network ACL is changed.",
+		Severity:    SeverityMedium,
+		Check: func(rc models.ResourceChange) string {
+			if hasTypeSuffix(rc.Type, networkTypes) {
+				return fmt.Sprintf("%s changes network access rules", rc.Address)
+			}
+			return ""
+		},
+	},
+	{
+		ID:          "TFS006",
+		Name:        "open-to-internet",
+		Description: "The planned configuration allows traffic from any address (0.0.0.0/0 or ::/0).",
+		Severity:    SeverityHigh,
+		Check: func(rc models.ResourceChange) string {
+			if containsValue(rc.Change.After, "0.0.0.0/0") || containsValue(rc.Change.After, "::/0") {
+				return fmt.Sprintf("%s allows traffic from the whole internet", rc.Address)
+			}
+			return ""
+		},
+	},
+	{
+		ID:          "TFS007",
+		Name:        "deletion-protection-disabled",
+		Description: "Deletion protection is turned off.",
+		Severity:    SeverityHigh,
+		Check: func(rc models.ResourceChange) string {
+			// prevent_destroy is a lifecycle argument, it never shows in the planned values
+			if rc.Change.Before["deletion_protection"] == true && rc.Change.After["deletion_protection"] == false {
+				return fmt.Sprintf("%s disables deletion_protection", rc.Address)
+			}
+			return ""
+		},
+	},
+}
+
+// The type lists below leave out the provider prefix: a type matches when it ends with
+// one of them on a "_" boundary (aws_db_instance, google_sql_database_instance), so
+// aws_route_table or aws_s3_bucket_policy do not match s3_bucket.
+
+// statefulTypes are the resource types holding data.
+var statefulTypes = []string{
+	// Databases
+	"db_instance", "rds_cluster", "docdb_cluster", "neptune_cluster", "redshift_cluster",
+	"dynamodb_table", "sql_database_instance", "sql_database", "mssql_database",
+	"mysql_flexible_server", "postgresql_flexible_server", "postgresql_server", "mysql_server",
+	"cosmosdb_account", "bigtable_instance", "bigtable_table", "spanner_instance",
+	"spanner_database", "firestore_database", "opensearch_domain", "elasticsearch_domain",
+	// Caches
+	"elasticache_cluster", "elasticache_replication_group", "redis_cache", "redis_instance",
+	// Object, block and file storage
+	"s3_bucket", "storage_bucket", "storage_account", "ebs_volume", "compute_disk",
+	"managed_disk", "efs_file_system", "fsx_lustre_file_system", "fsx_windows_file_system",
+	"filestore_instance",
+	// Keys and secrets
+	"kms_key", "kms_crypto_key", "key_vault", "key_vault_key", "key_vault_secret",
+	"secretsmanager_secret", "secret_manager_secret",
+}
+
+// accessControlTypes are the resource types granting permissions.
+var accessControlTypes = []string{
+	// AWS IAM and resource policies
+	"iam_role", "iam_policy", "iam_role_policy", "iam_role_policy_attachment",
+	"iam_user", "iam_user_policy", "iam_user_policy_attachment", "iam_group",
+	"iam_group_policy", "iam_group_policy_attachment", "iam_group_membership",
+	"iam_user_group_membership", "iam_policy_attachment", "iam_instance_profile",
+	"iam_access_key", "iam_openid_connect_provider", "s3_bucket_policy",
+	"sns_topic_policy", "sqs_queue_policy", "ecr_repository_policy", "kms_grant",
+	"lambda_permission", "secretsmanager_secret_policy",
+	// Google Cloud IAM (google_project_iam_member, google_storage_bucket_iam_binding...)
+	"iam_member", "iam_binding", "iam_custom_role", "service_account", "service_account_key",
+	// Azure RBAC
+	"role_assignment", "role_definition", "key_vault_access_policy",
+}
+
+// networkTypes are the resource types filtering network traffic.
+var networkTypes = []string{
+	"security_group", "security_group_rule", "security_group_ingress_rule",
+	"security_group_egress_rule", "network_acl", "network_acl_rule",
+	"firewall", "compute_firewall", "firewall_policy", "firewall_rule_group",
+	"networkfirewall_rule_group", "network_security_group", "network_security_rule",
+}
+
+func isStateful(resourceType string) bool {
+	return hasTypeSuffix(resourceType, statefulTypes)
+}
+
+func hasTypeSuffix(resourceType string, types []string) bool {
+	for _, t := range types {
+		if resourceType == t || strings.HasSuffix(resourceType, "_"+t) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsValue reports whether target appears anywhere in v (recursing into maps and lists).
+func containsValue(v interface{}, target string) bool {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, item := range val {
+			if containsValue(item, target) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range val {
+			if containsValue(item, target) {
+				return true
+			}
+		}
+	case string:
+		return val == target
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package risk
+
+import (
+	"testing"
+
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+func TestAssess(t *testing.T) {
+	tests := []struct {
+		name     string
+		rc       models.ResourceChange
+		rules    []string
+		expected string
+	}{
+		{
+			"Create",
+			models.ResourceChange{Type: "aws_instance", Change: models.Change{Actions: []string{"create"}}},
+			nil,
+			"none",
+		},
+		{
+			"DestroyBucket",
+			models.ResourceChange{Type: "aws_s3_bucket", Change: models.Change{Actions: []string{"delete"}}},
+			[]string{"TFS001", "TFS003"},
+			"high",
+		},
+		{
+			"OpenSecurityGroup",
+			models.ResourceChange{
+				Type: "aws_security_group_rule",
+				Change: models.Change{
+					Actions: []string{"update"},
+					After:   map[string]interface{}{"cidr_blocks": []interface{}{"0.0.0.0/0"}},
+				},
+			},
+			[]string{"TFS005", "TFS006"},
+			"high",
+		},
+		{
+			"IAMPolicy",
+			models.ResourceChange{Type: "aws_iam_policy", Change: models.Change{Actions: []string{"update"}}},
+			[]string{"TFS004"},
+			"medium",
+		},
+		{
+			"AutoscalingPolicy",
+			models.ResourceChange{Type: "aws_appautoscaling_policy", Change: models.Change{Actions: []string{"update"}}},
+			nil,
+			"none",
+		},
+		{
+			"DeletionProtectionOff",
+			models.ResourceChange{
+				Type: "aws_lb",
+				Change: models.Change{
+					Actions: []string{"update"},
+					Before:  map[string]interface{}{"enable_deletion_protection": true, "deletion_protection": true},
+					After:   map[string]interface{}{"enable_deletion_protection": true, "deletion_protection": false},
+				},
+			},
+			[]string{"TFS007"},
+			"high",
+		},
+	}
+
+	for _, tt := range tests {
+		a := Assess(tt.rc)
+		if a.Level != tt.expected {
+			t.Errorf("%s: Level = %q; want %q", tt.name, a.Level, tt.expected)
+		}
+		if len(a.Findings) != len(tt.rules) {
+			t.Errorf("%s: got %d findings; want %v", tt.name, len(a.Findings), tt.rules)
+			continue
+		}
+		for i, id := range tt.rules {
+			if a.Findings[i].RuleID != id {
+				t.Errorf("%s: finding %d = %s; want %s", tt.name, i, a.Findings[i].RuleID, id)
+			}
+		}
+	}
+}
+
+func TestIsStateful(t *testing.T) {
+	tests := []struct {
+		resourceType string
+		expected     bool
+	}{
+		{"aws_db_instance", true},
+		{"aws_s3_bucket", true},
+		{"google_sql_database_instance", true},
+		{"azurerm_storage_account", true},
+		{"aws_dynamodb_table", true},
+		{"aws_route_table", false},
+		{"aws_route_table_association", false},
+		{"aws_s3_bucket_policy", false},
+		{"aws_secretsmanager_secret_version", false},
+		{"aws_instance", false},
+	}
+	for _, tt := range tests {
+		if got := isStateful(tt.resourceType); got != tt.expected {
+			t.Errorf("isStateful(%q) = %v; want %v", tt.resourceType, got, tt.expected)
+		}
+	}
+}
+
+func TestTypeLists(t *testing.T) {
+	tests := []struct {
+		resourceType string
+		types        []string
+		expected     bool
+	}{
+		{"aws_iam_role_policy_attachment", accessControlTypes, true},
+		{"google_project_iam_member", accessControlTypes, true},
+		{"azurerm_role_assignment", accessControlTypes, true},
+		{"aws_s3_bucket_policy", accessControlTypes, true},
+		{"aws_appautoscaling_policy", accessControlTypes, false},
+		{"aws_autoscaling_policy", accessControlTypes, false},
+		{"aws_s3_bucket_public_access_block", accessControlTypes, false},
+		{"aws_security_group", networkTypes, true},
+		{"aws_vpc_security_group_ingress_rule", networkTypes, true},
+		{"google_compute_firewall", networkTypes, true},
+		{"azurerm_network_security_group", networkTypes, true},
+		{"aws_default_network_acl", networkTypes, true},
+		{"aws_lb_listener", networkTypes, false},
+		{"aws_vpc_endpoint_connection_accepter", networkTypes, false},
+	}
+	for _, tt := range tests {
+		if got := hasTypeSuffix(tt.resourceType, tt.types); got != tt.expected {
+			t.Errorf("hasTypeSuffix(%q) = %v; want %v", tt.resourceType, got, tt.expected)
+		}
+	}
+}
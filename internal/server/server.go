@@ -0,0 +1,209 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bernard-sh/tfs/internal/models"
+	"github.com/bernard-sh/tfs/internal/summary"
+	"github.com/bernard-sh/tfs/internal/web"
+)
+
+// Loader reads the plan being served. It is called again on every reload.
+type Loader func() (models.TfPlan, error)
+
+// Server hosts the HTML report of a plan and a small JSON API:
+//
+//	GET /             the report, reloading itself when the plan changes
+//	GET /api/plan     the plan data
+//	GET /api/summary  the versioned summary (same as `tfs summary --format json`)
+//	GET /api/events   server-sent "reload" events
+type Server struct {
+	load Loader
+
+	mu          sync.RWMutex
+	plan        models.TfPlan
+	html        []byte
+	subscribers map[chan struct{}]struct{}
+}
+
+// New loads the plan once and returns a server for it.
+func New(load Loader) (*Server, error) {
+	s := &Server{load: load, subscribers: make(map[chan struct{}]struct{})}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload reads the plan again and tells connected browsers to refresh.
+// On error the previous plan keeps being served.
+func (s *Server) Reload() error {
+	plan, err := s.load()
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := web.Render(&buf, plan, web.Options{LiveReload: true}); err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.plan = plan
+	s.html = buf.Bytes()
+	for ch := range s.subscribers {
+		// Non-blocking: a pending notification is as good as two
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Watch polls path and reloads the plan whenever its modification time changes,
+// until ctx is done.
+func (s *Server) Watch(ctx context.Context, path string, interval time.Duration) {
+	lastMod := modTime(path)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			mod := modTime(path)
+			if mod.Equal(lastMod) {
+				continue
+			}
+			// A plan still being written fails to load, so it is retried on the next tick
+			if err := s.Reload(); err != nil {
+				log.Printf("Failed to reload %s: %v", path, err)
+				continue
+			}
+			lastMod = mod
+			log.Printf("Reloaded %s", path)
+		}
+	}
+}
+
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// Handler serves the report and the API to requests addressed to a loopback host or
+// to addr, the address the server listens on. Other Host headers are rejected, so a
+// page on another site cannot read the plan through DNS rebinding.
+func (s *Server) Handler(addr string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handleReport)
+	mux.HandleFunc("GET /api/plan", s.handlePlan)
+	mux.HandleFunc("GET /api/summary", s.handleSummary)
+	mux.HandleFunc("GET /api/events", s.handleEvents)
+
+	bindHost := hostname(addr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := hostname(r.Host)
+		if !isLoopback(host) && (bindHost == "" || !strings.EqualFold(host, bindHost)) {
+			http.Error(w, "invalid Host header", http.StatusForbidden)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// hostname strips the port, if any, and the brackets of an IPv6 address.
+func hostname(hostport string) string {
+	if host, _, err := net.SplitHostPort(hostport); err == nil {
+		return host
+	}
+	return strings.Trim(hostport, "[]")
+}
+
+func isLoopback(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	html := s.html
+	s.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(html)
+}
+
+func (s *Server) handlePlan(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	plan := s.plan
+	s.mu.RUnlock()
+
+	writeJSON(w, plan)
+}
+
+func (s *Server) handleSummary(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	plan := s.plan
+	s.mu.RUnlock()
+
+	writeJSON(w, summary.NewReport(plan))
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ch := make(chan struct{}, 1)
+	s.mu.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, ch)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ch:
+			fmt.Fprint(w, "event: reload\ndata: {}\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+func testServer(t *testing.T) (*Server, *httptest.Server, *string) {
+	address := "res.first"
+	s, err := New(func() (models.TfPlan, error) {
+		return models.TfPlan{ResourceChanges: []models.ResourceChange{
+			{Address: address, Type: "res", Name: "first", Change: models.Change{Actions: []string{"create"}}},
+		}}, nil
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	srv := httptest.NewServer(s.Handler("127.0.0.1:0"))
+	t.Cleanup(srv.Close)
+	return s, srv, &address
+}
+
+func TestHandler(t *testing.T) {
+	_, srv, _ := testServer(t)
+
+	resp, err := http.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "res.first") || !strings.Contains(string(body), "/api/events") {
+		t.Errorf("Report is missing the plan data or the live reload script")
+	}
+
+	resp, err = http.Get(srv.URL + "/api/summary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report struct {
+		Counts struct{ Create int } `json:"counts"`
+	}
+	json.NewDecoder(resp.Body).Decode(&report)
+	resp.Body.Close()
+	if report.Counts.Create != 1 {
+		t.Errorf("/api/summary create count = %d; want 1", report.Counts.Create)
+	}
+
+	resp, err = http.Get(srv.URL + "/nope")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Unknown path returned %d; want 404", resp.StatusCode)
+	}
+}
+
+func TestHandler_Host(t *testing.T) {
+	s, err := New(func() (models.TfPlan, error) { return models.TfPlan{}, nil })
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	tests := []struct {
+		addr, host string
+		expected   int
+	}{
+		{"127.0.0.1:8080", "127.0.0.1:8080", http.StatusOK},
+		{"127.0.0.1:8080", "localhost:8080", http.StatusOK},
+		{"127.0.0.1:8080", "[::1]:8080", http.StatusOK},
+		{"127.0.0.1:8080", "attacker.example:8080", http.StatusForbidden},
+		{"127.0.0.1:8080", "attacker.example", http.StatusForbidden},
+		{"devbox:8080", "devbox:8080", http.StatusOK},
+		{"devbox:8080", "attacker.example:8080", http.StatusForbidden},
+		{":8080", "attacker.example:8080", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/plan", nil)
+		req.Host = tt.host
+		rec := httptest.NewRecorder()
+		s.Handler(tt.addr).ServeHTTP(rec, req)
+		if rec.Code != tt.expected {
+			t.Errorf("Handler(%q) with Host %q returned %d; want %d", tt.addr, tt.host, rec.Code, tt.expected)
+		}
+	}
+}
+
+func TestReloadNotifiesEvents(t *testing.T) {
+	s, srv, address := testServer(t)
+
+	resp, err := http.Get(srv.URL + "/api/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	// Wait for the subscription to be registered before reloading
+	for i := 0; i < 100; i++ {
+		s.mu.RLock()
+		n := len(s.subscribers)
+		s.mu.RUnlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	*address = "res.second"
+	if err := s.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil || line != "event: reload\n" {
+		t.Errorf("Expected a reload event, got %q (%v)", line, err)
+	}
+
+	planResp, err := http.Get(srv.URL + "/api/plan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer planResp.Body.Close()
+	body, _ := io.ReadAll(planResp.Body)
+	if !strings.Contains(string(body), "res.second") {
+		t.Errorf("/api/plan does not serve the reloaded plan: %s", body)
+	}
+}
+
+func TestWatchRetriesFailedReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	loads := 0
+	s, err := New(func() (models.TfPlan, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		loads++
+		if loads == 2 {
+			return models.TfPlan{}, errors.New("plan still being written")
+		}
+		return models.TfPlan{TerraformVersion: fmt.Sprint(loads)}, nil
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Watch(ctx, path, 10*time.Millisecond)
+
+	time.Sleep(20 * time.Millisecond)
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100; i++ {
+		s.mu.RLock()
+		version := s.plan.TerraformVersion
+		s.mu.RUnlock()
+		if version == "3" {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("Watch did not retry the reload that failed")
+}
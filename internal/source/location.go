@@ -0,0 +1,95 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Downloader fetches an object from a bucket; the S3 and GCS uploaders implement it.
+type Downloader interface {
+	Download(ctx context.Context, bucket, key string) ([]byte, error)
+}
+
+// StdinLocation reads the plan from standard input.
+const StdinLocation = "-"
+
+// IsRemote reports whether location is a URL other than file://.
+func IsRemote(location string) bool {
+	scheme, _, ok := strings.Cut(location, "://")
+	return ok && scheme != "file"
+}
+
+// LocalPath returns the file path of a location, resolving file:// URLs. It
+// returns false for stdin and bucket URLs, which have no directory and can't be
+// watched for changes.
+func LocalPath(location string) (string, bool) {
+	if location == StdinLocation || IsRemote(location) {
+		return "", false
+	}
+	if strings.HasPrefix(location, "file://") {
+		u, err := url.Parse(location)
+		if err != nil || (u.Host != "" && u.Host != "localhost") {
+			return "", false
+		}
+		return u.Path, true
+	}
+	return location, true
+}
+
+// read returns the content of location, with its file path when it's local.
+func (s Source) read(location string) (data []byte, path string, err error) {
+	if location == StdinLocation {
+		in := s.Input
+		if in == nil {
+			in = os.Stdin
+		}
+		data, err := io.ReadAll(in)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read plan from stdin: %w", err)
+		}
+		return data, "", nil
+	}
+
+	if IsRemote(location) {
+		data, err := s.download(location)
+		return data, "", err
+	}
+
+	path, ok := LocalPath(location)
+	if !ok {
+		return nil, "", fmt.Errorf("invalid file URL %q (expected file:///absolute/path)", location)
+	}
+	data, err = os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, "", fmt.Errorf("file does not exist: %s", path)
+	}
+	return data, path, err
+}
+
+func (s Source) download(location string) ([]byte, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid plan URL %q: %w", location, err)
+	}
+	if u.Scheme != "s3" && u.Scheme != "gs" {
+		return nil, fmt.Errorf("unsupported plan URL scheme %q (expected s3, gs or file)", u.Scheme)
+	}
+	key := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || key == "" {
+		return nil, fmt.Errorf("invalid plan URL %q (expected %s://bucket/key)", location, u.Scheme)
+	}
+	if s.Remote == nil {
+		return nil, fmt.Errorf("reading %s:// URLs is not supported here", u.Scheme)
+	}
+
+	ctx := context.Background()
+	d, err := s.Remote(ctx, u.Scheme)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s client: %w", u.Scheme, err)
+	}
+	return d.Download(ctx, u.Host, key)
+}
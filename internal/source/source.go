@@ -0,0 +1,280 @@
+// Package source turns plan files into the plan model, either by itself (JSON or
+// natively decoded binary plans) or through the show command of terraform, OpenTofu
+// or Terragrunt.
+package source
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/bernard-sh/tfs/internal/models"
+	"github.com/bernard-sh/tfs/internal/planfile"
+)
+
+// Kind selects how a plan file is read.
+type Kind string
+
+const (
+	Auto       Kind = "auto"       // JSON as is, binary plans natively, then the detected binary's show
+	Native     Kind = "native"     // Binary plan decoded by tfs
+	JSON       Kind = "json"       // Output of `show -json`
+	Terraform  Kind = "terraform"  // terraform show -json
+	Tofu       Kind = "tofu"       // tofu show -json
+	Terragrunt Kind = "terragrunt" // terragrunt show -json
+)
+
+// Kinds lists the valid kinds, in the order shown in help texts.
+var Kinds = []Kind{Auto, Native, JSON, Terraform, Tofu, Terragrunt}
+
+// ParseKind validates a --source value; the empty string means Auto.
+func ParseKind(s string) (Kind, error) {
+	if s == "" {
+		return Auto, nil
+	}
+	for _, k := range Kinds {
+		if string(k) == s {
+			return k, nil
+		}
+	}
+	names := make([]string, len(Kinds))
+	for i, k := range Kinds {
+		names[i] = string(k)
+	}
+	return "", fmt.Errorf("invalid source %q (expected %s)", s, strings.Join(names, ", "))
+}
+
+// IsBinary reports whether the kind runs an external show command.
+func (k Kind) IsBinary() bool {
+	return k == Terraform || k == Tofu || k == Terragrunt
+}
+
+// Source says how plan files are read.
+type Source struct {
+	Kind Kind
+	Dir  string   // Working directory of the show command, the plan's directory when empty
+	Env  []string // Extra KEY=VALUE environment of the show command
+	Args []string // Extra show arguments, placed before the plan file
+
+	Input  io.Reader                                                    // Read for "-", os.Stdin when nil
+	Remote func(ctx context.Context, scheme string) (Downloader, error) // Client for s3:// and gs:// locations
+
+	// Config is set when the caller needs the plan's configuration (dependencies,
+	// declarations), which natively decoded plans lack: Auto then prefers the show
+	// command for binary plans.
+	Config bool
+	Warn   func(msg string) // Reports problems worked around, e.g. a plan read without its configuration; nil to ignore
+}
+
+// Load reads the plan at location: a file path, a file://, s3:// or gs:// URL, or
+// "-" for stdin.
+func (s Source) Load(location string) (models.TfPlan, error) {
+	data, path, err := s.read(location)
+	if err != nil {
+		return models.TfPlan{}, err
+	}
+	plan, err := s.decode(location, path, data)
+	if err != nil {
+		return plan, err
+	}
+	// Hash the file rather than what was read from it, which depends on the source
+	plan.Meta.InputHash = models.HashInput(data)
+	return plan, nil
+}
+
+// decode turns the content of the plan file at location into the plan model. path
+// is the local file it was read from, "" for other locations.
+func (s Source) decode(location, path string, data []byte) (models.TfPlan, error) {
+	switch s.Kind {
+	case Auto, "":
+		if !planfile.IsPlanFile(data) {
+			return models.ParsePlan(string(data))
+		}
+		plan, err := planfile.Decode(data)
+		if err == nil && !(s.Config && plan.Lacks(models.SectionConfiguration)) {
+			return plan, nil
+		}
+		// Plan formats newer than the decoder, and configurations, still work through
+		// the show command
+		bin := Detect(s.dir(path))
+		shown, showErr := s.show(bin, path, data)
+		switch {
+		case showErr == nil:
+			if err == nil {
+				// Keep what only the plan file records
+				shown.Meta.Workspace, shown.Meta.ProviderVersions = plan.Meta.Workspace, plan.Meta.ProviderVersions
+			}
+			return shown, nil
+		case err != nil:
+			return models.TfPlan{}, fmt.Errorf("%w; %v", err, showErr)
+		}
+		s.warnConfig(location, plan, showErr)
+		return plan, nil
+	case Native:
+		if !planfile.IsPlanFile(data) {
+			return models.TfPlan{}, fmt.Errorf("%s is not a binary plan file", location)
+		}
+		plan, err := planfile.Decode(data)
+		if err == nil {
+			s.warnConfig(location, plan, nil)
+		}
+		return plan, err
+	case JSON:
+		return models.ParsePlan(string(data))
+	default:
+		if !s.Kind.IsBinary() {
+			return models.TfPlan{}, fmt.Errorf("invalid source %q", s.Kind)
+		}
+		return s.show(s.Kind, path, data)
+	}
+}
+
+// warnConfig warns callers needing the configuration that the plan at location was
+// read without it, and why the show command could not provide it.
+func (s Source) warnConfig(location string, plan models.TfPlan, showErr error) {
+	if !s.Config || s.Warn == nil || !plan.Lacks(models.SectionConfiguration) {
+		return
+	}
+	msg := location + " was decoded without its configuration: dependencies, blast radius and declarations are unavailable"
+	if showErr != nil {
+		msg += fmt.Sprintf(" (%v)", showErr)
+	} else {
+		msg += "; read it with --source terraform, tofu or terragrunt instead"
+	}
+	s.Warn(msg)
+}
+
+// dir is the working directory of the show command for the plan at path: the
+// plan's directory, or the current one for plans not read from a file.
+func (s Source) dir(path string) string {
+	if s.Dir != "" {
+		return s.Dir
+	}
+	if path == "" {
+		return "."
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return filepath.Dir(abs)
+	}
+	return filepath.Dir(path)
+}
+
+// ConfigDir is the root module directory of the plan at location, where its
+// declarations are looked up: the working directory of its show command.
+func (s Source) ConfigDir(location string) string {
+	path, _ := LocalPath(location)
+	return s.dir(path)
+}
+
+// show runs `<bin> show -json` on the plan, writing plans not read from a file to a
+// temporary one first.
+func (s Source) show(bin Kind, path string, data []byte) (models.TfPlan, error) {
+	dir := s.dir(path)
+	if path == "" {
+		tmp, err := os.CreateTemp("", "tfs-*.tfplan")
+		if err != nil {
+			return models.TfPlan{}, err
+		}
+		defer os.Remove(tmp.Name())
+		_, err = tmp.Write(data)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return models.TfPlan{}, fmt.Errorf("failed to write temporary plan: %w", err)
+		}
+		path = tmp.Name()
+	}
+
+	out, err := s.runShow(bin, dir, path)
+	if err != nil {
+		return models.TfPlan{}, err
+	}
+	plan, err := models.ParsePlan(string(out))
+	if err != nil {
+		return models.TfPlan{}, fmt.Errorf("%s show -json returned invalid output: %w", bin, err)
+	}
+	return plan, nil
+}
+
+// Show returns the `<bin> show -json` output for the plan file at path, run from the
+// plan's directory (or Dir).
+func (s Source) Show(bin Kind, path string) ([]byte, error) {
+	return s.runShow(bin, s.dir(path), path)
+}
+
+// runShow runs the show command, reporting its stderr on failure.
+func (s Source) runShow(bin Kind, dir, path string) ([]byte, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	args := append([]string{"show", "-json"}, s.Args...)
+	args = append(args, abs)
+
+	cmd := exec.Command(string(bin), args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), s.Env...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s show -json failed in %s: %w\n%s", bin, cmd.Dir, err, msg)
+		}
+		return nil, fmt.Errorf("%s show -json failed in %s: %w", bin, cmd.Dir, err)
+	}
+	return out, nil
+}
+
+// Detect picks the show binary for the project in dir: Terragrunt when dir or a
+// parent has a terragrunt.hcl, OpenTofu when the lock file or *.tofu files point to
+// it, terraform otherwise. A binary missing from PATH gives way to an installed one.
+func Detect(dir string) Kind {
+	kind := detectProject(dir)
+	if _, err := exec.LookPath(string(kind)); err == nil {
+		return kind
+	}
+	for _, k := range []Kind{Terraform, Tofu} {
+		if _, err := exec.LookPath(string(k)); err == nil {
+			return k
+		}
+	}
+	return kind
+}
+
+func detectProject(dir string) Kind {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	for d := dir; ; {
+		if fileExists(filepath.Join(d, "terragrunt.hcl")) {
+			return Terragrunt
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			break
+		}
+		d = parent
+	}
+
+	if lock, err := os.ReadFile(filepath.Join(dir, ".terraform.lock.hcl")); err == nil &&
+		bytes.Contains(lock, []byte("registry.opentofu.org")) {
+		return Tofu
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*.tofu")); len(matches) > 0 {
+		return Tofu
+	}
+	return Terraform
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,295 @@
+package source
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+const planJSON = `{"resource_changes":[{"address":"null_resource.a","type":"null_resource","name":"a","change":{"actions":["create"]}}]}`
+
+// fakeBinary installs an executable shell script named name on an isolated PATH.
+func fakeBinary(t *testing.T, name, script string) string {
+	t.Helper()
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, name), []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+	return bin
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseKind(t *testing.T) {
+	if k, err := ParseKind(""); err != nil || k != Auto {
+		t.Errorf("ParseKind(\"\") = %q, %v", k, err)
+	}
+	if k, err := ParseKind("tofu"); err != nil || k != Tofu {
+		t.Errorf("ParseKind(tofu) = %q, %v", k, err)
+	}
+	if _, err := ParseKind("pulumi"); err == nil || !strings.Contains(err.Error(), "terragrunt") {
+		t.Errorf("ParseKind(pulumi) error = %v, want the list of valid sources", err)
+	}
+}
+
+func TestLoad_JSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	writeFile(t, path, planJSON)
+
+	for _, kind := range []Kind{Auto, JSON} {
+		plan, err := Source{Kind: kind}.Load(path)
+		if err != nil {
+			t.Fatalf("%s: Load() error = %v", kind, err)
+		}
+		if len(plan.ResourceChanges) != 1 {
+			t.Errorf("%s: got %d resource changes", kind, len(plan.ResourceChanges))
+		}
+	}
+
+	if _, err := (Source{Kind: Native}).Load(path); err == nil {
+		t.Error("native source should reject JSON")
+	}
+	if _, err := (Source{}).Load(filepath.Join(t.TempDir(), "missing")); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("missing file error = %v", err)
+	}
+}
+
+func TestLoad_Show(t *testing.T) {
+	// Only shell builtins are available on the isolated PATH
+	fakeBinary(t, "tofu", `echo "$PWD $FOO $*" > "${0%/*}/args"
+echo '`+planJSON+`'
+`)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "plan.tfplan")
+	writeFile(t, path, "binary")
+
+	src := Source{Kind: Tofu, Env: []string{"FOO=bar"}, Args: []string{"-no-color"}}
+	plan, err := src.Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(plan.ResourceChanges) != 1 {
+		t.Errorf("got %d resource changes", len(plan.ResourceChanges))
+	}
+
+	args, err := os.ReadFile(filepath.Join(os.Getenv("PATH"), "args"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := dir + " bar show -json -no-color " + path + "\n"
+	if string(args) != want {
+		t.Errorf("invocation = %q, want %q", args, want)
+	}
+}
+
+func TestLoad_ShowError(t *testing.T) {
+	fakeBinary(t, "terraform", "echo 'Error: Failed to load plugin schemas' >&2\nexit 1\n")
+	path := filepath.Join(t.TempDir(), "plan.tfplan")
+	writeFile(t, path, "binary")
+
+	_, err := Source{Kind: Terraform}.Load(path)
+	if err == nil {
+		t.Fatal("Load() should fail when the show command fails")
+	}
+	if !strings.Contains(err.Error(), "terraform show -json failed") || !strings.Contains(err.Error(), "Failed to load plugin schemas") {
+		t.Errorf("error = %v, want the command and its stderr", err)
+	}
+}
+
+// writePlanFile writes a binary plan file without changes.
+func writePlanFile(t *testing.T, path string) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, name := range []string{"tfplan", "tfstate"} {
+		if _, err := w.Create(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, path, buf.String())
+}
+
+func TestLoad_Config(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.tfplan")
+	writePlanFile(t, path)
+	fakeBinary(t, "terraform", `echo shown > "${0%/*}/shown"
+echo '`+planJSON+`'
+`)
+	shown := filepath.Join(os.Getenv("PATH"), "shown")
+
+	// Without the need for a configuration, binary plans are decoded natively
+	plan, err := Source{}.Load(path)
+	if err != nil || len(plan.ResourceChanges) != 0 || !plan.Lacks(models.SectionConfiguration) {
+		t.Fatalf("Load() = %+v, %v; want the native plan", plan, err)
+	}
+	if _, err := os.Stat(shown); err == nil {
+		t.Error("Load() ran the show command without the need for a configuration")
+	}
+	nativeHash := plan.Hash()
+
+	var warnings []string
+	src := Source{Config: true, Warn: func(msg string) { warnings = append(warnings, msg) }}
+	plan, err = src.Load(path)
+	if err != nil || len(plan.ResourceChanges) != 1 || plan.Lacks(models.SectionConfiguration) {
+		t.Fatalf("Load() = %+v, %v; want the plan of the show command", plan, err)
+	}
+	if plan.Hash() != nativeHash {
+		t.Errorf("Hash() = %s through show, %s natively; want the same hash for the same file", plan.Hash(), nativeHash)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("Unexpected warnings: %v", warnings)
+	}
+
+	// When the show command fails, the native plan is kept with a warning
+	fakeBinary(t, "terraform", "echo 'Error: Backend initialization required' >&2\nexit 1\n")
+	plan, err = src.Load(path)
+	if err != nil || !plan.Lacks(models.SectionConfiguration) {
+		t.Fatalf("Load() = %+v, %v; want the native plan", plan, err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "without its configuration") || !strings.Contains(warnings[0], "Backend initialization required") {
+		t.Errorf("warnings = %q; want the missing configuration and the show error", warnings)
+	}
+
+	src.Kind = Native
+	warnings = nil
+	if _, err := src.Load(path); err != nil || len(warnings) != 1 || !strings.Contains(warnings[0], "--source terraform") {
+		t.Errorf("Native Load() = %v, warnings %q; want a warning suggesting the show command", err, warnings)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	fakeBinary(t, "terraform", "")
+	bin := os.Getenv("PATH")
+	for _, name := range []string{"tofu", "terragrunt"} {
+		writeFile(t, filepath.Join(bin, name), "#!/bin/sh\n")
+		os.Chmod(filepath.Join(bin, name), 0o755)
+	}
+
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "plain", "main.tf"), "")
+	writeFile(t, filepath.Join(root, "tofu", ".terraform.lock.hcl"), `provider "registry.opentofu.org/hashicorp/aws" {}`)
+	writeFile(t, filepath.Join(root, "tofufiles", "main.tofu"), "")
+	writeFile(t, filepath.Join(root, "live", "terragrunt.hcl"), "")
+	writeFile(t, filepath.Join(root, "live", ".terragrunt-cache", "x", "y", "main.tf"), "")
+
+	tests := map[string]Kind{
+		"plain":                      Terraform,
+		"tofu":                       Tofu,
+		"tofufiles":                  Tofu,
+		"live":                       Terragrunt,
+		"live/.terragrunt-cache/x/y": Terragrunt,
+	}
+	for dir, want := range tests {
+		if got := Detect(filepath.Join(root, dir)); got != want {
+			t.Errorf("Detect(%s) = %s, want %s", dir, got, want)
+		}
+	}
+
+	// Without tofu installed, an OpenTofu project falls back to terraform
+	os.Remove(filepath.Join(bin, "tofu"))
+	if got := Detect(filepath.Join(root, "tofu")); got != Terraform {
+		t.Errorf("Detect() without tofu = %s, want terraform", got)
+	}
+}
+
+type fakeDownloader map[string]string
+
+func (f fakeDownloader) Download(ctx context.Context, bucket, key string) ([]byte, error) {
+	content, ok := f[bucket+"/"+key]
+	if !ok {
+		return nil, fmt.Errorf("no such object %s/%s", bucket, key)
+	}
+	return []byte(content), nil
+}
+
+func TestLoad_Locations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	writeFile(t, path, planJSON)
+
+	var schemes []string
+	src := Source{
+		Input: strings.NewReader(planJSON),
+		Remote: func(ctx context.Context, scheme string) (Downloader, error) {
+			schemes = append(schemes, scheme)
+			return fakeDownloader{"bucket/archive/prod/plan.json": planJSON}, nil
+		},
+	}
+
+	for _, location := range []string{"-", "file://" + path, "s3://bucket/archive/prod/plan.json", "gs://bucket/archive/prod/plan.json"} {
+		plan, err := src.Load(location)
+		if err != nil {
+			t.Errorf("Load(%s) error = %v", location, err)
+			continue
+		}
+		if len(plan.ResourceChanges) != 1 {
+			t.Errorf("Load(%s) got %d resource changes", location, len(plan.ResourceChanges))
+		}
+	}
+	if !reflect.DeepEqual(schemes, []string{"s3", "gs"}) {
+		t.Errorf("remote clients = %v", schemes)
+	}
+
+	for _, location := range []string{"s3://bucket/missing", "s3://bucket", "ftp://host/plan", "file://host/plan"} {
+		if _, err := src.Load(location); err == nil {
+			t.Errorf("Load(%s) should fail", location)
+		}
+	}
+	if _, err := (Source{}).Load("gs://bucket/plan"); err == nil {
+		t.Error("Load() of a URL without a remote client should fail")
+	}
+}
+
+func TestLoad_ShowFromStdin(t *testing.T) {
+	// Binary plans from stdin are handed to the show command as a temporary file
+	fakeBinary(t, "terraform", `read -r content < "$3"; echo "$content" > "${0%/*}/content"
+echo '`+planJSON+`'
+`)
+	src := Source{Kind: Terraform, Input: strings.NewReader("binary plan\n")}
+	if _, err := src.Load("-"); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(os.Getenv("PATH"), "content"))
+	if err != nil || string(content) != "binary plan\n" {
+		t.Errorf("show command read %q, %v", content, err)
+	}
+}
+
+func TestLocalPath(t *testing.T) {
+	tests := []struct {
+		location string
+		path     string
+		local    bool
+	}{
+		{"plan.tfplan", "plan.tfplan", true},
+		{"file:///tmp/plan.tfplan", "/tmp/plan.tfplan", true},
+		{"file://localhost/tmp/plan.tfplan", "/tmp/plan.tfplan", true},
+		{"-", "", false},
+		{"s3://bucket/plan", "", false},
+	}
+	for _, tt := range tests {
+		path, local := LocalPath(tt.location)
+		if path != tt.path || local != tt.local {
+			t.Errorf("LocalPath(%q) = %q, %v", tt.location, path, local)
+		}
+	}
+}
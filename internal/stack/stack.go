@@ -0,0 +1,203 @@
+package stack
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bernard-sh/tfs/internal/models"
+	"github.com/bernard-sh/tfs/internal/risk"
+	"github.com/bernard-sh/tfs/internal/source"
+	"github.com/bernard-sh/tfs/internal/summary"
+)
+
+// Stack is one plan of a multi-plan run (a monorepo root, workspace or Terragrunt unit).
+type Stack struct {
+	Label string // Stack path, e.g. "envs/prod/network"
+	Path  string // Plan file
+	Dir   string // Root module directory, where declarations are looked up; "" when unknown
+	Plan  models.TfPlan
+}
+
+// Stats is the per-stack line of the index view.
+type Stats struct {
+	Counts   [models.NumCategories]int `json:"counts"`
+	Total    int                       `json:"total"`
+	HighRisk int                       `json:"high_risk"` // Number of high-risk resources
+	MaxRisk  int                       `json:"max_risk"`  // Highest resource risk score
+}
+
+func (s Stack) Stats() Stats {
+	sum := summary.New(s.Plan)
+	st := Stats{Counts: sum.Counts, Total: sum.Total()}
+	for _, rc := range s.Plan.ResourceChanges {
+		if rc.IsNoOp() {
+			continue
+		}
+		a := risk.Assess(rc)
+		if a.IsHigh() {
+			st.HighRisk++
+		}
+		if a.Score > st.MaxRisk {
+			st.MaxRisk = a.Score
+		}
+	}
+	return st
+}
+
+// DefaultPatterns are the file names picked up when scanning a directory for plans.
+var DefaultPatterns = []string{"*.tfplan", "*.tfplan.json", "tfplan", "tfplan.json", "plan.json"}
+
+// skipDirs are never scanned: provider plugins and VCS metadata.
+// .terragrunt-cache is scanned on purpose since Terragrunt writes plans there.
+var skipDirs = map[string]bool{".terraform": true, ".git": true, "node_modules": true}
+
+// Discover expands the arguments into plan files: files, stdin ("-") and URLs are kept
+// as-is, glob patterns are expanded and directories are scanned recursively for files
+// matching patterns.
+func Discover(args []string, patterns []string) ([]string, error) {
+	if len(patterns) == 0 {
+		patterns = DefaultPatterns
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+	add := func(f string) {
+		if !seen[f] {
+			seen[f] = true
+			files = append(files, f)
+		}
+	}
+
+	for _, arg := range args {
+		if _, local := source.LocalPath(arg); !local || strings.HasPrefix(arg, "file://") {
+			add(arg)
+			continue
+		}
+
+		matches := []string{arg}
+		if strings.ContainsAny(arg, "*?[") {
+			var err error
+			matches, err = filepath.Glob(arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", arg, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no plan matches %q", arg)
+			}
+		}
+
+		for _, m := range matches {
+			info, err := os.Stat(m)
+			if err != nil {
+				return nil, fmt.Errorf("file does not exist: %s", m)
+			}
+			if !info.IsDir() {
+				add(m)
+				continue
+			}
+			found, err := scan(m, patterns)
+			if err != nil {
+				return nil, err
+			}
+			if len(found) == 0 {
+				return nil, fmt.Errorf("no plan found in %s (looking for %s)", m, strings.Join(patterns, ", "))
+			}
+			for _, f := range found {
+				add(f)
+			}
+		}
+	}
+	return files, nil
+}
+
+func scan(dir string, patterns []string) ([]string, error) {
+	var found []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && skipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		for _, p := range patterns {
+			if ok, _ := filepath.Match(p, d.Name()); ok {
+				found = append(found, path)
+				break
+			}
+		}
+		return nil
+	})
+	sort.Strings(found)
+	return found, err
+}
+
+// Labels names each plan by its stack path: the directory of the plan, relative to
+// the directory common to all plans, with Terragrunt cache directories stripped.
+// Plans sharing a directory are told apart by their file name.
+func Labels(paths []string) []string {
+	dirs := make([]string, len(paths))
+	for i, p := range paths {
+		dir := filepath.ToSlash(filepath.Dir(filepath.Clean(labelPath(p))))
+		if idx := strings.Index(dir, ".terragrunt-cache"); idx >= 0 {
+			dir = strings.TrimSuffix(dir[:idx], "/")
+		}
+		dirs[i] = dir
+	}
+
+	base := commonPrefix(dirs)
+	counts := make(map[string]int)
+	labels := make([]string, len(paths))
+	for i, dir := range dirs {
+		label := strings.TrimPrefix(strings.TrimPrefix(dir, base), "/")
+		if label == "" {
+			label = "."
+			if base != "" && base != "." {
+				label = filepath.Base(base)
+			}
+		}
+		labels[i] = label
+		counts[label]++
+	}
+	for i, label := range labels {
+		if counts[label] > 1 {
+			labels[i] = label + "/" + filepath.Base(paths[i])
+		}
+	}
+	return labels
+}
+
+// labelPath is the path a plan location is labelled by: the bucket and key of URLs,
+// and "stdin" for plans read from standard input.
+func labelPath(location string) string {
+	if location == source.StdinLocation {
+		return "stdin/-"
+	}
+	if _, rest, ok := strings.Cut(location, "://"); ok {
+		return rest
+	}
+	return location
+}
+
+// commonPrefix returns the longest directory prefix shared by all dirs.
+func commonPrefix(dirs []string) string {
+	if len(dirs) < 2 {
+		return ""
+	}
+	prefix := strings.Split(dirs[0], "/")
+	for _, d := range dirs[1:] {
+		parts := strings.Split(d, "/")
+		n := 0
+		for n < len(prefix) && n < len(parts) && prefix[n] == parts[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return strings.Join(prefix, "/")
+}
@@ -0,0 +1,71 @@
+package stack
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiscover(t *testing.T) {
+	root := t.TempDir()
+	for _, f := range []string{
+		"envs/prod/tfplan",
+		"envs/dev/tfplan",
+		"envs/dev/.terraform/tfplan",
+		"envs/dev/main.tf",
+		"network/.terragrunt-cache/abc/def/tfplan",
+	} {
+		path := filepath.Join(root, f)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte("{}"), 0644)
+	}
+
+	files, err := Discover([]string{root}, nil)
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	expected := []string{
+		filepath.Join(root, "envs/dev/tfplan"),
+		filepath.Join(root, "envs/prod/tfplan"),
+		filepath.Join(root, "network/.terragrunt-cache/abc/def/tfplan"),
+	}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("Discover() = %v; want %v", files, expected)
+	}
+
+	if got := Labels(files); !reflect.DeepEqual(got, []string{"envs/dev", "envs/prod", "network"}) {
+		t.Errorf("Labels() = %v", got)
+	}
+
+	if _, err := Discover([]string{filepath.Join(root, "envs/*/nope")}, nil); err == nil {
+		t.Errorf("Expected an error for a glob matching nothing")
+	}
+}
+
+func TestLabels_SameDirectory(t *testing.T) {
+	got := Labels([]string{"plans/a.tfplan", "plans/b.tfplan"})
+	expected := []string{"plans/a.tfplan", "plans/b.tfplan"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Labels() = %v; want %v", got, expected)
+	}
+
+	if got := Labels([]string{"tfplan"}); !reflect.DeepEqual(got, []string{"."}) {
+		t.Errorf("Labels() of a single plan = %v", got)
+	}
+}
+
+func TestDiscover_Locations(t *testing.T) {
+	args := []string{"-", "s3://archive/envs/prod/plan.json", "gs://archive/envs/dev/plan.json"}
+	files, err := Discover(args, nil)
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	if !reflect.DeepEqual(files, args) {
+		t.Errorf("Discover() = %v; want the locations as-is", files)
+	}
+
+	if got := Labels(args); !reflect.DeepEqual(got, []string{"stdin", "archive/envs/prod", "archive/envs/dev"}) {
+		t.Errorf("Labels() = %v", got)
+	}
+}
@@ -0,0 +1,87 @@
+package summary
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/bernard-sh/tfs/internal/models"
+	"github.com/bernard-sh/tfs/internal/risk"
+)
+
+// SchemaVersion is bumped on any breaking change to Report. Adding fields is not breaking.
+const SchemaVersion = 1
+
+// Report is the machine-readable summary emitted by `tfs summary --format json`.
+type Report struct {
+	SchemaVersion int              `json:"schema_version"`
+	Counts        Counts           `json:"counts"`
+	Resources     []ResourceReport `json:"resources"`
+}
+
+type Counts struct {
+	Create  int `json:"create"`
+	Destroy int `json:"destroy"`
+	Replace int `json:"replace"`
+	Update  int `json:"update"`
+	Import  int `json:"import"`
+	Total   int `json:"total"`
+}
+
+type ResourceReport struct {
+	Address      string          `json:"address"`
+	Type         string          `json:"type"`
+	Name         string          `json:"name"`
+	Category     string          `json:"category"`
+	Actions      []string        `json:"actions"`
+	Module       string          `json:"module"`
+	Provider     string          `json:"provider"`
+	Risk         risk.Assessment `json:"risk"`
+	ChangedPaths []string        `json:"changed_paths"`
+}
+
+// NewReport builds the report of a plan, in the same order as the TUI tabs.
+func NewReport(plan models.TfPlan) Report {
+	s := New(plan)
+	r := Report{
+		SchemaVersion: SchemaVersion,
+		Counts: Counts{
+			Create:  s.Counts[models.CategoryCreate],
+			Destroy: s.Counts[models.CategoryDestroy],
+			Replace: s.Counts[models.CategoryReplace],
+			Update:  s.Counts[models.CategoryUpdate],
+			Import:  s.Counts[models.CategoryImport],
+			Total:   s.Total(),
+		},
+		Resources: []ResourceReport{},
+	}
+
+	lists := models.Partition(plan)
+	for i := 0; i < models.NumCategories; i++ {
+		cat := models.Category(i)
+		for _, rc := range lists[cat] {
+			paths := rc.Change.ChangedPaths()
+			if paths == nil {
+				paths = []string{}
+			}
+			r.Resources = append(r.Resources, ResourceReport{
+				Address:      rc.Address,
+				Type:         rc.Type,
+				Name:         rc.Name,
+				Category:     cat.String(),
+				Actions:      rc.Change.Actions,
+				Module:       rc.ModuleAddress,
+				Provider:     rc.ProviderName,
+				Risk:         risk.Assess(rc),
+				ChangedPaths: paths,
+			})
+		}
+	}
+	return r
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,69 @@
+package summary
+
+import (
+	"fmt"
+
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+// Exit codes returned by `tfs summary`. 1 is left to generic failures (bad input, etc),
+// reported through log.Fatalf like in every other command.
+const (
+	ExitNoChanges   = 0
+	ExitChanges     = 2 // Only additive changes (create / update / import)
+	ExitDestructive = 3 // At least one destroy or replace over the threshold
+)
+
+// Summary holds the per-category counts of a plan, using the same buckets as the TUI.
+type Summary struct {
+	Counts [models.NumCategories]int
+}
+
+// Thresholds are the number of destroys / replaces tolerated before a plan is
+// reported as destructive. The zero value treats any destroy or replace as destructive.
+type Thresholds struct {
+	Destroy int
+	Replace int
+}
+
+func New(plan models.TfPlan) Summary {
+	var s Summary
+	for cat, rcs := range models.Partition(plan) {
+		s.Counts[cat] = len(rcs)
+	}
+	return s
+}
+
+// Total is the number of resources with a change.
+func (s Summary) Total() int {
+	total := 0
+	for _, c := range s.Counts {
+		total += c
+	}
+	return total
+}
+
+// ExitCode encodes the plan content as a process exit code.
+func (s Summary) ExitCode(t Thresholds) int {
+	if s.Total() == 0 {
+		return ExitNoChanges
+	}
+	if s.Counts[models.CategoryDestroy] > t.Destroy || s.Counts[models.CategoryReplace] > t.Replace {
+		return ExitDestructive
+	}
+	return ExitChanges
+}
+
+// String renders a one-line summary in the style of terraform's "Plan:" line.
+func (s Summary) String() string {
+	if s.Total() == 0 {
+		return "No changes."
+	}
+	return fmt.Sprintf("Plan: %d to create, %d to destroy, %d to replace, %d to update, %d to import.",
+		s.Counts[models.CategoryCreate],
+		s.Counts[models.CategoryDestroy],
+		s.Counts[models.CategoryReplace],
+		s.Counts[models.CategoryUpdate],
+		s.Counts[models.CategoryImport],
+	)
+}
@@ -0,0 +1,103 @@
+package summary
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+func TestExitCode(t *testing.T) {
+	create := models.ResourceChange{Address: "a.create", Change: models.Change{Actions: []string{"create"}}}
+	noop := models.ResourceChange{Address: "a.noop", Change: models.Change{Actions: []string{"no-op"}}}
+	destroy := models.ResourceChange{Address: "a.delete", Change: models.Change{Actions: []string{"delete"}}}
+	replace := models.ResourceChange{Address: "a.replace", Change: models.Change{Actions: []string{"create", "delete"}}}
+
+	tests := []struct {
+		name       string
+		changes    []models.ResourceChange
+		thresholds Thresholds
+		expected   int
+	}{
+		{"Empty", nil, Thresholds{}, ExitNoChanges},
+		{"OnlyNoOp", []models.ResourceChange{noop}, Thresholds{}, ExitNoChanges},
+		{"Additive", []models.ResourceChange{create, noop}, Thresholds{}, ExitChanges},
+		{"Destroy", []models.ResourceChange{create, destroy}, Thresholds{}, ExitDestructive},
+		{"Replace", []models.ResourceChange{replace}, Thresholds{}, ExitDestructive},
+		{"DestroyUnderThreshold", []models.ResourceChange{destroy}, Thresholds{Destroy: 1}, ExitChanges},
+		{"ReplaceOverThreshold", []models.ResourceChange{replace, replace}, Thresholds{Replace: 1}, ExitDestructive},
+	}
+
+	for _, tt := range tests {
+		s := New(models.TfPlan{ResourceChanges: tt.changes})
+		if got := s.ExitCode(tt.thresholds); got != tt.expected {
+			t.Errorf("%s: ExitCode() = %d; want %d", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	s := New(models.TfPlan{ResourceChanges: []models.ResourceChange{
+		{Address: "a.create", Change: models.Change{Actions: []string{"create"}}},
+		{Address: "a.update", Change: models.Change{Actions: []string{"update"}}},
+	}})
+	expected := "Plan: 1 to create, 0 to destroy, 0 to replace, 1 to update, 0 to import."
+	if got := s.String(); got != expected {
+		t.Errorf("String() = %q; want %q", got, expected)
+	}
+
+	if got := New(models.TfPlan{}).String(); got != "No changes." {
+		t.Errorf("String() on empty plan = %q; want %q", got, "No changes.")
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	plan, err := models.ParsePlan(`{
+		"resource_changes": [
+			{
+				"address": "module.db.aws_db_instance.main",
+				"module_address": "module.db",
+				"type": "aws_db_instance",
+				"name": "main",
+				"provider_name": "registry.terraform.io/hashicorp/aws",
+				"change": {
+					"actions": ["delete", "create"],
+					"before": {"engine_version": "14", "tags": {"env": "prod"}, "port": 5432},
+					"after": {"engine_version": "15", "tags": {"env": "prod"}, "port": 5432},
+					"after_unknown": {"arn": true}
+				}
+			},
+			{
+				"address": "null_resource.noop",
+				"type": "null_resource",
+				"name": "noop",
+				"change": {"actions": ["no-op"]}
+			}
+		]
+	}`)
+	if err != nil {
+		t.Fatalf("ParsePlan failed: %v", err)
+	}
+
+	r := NewReport(plan)
+	if r.SchemaVersion != SchemaVersion {
+		t.Errorf("SchemaVersion = %d; want %d", r.SchemaVersion, SchemaVersion)
+	}
+	if r.Counts.Replace != 1 || r.Counts.Total != 1 {
+		t.Errorf("Unexpected counts: %+v", r.Counts)
+	}
+	if len(r.Resources) != 1 {
+		t.Fatalf("Expected 1 resource, got %d", len(r.Resources))
+	}
+
+	res := r.Resources[0]
+	if res.Category != "replace" || res.Module != "module.db" || res.Provider != "registry.terraform.io/hashicorp/aws" {
+		t.Errorf("Unexpected resource report: %+v", res)
+	}
+	if got, want := strings.Join(res.ChangedPaths, ","), "arn,engine_version"; got != want {
+		t.Errorf("ChangedPaths = %q; want %q", got, want)
+	}
+	if !res.Risk.IsHigh() {
+		t.Errorf("Expected replacing a database to be high risk, got %+v", res.Risk)
+	}
+}
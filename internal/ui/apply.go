@@ -0,0 +1,202 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/bernard-sh/tfs/internal/summary"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ApplyFunc applies the reviewed plan, writing the command output to w.
+type ApplyFunc func(w io.Writer) error
+
+// applyLineMsg is one line of apply output.
+type applyLineMsg string
+
+// applyDoneMsg ends the apply, with its error if it failed.
+type applyDoneMsg struct{ err error }
+
+var (
+	warnStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFAF00")).Bold(true)
+	noticeStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFAF00"))
+	successStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#00AF00")).Bold(true)
+	failureStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#D70000")).Bold(true)
+)
+
+// lineWriter sends every complete line written to it on a channel.
+type lineWriter struct {
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	lines chan<- tea.Msg
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf.Write(p)
+	for {
+		line, err := w.buf.ReadString('\n')
+		if err != nil {
+			// Keep the partial line for the next write
+			w.buf.Reset()
+			w.buf.WriteString(line)
+			return len(p), nil
+		}
+		w.lines <- applyLineMsg(strings.TrimRight(line, "\r\n"))
+	}
+}
+
+func (w *lineWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.buf.Len() > 0 {
+		w.lines <- applyLineMsg(w.buf.String())
+		w.buf.Reset()
+	}
+}
+
+// startApply runs the apply in the background, streaming its output as messages.
+func startApply(apply ApplyFunc) (<-chan tea.Msg, tea.Cmd) {
+	msgs := make(chan tea.Msg, 64)
+	go func() {
+		w := &lineWriter{lines: msgs}
+		err := apply(w)
+		w.flush()
+		msgs <- applyDoneMsg{err: err}
+		close(msgs)
+	}()
+	return msgs, waitForApply(msgs)
+}
+
+// waitForApply delivers the next apply message.
+func waitForApply(msgs <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-msgs
+	}
+}
+
+// pendingHighRisk lists the high-risk resources not yet marked as reviewed, which
+// block the apply.
+func (m model) pendingHighRisk() []string {
+	var pending []string
+	for tab := 0; tab < len(m.tabs); tab++ {
+		for _, rc := range m.lists[tab] {
+			if m.highRisk[rc.Key()] && !m.reviewed[rc.Key()] {
+				pending = append(pending, rc.Key())
+			}
+		}
+	}
+	return pending
+}
+
+// requestApply opens the confirmation, or explains what must be reviewed first.
+func (m model) requestApply() model {
+	pending := m.pendingHighRisk()
+	if len(pending) > 0 {
+		m.notice = fmt.Sprintf("Review the %d high-risk resource(s) marked ⚠ before applying ([Space] marks as reviewed)", len(pending))
+		return m
+	}
+	m.notice = ""
+	m.viewMode = "confirm"
+	return m
+}
+
+// updateApply handles keys on the confirmation and apply screens.
+func (m model) updateApply(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.viewMode == "confirm" {
+		switch msg.String() {
+		case "y", "Y":
+			m.viewMode = "apply"
+			m.applying = true
+			m.applyOutput = nil
+			m.viewport.SetContent("")
+			var cmd tea.Cmd
+			m.applyMsgs, cmd = startApply(m.apply)
+			return m, cmd
+		case "ctrl+c":
+			return m, tea.Quit
+		default:
+			m.viewMode = "list"
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "up", "k":
+		m.viewport.LineUp(1)
+	case "down", "j":
+		m.viewport.LineDown(1)
+	case "q", "esc", "ctrl+c":
+		// Never leave while terraform is still running
+		if !m.applying {
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+// handleApplyMsg appends apply output to the pane as it arrives.
+func (m model) handleApplyMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case applyLineMsg:
+		m.applyOutput = append(m.applyOutput, string(msg))
+		m.viewport.SetContent(strings.Join(m.applyOutput, "\n"))
+		m.viewport.GotoBottom()
+		return m, waitForApply(m.applyMsgs)
+	case applyDoneMsg:
+		m.applying = false
+		m.applyErr = msg.err
+	}
+	return m, nil
+}
+
+// confirmView summarises what is about to be applied.
+func (m model) confirmView() string {
+	var s strings.Builder
+	s.WriteString(warnStyle.Render("Apply this plan?") + "\n\n")
+	s.WriteString(summary.New(m.plan).String() + "\n\n")
+
+	var acknowledged []string
+	for tab := 0; tab < len(m.tabs); tab++ {
+		for _, rc := range m.lists[tab] {
+			if m.highRisk[rc.Key()] {
+				acknowledged = append(acknowledged, rc.Key())
+			}
+		}
+	}
+	if len(acknowledged) > 0 {
+		s.WriteString(fmt.Sprintf("High-risk resources acknowledged (%d):\n", len(acknowledged)))
+		for _, addr := range acknowledged {
+			s.WriteString("  ✓ " + addr + "\n")
+		}
+		s.WriteString("\n")
+	}
+	s.WriteString(fmt.Sprintf("Reviewed: %d of %d resources\n", m.reviewedCount(), m.resourceCount()))
+	s.WriteString("\n[y]: Apply  [any other key]: Cancel")
+	return s.String()
+}
+
+// applyView shows the streamed apply output.
+func (m model) applyView() string {
+	var s strings.Builder
+	switch {
+	case m.applying:
+		s.WriteString(warnStyle.Render("Applying...") + "\n\n")
+	case m.applyErr != nil:
+		s.WriteString(failureStyle.Render("Apply failed: "+m.applyErr.Error()) + "\n\n")
+	default:
+		s.WriteString(successStyle.Render("Apply complete") + "\n\n")
+	}
+	s.WriteString(m.viewport.View())
+	if m.applying {
+		s.WriteString("\n[Arrows]: Scroll")
+	} else {
+		s.WriteString("\n[Arrows]: Scroll  [q]: Quit")
+	}
+	return s.String()
+}
@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bernard-sh/tfs/internal/models"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestApply_RequiresHighRiskReview(t *testing.T) {
+	plan := models.TfPlan{ResourceChanges: []models.ResourceChange{
+		{Address: "aws_instance.web", Type: "aws_instance", Name: "web", Change: models.Change{Actions: []string{"create"}}},
+		{Address: "aws_db_instance.main", Type: "aws_db_instance", Name: "main", Change: models.Change{Actions: []string{"delete"}}},
+	}}
+
+	applied := false
+	var m tea.Model = PlanModel(plan, Options{Apply: func(w io.Writer) error {
+		applied = true
+		fmt.Fprint(w, "Applying...\nApply complete! Resources: 1 added, 0 changed, 1 destroyed.")
+		return nil
+	}})
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+
+	var cmd tea.Cmd
+	press := func(key tea.KeyType, runes ...rune) {
+		m, cmd = m.Update(tea.KeyMsg{Type: key, Runes: runes})
+	}
+
+	// The database destroy is high risk and blocks the apply until reviewed
+	press(tea.KeyRunes, 'a')
+	if mode := m.(model).viewMode; mode != "list" {
+		t.Fatalf("Apply with unreviewed high-risk resources opened %q", mode)
+	}
+	if view := m.View(); !strings.Contains(view, "1 high-risk") {
+		t.Errorf("Expected a notice about the high-risk resource:\n%s", view)
+	}
+
+	press(tea.KeyTab) // DESTROY
+	press(tea.KeySpace)
+	if !m.(model).reviewed["aws_db_instance.main"] {
+		t.Fatal("Space did not mark the resource as reviewed")
+	}
+
+	press(tea.KeyRunes, 'a')
+	if mode := m.(model).viewMode; mode != "confirm" {
+		t.Fatalf("Apply after review opened %q, want confirm", mode)
+	}
+	if view := m.View(); !strings.Contains(view, "1 to create, 1 to destroy") || !strings.Contains(view, "✓ aws_db_instance.main") {
+		t.Errorf("Confirmation is missing the summary or acknowledged resources:\n%s", view)
+	}
+
+	press(tea.KeyRunes, 'y')
+	for cmd != nil {
+		m, cmd = m.Update(cmd())
+	}
+	if !applied {
+		t.Fatal("Confirming did not run the apply")
+	}
+	view := m.View()
+	if !strings.Contains(view, "Apply complete") || !strings.Contains(view, "1 destroyed") {
+		t.Errorf("Apply pane is missing the output:\n%s", view)
+	}
+
+	press(tea.KeyRunes, 'q')
+	if cmd == nil {
+		t.Error("q after the apply should quit")
+	}
+}
+
+func TestApply_Disabled(t *testing.T) {
+	plan := models.TfPlan{ResourceChanges: []models.ResourceChange{
+		{Address: "aws_instance.web", Type: "aws_instance", Name: "web", Change: models.Change{Actions: []string{"create"}}},
+	}}
+	var m tea.Model = PlanModel(plan, Options{})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	if mode := m.(model).viewMode; mode != "list" {
+		t.Errorf("Apply without --allow-apply opened %q", mode)
+	}
+	if strings.Contains(m.View(), "[a]: Apply") {
+		t.Error("Apply key is advertised without --allow-apply")
+	}
+}
+
+type memoryReviews map[string]map[string]bool
+
+func (s memoryReviews) Load(plan models.TfPlan) (map[string]bool, error) {
+	reviewed := make(map[string]bool)
+	for addr, ok := range s[plan.Hash()] {
+		reviewed[addr] = ok
+	}
+	return reviewed, nil
+}
+
+func (s memoryReviews) Save(plan models.TfPlan, reviewed map[string]bool) error {
+	saved := make(map[string]bool)
+	for addr, ok := range reviewed {
+		saved[addr] = ok
+	}
+	s[plan.Hash()] = saved
+	return nil
+}
+
+func TestReview_Persists(t *testing.T) {
+	plan := models.TfPlan{ResourceChanges: []models.ResourceChange{
+		{Address: "aws_instance.web", Type: "aws_instance", Name: "web", Change: models.Change{Actions: []string{"create"}}},
+		{Address: "aws_instance.api", Type: "aws_instance", Name: "api", Change: models.Change{Actions: []string{"create"}}},
+	}}
+	store := memoryReviews{}
+
+	var m tea.Model = PlanModel(plan, Options{Reviews: store})
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace})
+	if view := m.View(); !strings.Contains(view, "Reviewed 1/2") || !strings.Contains(view, "CREATE (+ 2) 1/2") {
+		t.Errorf("View is missing the review progress:\n%s", view)
+	}
+
+	// A new session on the same plan resumes the progress
+	m = PlanModel(plan, Options{Reviews: store})
+	if got := m.(model).reviewedCount(); got != 1 {
+		t.Errorf("Reloaded %d reviewed resources, want 1", got)
+	}
+}
@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/bernard-sh/tfs/internal/compare"
+	"github.com/bernard-sh/tfs/internal/diff"
+	"github.com/bernard-sh/tfs/internal/models"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// CompareModel shows the differences between two plans, reusing the plan view
+// with one tab per kind of difference.
+func CompareModel(r compare.Result) tea.Model {
+	m := newModel(models.TfPlan{})
+
+	titles := []string{"ADDED", "REMOVED", "ACTION CHANGED", "VALUES CHANGED"}
+	m.tabs = make([]string, compare.NumKinds)
+	for i, entries := range r.Entries {
+		m.tabs[i] = fmt.Sprintf("%s (%d)", titles[i], len(entries))
+		for _, e := range entries {
+			m.lists[i] = append(m.lists[i], e.Resource())
+		}
+	}
+
+	m.detail = func(tab, index int) string {
+		return renderCompareEntry(r.Entries[tab][index])
+	}
+	return m
+}
+
+func renderCompareEntry(e compare.Entry) string {
+	header := lineStyle(diff.LineHeader).Render(fmt.Sprintf("# %s %s", e.Address, e.Description())) + "\n\n"
+
+	switch e.Kind {
+	case compare.Removed:
+		return header + "Previously planned:\n" + RenderDiff(*e.Old)
+	case compare.ValuesChanged:
+		// Old after-values on the left, new ones on the right; skip the "will be updated" header
+		return header + renderLines(diff.Lines(e.ValuesChange())[1:])
+	default:
+		return header + RenderDiff(*e.New)
+	}
+}
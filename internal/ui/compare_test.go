@@ -0,0 +1,33 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bernard-sh/tfs/internal/compare"
+	"github.com/bernard-sh/tfs/internal/models"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestCompareModel(t *testing.T) {
+	oldPlan := models.TfPlan{ResourceChanges: []models.ResourceChange{
+		{Address: "res.a", Type: "res", Name: "a", Change: models.Change{Actions: []string{"update"}}},
+	}}
+	newPlan := models.TfPlan{ResourceChanges: []models.ResourceChange{
+		{Address: "res.a", Type: "res", Name: "a", Change: models.Change{Actions: []string{"delete", "create"}}},
+	}}
+
+	var m tea.Model = CompareModel(compare.Compare(oldPlan, newPlan))
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	uiModel := m.(model)
+	if uiModel.viewMode != "detail" {
+		t.Fatalf("Enter on the ACTION CHANGED tab did not open the detail view")
+	}
+	if view := uiModel.viewport.View(); !strings.Contains(view, "action changed: update -> delete, create") {
+		t.Errorf("Detail view is missing the action change:\n%s", view)
+	}
+}
@@ -0,0 +1,144 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bernard-sh/tfs/internal/stack"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	stackTitleStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#7AA2F7"))
+
+	dashboardHeaderStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("#A9B1D6"))
+
+	highRiskStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#D70000"))
+)
+
+// dashboard is the index of a multi-plan run: one line per stack, drilling into
+// the single-plan view on enter.
+type dashboard struct {
+	stacks []stack.Stack
+	stats  []stack.Stats
+	cursor int
+	child  *model // Plan being viewed, nil on the index
+	size   tea.WindowSizeMsg
+
+	reviews ReviewStore // Persists each stack's review progress when set
+}
+
+// InitialDashboard builds the multi-plan index view.
+func InitialDashboard(stacks []stack.Stack, reviews ReviewStore) tea.Model {
+	d := dashboard{stacks: stacks, reviews: reviews}
+	for _, st := range stacks {
+		d.stats = append(d.stats, st.Stats())
+	}
+	return d
+}
+
+func (d dashboard) Init() tea.Cmd {
+	return nil
+}
+
+func (d dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		d.size = size
+	}
+
+	// Delegate to the plan view, except Esc on its list which goes back to the index
+	if d.child != nil {
+		if key, ok := msg.(tea.KeyMsg); ok && key.String() == "esc" && d.child.viewMode == "list" {
+			d.child = nil
+			return d, nil
+		}
+		updated, cmd := d.child.Update(msg)
+		child := updated.(model)
+		d.child = &child
+		return d, cmd
+	}
+
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "q", "ctrl+c":
+			return d, tea.Quit
+
+		case "up", "k":
+			if d.cursor > 0 {
+				d.cursor--
+			}
+
+		case "down", "j":
+			if d.cursor < len(d.stacks)-1 {
+				d.cursor++
+			}
+
+		case "enter":
+			if len(d.stacks) > 0 {
+				child := newModel(d.stacks[d.cursor].Plan)
+				child.stack = d.stacks[d.cursor].Label
+				child.setConfigDir(d.stacks[d.cursor].Dir)
+				child.loadReviews(d.reviews)
+				updated, _ := child.Update(d.size)
+				child = updated.(model)
+				d.child = &child
+			}
+		}
+	}
+	return d, nil
+}
+
+func (d dashboard) View() string {
+	if d.child != nil {
+		return d.child.View()
+	}
+
+	var s strings.Builder
+	s.WriteString(stackTitleStyle.Render(fmt.Sprintf("%d stacks", len(d.stacks))) + "\n")
+
+	width := d.size.Width
+	if width == 0 {
+		width = 80 // fallback
+	}
+	s.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("#626262")).Render(strings.Repeat("─", width)) + "\n\n")
+
+	labelWidth := len("STACK")
+	for _, st := range d.stacks {
+		labelWidth = max(labelWidth, len(st.Label))
+	}
+
+	header := fmt.Sprintf("%-*s %6s %6s %6s %6s %6s %6s", labelWidth, "STACK", "+", "-", "-/+", "~", "IMP", "RISK")
+	s.WriteString(dashboardHeaderStyle.Render("  "+header) + "\n")
+
+	for i, st := range d.stacks {
+		stats := d.stats[i]
+		risk := "-"
+		if stats.HighRisk > 0 {
+			risk = fmt.Sprintf("%d!", stats.HighRisk)
+		} else if stats.MaxRisk > 0 {
+			risk = fmt.Sprintf("%d", stats.MaxRisk)
+		}
+		line := fmt.Sprintf("%-*s %6d %6d %6d %6d %6d %6s", labelWidth, st.Label,
+			stats.Counts[0], stats.Counts[1], stats.Counts[2], stats.Counts[3], stats.Counts[4], risk)
+
+		switch {
+		case d.cursor == i:
+			s.WriteString(selectedItemStyle.Render(line) + "\n")
+		case stats.HighRisk > 0:
+			s.WriteString(highRiskStyle.PaddingLeft(2).Render(line) + "\n")
+		default:
+			s.WriteString(itemStyle.Render(line) + "\n")
+		}
+	}
+
+	s.WriteString("\n\nRISK: number of high-risk resources (!) or highest risk score")
+	s.WriteString("\n[Arrows]: Navigate  [Enter]: Open stack  [q]: Quit")
+	return s.String()
+}
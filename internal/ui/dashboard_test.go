@@ -0,0 +1,43 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bernard-sh/tfs/internal/models"
+	"github.com/bernard-sh/tfs/internal/stack"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestDashboard_Navigation(t *testing.T) {
+	stacks := []stack.Stack{
+		{Label: "envs/dev", Plan: models.TfPlan{}},
+		{Label: "envs/prod", Plan: models.TfPlan{ResourceChanges: []models.ResourceChange{
+			{Address: "aws_s3_bucket.data", Type: "aws_s3_bucket", Name: "data", Change: models.Change{Actions: []string{"delete"}}},
+		}}},
+	}
+
+	var m tea.Model = InitialDashboard(stacks, nil)
+	if view := m.View(); !strings.Contains(view, "envs/prod") || !strings.Contains(view, "1!") {
+		t.Errorf("Index view is missing the stacks or their risk:\n%s", view)
+	}
+
+	press := func(key tea.KeyType, runes ...rune) {
+		m, _ = m.Update(tea.KeyMsg{Type: key, Runes: runes})
+	}
+
+	press(tea.KeyRunes, 'j')
+	press(tea.KeyEnter)
+	d := m.(dashboard)
+	if d.child == nil || d.child.stack != "envs/prod" {
+		t.Fatalf("Enter did not open the selected stack")
+	}
+	if len(d.child.lists[1]) != 1 {
+		t.Errorf("Expected the prod stack's destroy to be listed")
+	}
+
+	press(tea.KeyEsc)
+	if m.(dashboard).child != nil {
+		t.Errorf("Esc on the plan list did not go back to the index")
+	}
+}
@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bernard-sh/tfs/internal/config"
+	"github.com/bernard-sh/tfs/internal/models"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestDetail_BlastRadius(t *testing.T) {
+	plan, err := models.ParsePlan(`{
+		"resource_changes": [
+			{"address": "aws_subnet.a", "type": "aws_subnet", "name": "a", "change": {"actions": ["delete", "create"]}},
+			{"address": "aws_instance.web", "type": "aws_instance", "name": "web", "change": {"actions": ["delete", "create"]}}
+		],
+		"configuration": {"root_module": {"resources": [
+			{"address": "aws_instance.web", "mode": "managed", "type": "aws_instance", "name": "web",
+				"expressions": {"subnet_id": {"references": ["aws_subnet.a.id", "aws_subnet.a"]}}}
+		]}}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var m tea.Model = newModel(plan)
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	view := m.(model).viewport.View()
+	for _, want := range []string{
+		"# aws_subnet.a must be replaced",
+		"# Blast radius: 1 dependents, 1 replaced, destroyed or made unknown",
+		"-/+ aws_instance.web (replaced)",
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Detail view missing %q:\n%s", want, view)
+		}
+	}
+}
+
+func TestDetail_Source(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "main.tf"), []byte("resource \"aws_instance\" \"web\" {\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	plan, err := models.ParsePlan(`{
+		"resource_changes": [
+			{"address": "aws_instance.web", "mode": "managed", "type": "aws_instance", "name": "web", "change": {"actions": ["create"]}}
+		],
+		"configuration": {"root_module": {"resources": [
+			{"address": "aws_instance.web", "mode": "managed", "type": "aws_instance", "name": "web",
+				"expressions": {"ami": {"references": ["var.ami"]}, "instance_type": {"constant_value": "t3.micro"}}}
+		]}}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var m tea.Model = PlanModel(plan, Options{ConfigDir: root})
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	view := m.(model).viewport.View()
+	for _, want := range []string{
+		"# Declared in main.tf:1",
+		"ami = var.ami",
+		`instance_type = "t3.micro"`,
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Detail view missing %q:\n%s", want, view)
+		}
+	}
+
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "")
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	if cmd != nil || !strings.Contains(m.View(), "Set $VISUAL or $EDITOR") {
+		t.Errorf("Expected a notice without an editor, got:\n%s", m.View())
+	}
+}
+
+func TestEditorCommand(t *testing.T) {
+	loc := config.Location{File: "modules/db/main.tf", Line: 12}
+	file := filepath.Join("/repo", "modules", "db", "main.tf")
+	tests := []struct {
+		editor   string
+		expected []string
+	}{
+		{"vim", []string{"vim", "+12", file}},
+		{"emacs -nw", []string{"emacs", "-nw", "+12", file}},
+		{"code --wait", []string{"code", "--wait", "-g", file + ":12"}},
+		{"hx", []string{"hx", file + ":12"}},
+	}
+
+	t.Setenv("VISUAL", "")
+	for _, tt := range tests {
+		t.Setenv("EDITOR", tt.editor)
+		cmd, ok := editorCommand("/repo", loc)
+		if !ok {
+			t.Errorf("editorCommand() with EDITOR=%q found no editor", tt.editor)
+		} else if !reflect.DeepEqual(cmd.Args, tt.expected) {
+			t.Errorf("editorCommand() with EDITOR=%q = %v; want %v", tt.editor, cmd.Args, tt.expected)
+		}
+	}
+	t.Setenv("EDITOR", "")
+	if _, ok := editorCommand("/repo", loc); ok {
+		t.Errorf("Expected no command without an editor")
+	}
+}
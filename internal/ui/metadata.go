@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bernard-sh/tfs/internal/diff"
+	"github.com/bernard-sh/tfs/internal/metadata"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var statusStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#565F89"))
+
+// MetadataLines describes how the plan was made, for the plan info panel.
+func MetadataLines(m metadata.Metadata) []diff.Line {
+	lines := []diff.Line{{Kind: diff.LineHeader, Text: "# Plan"}}
+	field := func(name, value string) {
+		if value == "" {
+			value = "unknown"
+		}
+		lines = append(lines, diff.Line{Kind: diff.LinePlain, Text: fmt.Sprintf("  %-17s %s", name, value)})
+	}
+	field("Terraform", m.TerraformVersion)
+	if m.FormatVersion != "" {
+		field("Format version", m.FormatVersion)
+	}
+	field("Workspace", m.Workspace)
+	field("Planned at", m.Timestamp)
+	if warnings := m.Warnings(); len(warnings) > 0 {
+		lines = append(lines, diff.Line{Kind: diff.LineDelete, Text: fmt.Sprintf("  %-17s %s", "Status", strings.Join(warnings, ", "))})
+	} else if m.Ready() {
+		field("Status", "applyable, complete")
+	}
+
+	lines = append(lines, diff.Line{Kind: diff.LinePlain, Text: ""}, diff.Line{Kind: diff.LineHeader, Text: fmt.Sprintf("# Variables (%d)", len(m.Variables))})
+	for _, v := range m.Variables {
+		lines = append(lines, diff.Line{Kind: diff.LinePlain, Text: fmt.Sprintf("  %s = %s", v.Name, v.Value)})
+	}
+
+	lines = append(lines, diff.Line{Kind: diff.LinePlain, Text: ""}, diff.Line{Kind: diff.LineHeader, Text: fmt.Sprintf("# Providers (%d)", len(m.Providers))})
+	for _, p := range m.Providers {
+		text := "  " + p.Source
+		if p.Version != "" {
+			text += " " + p.Version
+		}
+		if p.Constraint != "" {
+			text += " (" + p.Constraint + ")"
+		}
+		lines = append(lines, diff.Line{Kind: diff.LinePlain, Text: text})
+	}
+	return lines
+}
+
+// statusLine is the one-line metadata shown above the tabs, with the warnings of
+// plans unsafe to apply; "" when there is nothing to show.
+func (m model) statusLine() string {
+	var parts []string
+	if summary := m.meta.Summary(); summary != "" {
+		parts = append(parts, statusStyle.Render(summary))
+	}
+	if warnings := m.meta.Warnings(); len(warnings) > 0 {
+		parts = append(parts, warnStyle.Render("⚠ Plan "+strings.Join(warnings, ", ")))
+	}
+	line := strings.Join(parts, "  ")
+	if m.viewport.Width > 0 {
+		line = lipgloss.NewStyle().MaxWidth(m.viewport.Width).Render(line) // One line, as counted in the layout
+	}
+	return line
+}
+
+// showMetadata opens the plan info panel.
+func (m model) showMetadata() model {
+	m.viewMode = "info"
+	m.viewport.SetContent(renderLines(MetadataLines(m.meta)))
+	m.viewport.GotoTop()
+	return m
+}
@@ -1,11 +1,15 @@
 package ui
 
 import (
-	"encoding/json"
 	"fmt"
-	"sort"
 	"strings"
 
+	"github.com/bernard-sh/tfs/internal/config"
+	"github.com/bernard-sh/tfs/internal/diff"
+	"github.com/bernard-sh/tfs/internal/graph"
+	"github.com/bernard-sh/tfs/internal/metadata"
+	"github.com/bernard-sh/tfs/internal/models"
+	"github.com/bernard-sh/tfs/internal/risk"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -13,32 +17,30 @@ import (
 
 // --- 1. TYPES & MODELS ---
 
-type TfPlan struct {
-	ResourceChanges []ResourceChange `json:"resource_changes"`
-}
-
-type ResourceChange struct {
-	Address string `json:"address"`
-	Type    string `json:"type"`
-	Name    string `json:"name"`
-	Change  Change `json:"change"`
-}
-
-type Change struct {
-	Actions      []string               `json:"actions"`
-	Before       map[string]interface{} `json:"before"`
-	After        map[string]interface{} `json:"after"`
-	AfterUnknown map[string]interface{} `json:"after_unknown"`
-}
-
 type model struct {
-	plan      TfPlan
+	plan      models.TfPlan
 	activeTab int // 0: Create, 1: Destroy, 2: Replace, 3: Update, 4: Import
 	cursor    int
-	viewMode  string // "list" or "detail"
-	lists     map[int][]ResourceChange
+	viewMode  string // "list", "detail" or "info"
+	lists     map[int][]models.ResourceChange
 	tabs      []string
 	viewport  viewport.Model
+	stack     string                      // Stack label when opened from the multi-plan dashboard
+	detail    func(tab, index int) string // Detail view content, RenderDiff of the item when nil
+	configDir string                      // Root module directory, where declarations are looked up
+	meta      metadata.Metadata           // How the plan was made, for the status line and info panel
+
+	reviewed map[string]bool        // Addresses marked as reviewed
+	reviews  ReviewStore            // Where reviewed is saved, nil when not persisted
+	highRisk map[string]bool        // Addresses of high-risk resources, to acknowledge before applying
+	blast    map[string]graph.Blast // Blast radius of destroyed and replaced resources
+	notice   string                 // One-off message shown above the footer
+
+	apply       ApplyFunc // Apply action, disabled when nil
+	applying    bool
+	applyOutput []string
+	applyErr    error
+	applyMsgs   <-chan tea.Msg
 }
 
 // --- 2. STYLES ---
@@ -93,295 +95,112 @@ func getTabStyle(index int, active bool) lipgloss.Style {
 
 // --- 3. HELPER FUNCTIONS ---
 
-func getSymbol(action string) string {
-	switch action {
-	case "create":
-		return "+"
-	case "delete":
-		return "-"
-	case "update":
-		return "~"
-	case "replace":
-		return "-/+"
-	default:
-		return ""
+// tabLabels builds the category titles, e.g. "CREATE (+ 3)", from the per-category counts.
+func tabLabels(counts []int) []string {
+	return []string{
+		"CREATE (+ " + fmt.Sprintf("%d", counts[0]) + ")",
+		"DESTROY (- " + fmt.Sprintf("%d", counts[1]) + ")",
+		"REPLACE (-/+ " + fmt.Sprintf("%d", counts[2]) + ")",
+		"UPDATE (~ " + fmt.Sprintf("%d", counts[3]) + ")",
+		"IMPORT (" + fmt.Sprintf("%d", counts[4]) + ")",
 	}
 }
 
-// Helper to format a value for display
-// Moved inside renderDiff in main.go, but here we can make it standalone or method
-func formatValue(v interface{}, indent int) string {
-	if v == nil {
-		return "null"
-	}
-	switch val := v.(type) {
-	case map[string]interface{}:
-		var sb strings.Builder
-		sb.WriteString("{\n")
-		keys := make([]string, 0, len(val))
-		for k := range val {
-			keys = append(keys, k)
-		}
-		sort.Strings(keys)
-		padding := strings.Repeat(" ", indent+2)
-		for _, k := range keys {
-			sb.WriteString(fmt.Sprintf("%s%s = %s\n", padding, k, formatValue(val[k], indent+2)))
-		}
-		sb.WriteString(strings.Repeat(" ", indent) + "}")
-		return sb.String()
-	case []interface{}:
-		if len(val) == 0 {
-			return "[]"
-		}
-		var sb strings.Builder
-		sb.WriteString("[\n")
-		padding := strings.Repeat(" ", indent+2)
-		for _, item := range val {
-			sb.WriteString(fmt.Sprintf("%s%s,\n", padding, formatValue(item, indent+2)))
-		}
-		sb.WriteString(strings.Repeat(" ", indent) + "]")
-		return sb.String()
-	case string:
-		return fmt.Sprintf("%q", val)
-	case json.Number:
-		return val.String()
+var (
+	addStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#00AF00")) // Green
+	delStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#D70000")) // Red
+	modStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#AE00FF")) // Purple (Update)
+	repStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFAF00")) // Orange (Replace)
+)
+
+func lineStyle(kind diff.LineKind) lipgloss.Style {
+	switch kind {
+	case diff.LineHeader:
+		return lipgloss.NewStyle().Bold(true)
+	case diff.LineAdd:
+		return addStyle
+	case diff.LineDelete:
+		return delStyle
+	case diff.LineUpdate:
+		return modStyle
+	case diff.LineReplace:
+		return repStyle
 	default:
-		return fmt.Sprintf("%v", val)
+		return lipgloss.NewStyle()
 	}
 }
 
-// Helper to pretty-print attributes with recursive diff style
-func renderDiff(rc ResourceChange) string {
-	var s strings.Builder
-
-	// Styles
-	addStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#00AF00")) // Green
-	delStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#D70000")) // Red
-	modStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#AE00FF")) // Purple (Update)
-	repStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFAF00")) // Orange (Replace)
-
-	// Recursive diff function
-	var stringifyDiff func(key string, valBefore, valAfter interface{}, unknown interface{}, indent int, modStyle lipgloss.Style) string
-	stringifyDiff = func(key string, valBefore, valAfter interface{}, unknown interface{}, indent int, modStyle lipgloss.Style) string {
-		var sb strings.Builder
-		padding := strings.Repeat(" ", indent)
-
-		// Check if "known after apply"
-		isUnknown := false
-		if b, ok := unknown.(bool); ok && b {
-			isUnknown = true
-		}
-
-		// 1. ADDITION (+ key = value)
-		if valBefore == nil && (valAfter != nil || isUnknown) {
-			valStr := "(known after apply)"
-			if !isUnknown {
-				valStr = formatValue(valAfter, indent)
-			}
-
-			// If formatValue is multi-line, it returns uncolored string. We wrap the preamble.
-			// IMPORTANT: Do NOT include \n in the Render call to avoid staircase effect
-			rawLine := fmt.Sprintf("%s+ %s = %s", padding, key, valStr)
-			return addStyle.Render(rawLine) + "\n"
-		}
-
-		// 2. DELETION (- key = value)
-		if valBefore != nil && valAfter == nil && !isUnknown {
-			valStr := formatValue(valBefore, indent)
-			rawLine := fmt.Sprintf("%s- %s = %s", padding, key, valStr)
-			return delStyle.Render(rawLine) + "\n"
-		}
-
-		// 3. MODIFICATION or UNCHANGED
-		// Handle Maps recursively
-		mapBefore, isMapBefore := valBefore.(map[string]interface{})
-		mapAfter, isMapAfter := valAfter.(map[string]interface{})
-
-		if isMapBefore && isMapAfter {
-			// Header: ~ key = {
-			headerRaw := fmt.Sprintf("%s~ %s = {", padding, key)
-			sb.WriteString(modStyle.Render(headerRaw) + "\n")
-
-			// Union of keys
-			seen := make(map[string]bool)
-			for k := range mapBefore {
-				seen[k] = true
-			}
-			for k := range mapAfter {
-				seen[k] = true
-			}
-
-			allKeys := make([]string, 0, len(seen))
-			for k := range seen {
-				allKeys = append(allKeys, k)
-			}
-			sort.Strings(allKeys)
-
-			for _, k := range allKeys {
-				var vB, vA interface{}
-				if v, ok := mapBefore[k]; ok {
-					vB = v
-				}
-				if v, ok := mapAfter[k]; ok {
-					vA = v
-				}
-
-				// Recurse (inner lines will be colored themselves)
-				sb.WriteString(stringifyDiff(k, vB, vA, nil, indent+4, modStyle))
-			}
-
-			// Footer: }
-			footerRaw := fmt.Sprintf("%s}", padding)
-			sb.WriteString(modStyle.Render(footerRaw) + "\n")
-
-			return sb.String()
-		}
-
-		// Scalar Update
-		sBefore := formatValue(valBefore, indent)
-		sAfter := "(known after apply)"
-		if !isUnknown {
-			sAfter = formatValue(valAfter, indent)
-		}
-
-		if sBefore != sAfter {
-			rawLine := fmt.Sprintf("%s~ %s = %s -> %s", padding, key, sBefore, sAfter)
-			return modStyle.Render(rawLine) + "\n"
-		} else {
-			return ""
-		}
-	}
-
-	// Main execution based on Action - Check for replace first
-	isReplace := false
-	action := rc.Change.Actions[0]
-	if len(rc.Change.Actions) > 1 && rc.Change.Actions[0] == "delete" && rc.Change.Actions[1] == "create" {
-		isReplace = true
-		action = "replace"
-	}
-
-	// Styles for resource block header
-	// e.g. # type.name will be created
-	headerLine := fmt.Sprintf("# %s.%s will be %sed", rc.Type, rc.Name, action)
-	if action == "update" {
-		headerLine = fmt.Sprintf("# %s.%s will be updated in-place", rc.Type, rc.Name)
-	} else if isReplace {
-		headerLine = fmt.Sprintf("# %s.%s must be replaced", rc.Type, rc.Name)
-	}
-	s.WriteString(lipgloss.NewStyle().Bold(true).Render(headerLine) + "\n")
-
-	// e.g. + resource "type" "name" {
-	// User snippet shows 2 spaces indent for resource line.
-
-	symbol := getSymbol(action)
-
-	// Open Resource Block
-	// "  + resource"
-	resourceLine := fmt.Sprintf("  %s resource %q %q {", symbol, rc.Type, rc.Name)
-
-	// Apply color to the resource opening line
-	// Also determine modStyle to pass down
-	var parentStyle lipgloss.Style
-
-	if action == "create" {
-		s.WriteString(addStyle.Render(resourceLine) + "\n")
-		parentStyle = addStyle
-	} else if action == "delete" {
-		s.WriteString(delStyle.Render(resourceLine) + "\n")
-		parentStyle = delStyle
-	} else if action == "update" {
-		s.WriteString(modStyle.Render(resourceLine) + "\n")
-		parentStyle = modStyle
-	} else if isReplace {
-		s.WriteString(repStyle.Render(resourceLine) + "\n")
-		parentStyle = repStyle
-	} else {
-		s.WriteString(resourceLine + "\n")
-		parentStyle = lipgloss.NewStyle() // No color
-	}
-
-	// Iterate keys
-	// Collect top level keys
-	seen := make(map[string]bool)
-	if rc.Change.Before != nil {
-		for k := range rc.Change.Before {
-			seen[k] = true
-		}
-	}
-	if rc.Change.After != nil {
-		for k := range rc.Change.After {
-			seen[k] = true
-		}
-	}
-	for k := range rc.Change.AfterUnknown {
-		seen[k] = true
-	}
+// RenderDiff pretty-prints a resource change with the TUI colours.
+func RenderDiff(rc models.ResourceChange) string {
+	return renderLines(diff.Lines(rc))
+}
 
-	allKeys := make([]string, 0, len(seen))
-	for k := range seen {
-		if k == "id" {
-			continue
-		}
-		allKeys = append(allKeys, k)
+func renderLines(lines []diff.Line) string {
+	var s strings.Builder
+	for _, line := range lines {
+		// IMPORTANT: Do NOT include \n in the Render call to avoid staircase effect
+		s.WriteString(lineStyle(line.Kind).Render(line.Text) + "\n")
 	}
-	sort.Strings(allKeys)
-
-	for _, k := range allKeys {
-		var vB, vA, vU interface{}
-		if rc.Change.Before != nil {
-			vB = rc.Change.Before[k]
-		}
-		if rc.Change.After != nil {
-			vA = rc.Change.After[k]
-		}
-		if rc.Change.AfterUnknown != nil {
-			vU = rc.Change.AfterUnknown[k]
-		}
+	return s.String()
+}
 
-		s.WriteString(stringifyDiff(k, vB, vA, vU, 2, parentStyle))
+// marker flags reviewed resources, and high-risk ones still to acknowledge when the
+// apply action is enabled.
+func (m model) marker(address string) string {
+	switch {
+	case m.reviewed[address]:
+		return "✓ "
+	case m.apply != nil && m.highRisk[address]:
+		return "⚠ "
+	default:
+		return "  "
 	}
-
-	s.WriteString("}\n")
-
-	return s.String()
 }
 
 // --- 4. MODEL INITIALIZATION ---
 
 func InitialModel(jsonContent string) (tea.Model, error) {
-	var plan TfPlan
-	// Use decoder to parse numbers as strings/json.Number to preserve formatting
-	dec := json.NewDecoder(strings.NewReader(jsonContent))
-	dec.UseNumber()
-	err := dec.Decode(&plan)
+	plan, err := models.ParsePlan(jsonContent)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode plan JSON: %w", err)
+		return nil, err
 	}
+	return newModel(plan), nil
+}
+
+// Options configure the single-plan view.
+type Options struct {
+	Apply   ApplyFunc   // Enables the apply action when set
+	Reviews ReviewStore // Persists review progress when set
+	// ConfigDir is the root module directory of the plan. When set, the detail view
+	// shows the file declaring the resource and [e] opens it in $EDITOR.
+	ConfigDir string
+}
 
+// PlanModel builds the single-plan view of an already parsed plan.
+func PlanModel(plan models.TfPlan, opts Options) tea.Model {
+	m := newModel(plan)
+	m.apply = opts.Apply
+	m.setConfigDir(opts.ConfigDir)
+	m.loadReviews(opts.Reviews)
+	return m
+}
+
+// newModel builds the single-plan view of an already parsed plan.
+func newModel(plan models.TfPlan) model {
 	// Partition resources into buckets
-	lists := make(map[int][]ResourceChange)
+	lists := make(map[int][]models.ResourceChange)
 	actionCounter := []int{0, 0, 0, 0, 0} // CREATE, DESTROY, REPLACE, UPDATE, IMPORT (Fixed order)
 
-	for _, rc := range plan.ResourceChanges {
-		action := rc.Change.Actions[0]
-
-		// Simple mapping logic
-		var tabIndex int
-
-		// Check for Replace first (delete, create)
-		if len(rc.Change.Actions) > 1 && rc.Change.Actions[0] == "delete" && rc.Change.Actions[1] == "create" {
-			tabIndex = 2 // Replace
-		} else if action == "create" {
-			tabIndex = 0
-		} else if action == "delete" {
-			tabIndex = 1
-		} else if action == "update" {
-			tabIndex = 3
-		} else {
-			tabIndex = 4 // Import or other
+	highRisk := make(map[string]bool)
+
+	for cat, rcs := range models.Partition(plan) {
+		lists[int(cat)] = rcs
+		actionCounter[cat] = len(rcs)
+		for _, rc := range rcs {
+			if risk.Assess(rc).IsHigh() {
+				highRisk[rc.Key()] = true
+			}
 		}
-		lists[tabIndex] = append(lists[tabIndex], rc)
-		actionCounter[tabIndex] = actionCounter[tabIndex] + 1
 	}
 
 	return model{
@@ -390,15 +209,20 @@ func InitialModel(jsonContent string) (tea.Model, error) {
 		cursor:    0,
 		viewMode:  "list",
 		lists:     lists,
-		tabs: []string{
-			"CREATE (+ " + fmt.Sprintf("%d", actionCounter[0]) + ")",
-			"DESTROY (- " + fmt.Sprintf("%d", actionCounter[1]) + ")",
-			"REPLACE (-/+ " + fmt.Sprintf("%d", actionCounter[2]) + ")",
-			"UPDATE (~ " + fmt.Sprintf("%d", actionCounter[3]) + ")",
-			"IMPORT (" + fmt.Sprintf("%d", actionCounter[4]) + ")",
-		},
+		tabs:      tabLabels(actionCounter),
 		viewport: viewport.New(0, 0), // Initial size, will be updated on resize
-	}, nil
+		reviewed:  make(map[string]bool),
+		highRisk:  highRisk,
+		blast:     graph.BlastRadius(plan),
+		meta:      metadata.New(plan, ""),
+	}
+}
+
+// setConfigDir sets the root module directory of the plan, where declarations, the
+// workspace and the dependency lock file are looked up.
+func (m *model) setConfigDir(dir string) {
+	m.configDir = dir
+	m.meta = metadata.New(m.plan, dir)
 }
 
 // --- 5. TEA BOILERPLATE ---
@@ -414,11 +238,45 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 
+	case applyLineMsg, applyDoneMsg:
+		return m.handleApplyMsg(msg)
+
+	case editorDoneMsg:
+		if msg.err != nil {
+			m.notice = fmt.Sprintf("Editor failed: %v", msg.err)
+		}
+		return m, nil
+
 	case tea.KeyMsg:
+		if m.viewMode == "confirm" || m.viewMode == "apply" {
+			return m.updateApply(msg)
+		}
+		m.notice = ""
+
 		switch msg.String() {
 		case "q", "ctrl+c":
 			return m, tea.Quit
 
+		case " ":
+			if list := m.lists[m.activeTab]; len(list) > 0 {
+				m = m.toggleReviewed(list[m.cursor].Key())
+			}
+
+		case "a":
+			if m.apply != nil {
+				return m.requestApply(), nil
+			}
+
+		case "e":
+			if m.viewMode != "info" {
+				return m.editDeclaration()
+			}
+
+		case "i":
+			if m.detail == nil {
+				return m.showMetadata(), nil
+			}
+
 		case "tab", "right", "l":
 			// Cycle tabs
 			m.activeTab++
@@ -462,13 +320,24 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.viewMode = "detail"
 
 				// Set viewport content
-				selectedRes := m.lists[m.activeTab][m.cursor]
-				// renderDiff now includes headers and detailed body
-				m.viewport.SetContent(renderDiff(selectedRes))
+				if m.detail != nil {
+					m.viewport.SetContent(m.detail(m.activeTab, m.cursor))
+				} else {
+					selectedRes := m.lists[m.activeTab][m.cursor]
+					// RenderDiff now includes headers and detailed body
+					content := RenderDiff(selectedRes)
+					if b, ok := m.blast[selectedRes.Address]; ok && selectedRes.Deposed == "" {
+						content += "\n" + renderLines(b.Lines())
+					}
+					if src, ok := config.Describe(m.plan.Configuration, m.configDir, selectedRes); ok {
+						content += "\n" + renderLines(src.Lines())
+					}
+					m.viewport.SetContent(content)
+				}
 			}
 
 		case "esc":
-			if m.viewMode == "detail" {
+			if m.viewMode == "detail" || m.viewMode == "info" {
 				m.viewMode = "list"
 			}
 		}
@@ -477,6 +346,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Handle resizing
 		headerHeight := 3 // Header + Tabs
 		footerHeight := 2 // Help text
+		if m.stack != "" {
+			headerHeight++ // Stack title
+		}
+		if m.statusLine() != "" {
+			headerHeight++
+		}
 
 		m.viewport.Width = msg.Width
 		m.viewport.Height = msg.Height - headerHeight - footerHeight
@@ -490,11 +365,25 @@ func (m model) View() string {
 
 	// --- A. Render Header + Tabs ---
 
+	if m.viewMode == "confirm" {
+		return m.confirmView()
+	}
+	if m.viewMode == "apply" {
+		return m.applyView()
+	}
+
+	if m.stack != "" {
+		s.WriteString(stackTitleStyle.Render("Stack: "+m.stack) + "\n")
+	}
+	if status := m.statusLine(); status != "" {
+		s.WriteString(status + "\n")
+	}
+
 	// Render Tabs
 	var tabs []string
 	for i, t := range m.tabs {
 		style := getTabStyle(i, i == m.activeTab)
-		tabs = append(tabs, style.Render(t))
+		tabs = append(tabs, style.Render(t+m.tabProgress(i)))
 	}
 	row := lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
 	s.WriteString(row + "\n")
@@ -523,20 +412,45 @@ func (m model) View() string {
 			s.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("#626262")).Render("  No changes in this category."))
 		} else {
 			for i, item := range currentList {
+				label := m.marker(item.Key()) + item.Key()
 				// Render cursor logic
 				if m.cursor == i {
-					s.WriteString(selectedItemStyle.Render(item.Address) + "\n")
+					s.WriteString(selectedItemStyle.Render(label) + "\n")
 				} else {
-					s.WriteString(itemStyle.Render(item.Address) + "\n")
+					s.WriteString(itemStyle.Render(label) + "\n")
 				}
 			}
 		}
-		s.WriteString("\n\n[Arrows]: Navigate  [Enter]: Details  [Tab]: Next Category  [q]: Quit")
+		if m.notice != "" {
+			s.WriteString("\n" + noticeStyle.Render(m.notice))
+		}
+		s.WriteString(fmt.Sprintf("\n\nReviewed %d/%d", m.reviewedCount(), m.resourceCount()))
+		help := "[Arrows]: Navigate  [Enter]: Details  [Space]: Reviewed  [Tab]: Next Category"
+		if m.apply != nil {
+			help += "  [a]: Apply"
+		}
+		if m.configDir != "" {
+			help += "  [e]: Edit"
+		}
+		if m.detail == nil {
+			help += "  [i]: Plan info"
+		}
+		if m.stack != "" {
+			help += "  [Esc]: All stacks"
+		}
+		s.WriteString("\n\n" + help + "  [q]: Quit")
 
 	} else {
 		// Render Detail View
 		s.WriteString(m.viewport.View())
-		s.WriteString("\n(Press Esc to go back)")
+		footer := "\n(Press Esc to go back)"
+		if m.configDir != "" && m.detail == nil && m.viewMode == "detail" {
+			footer = "\n(Press Esc to go back, e to edit the declaration)"
+		}
+		s.WriteString(footer)
+		if m.notice != "" {
+			s.WriteString("  " + noticeStyle.Render(m.notice))
+		}
 	}
 
 	return s.String()
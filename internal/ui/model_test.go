@@ -1,48 +1,11 @@
 package ui
 
 import (
+	"strings"
 	"testing"
-)
-
-func TestGetSymbol(t *testing.T) {
-	tests := []struct {
-		action   string
-		expected string
-	}{
-		{"create", "+"},
-		{"delete", "-"},
-		{"update", "~"},
-		{"replace", "-/+"},
-		{"unknown", ""},
-	}
-
-	for _, tt := range tests {
-		got := getSymbol(tt.action)
-		if got != tt.expected {
-			t.Errorf("getSymbol(%q) = %q; want %q", tt.action, got, tt.expected)
-		}
-	}
-}
 
-func TestFormatValue(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    interface{}
-		expected string // Partial match check usually easier for complex strings
-	}{
-		{"String", "hello", "\"hello\""},
-		{"Int", 123, "123"},
-		{"Nil", nil, "null"},
-		{"List", []interface{}{"a", "b"}, "[\n  \"a\",\n  \"b\",\n]"},
-	}
-
-	for _, tt := range tests {
-		got := formatValue(tt.input, 0)
-		if got != tt.expected {
-			t.Errorf("formatValue(%s) = %q; want %q", tt.name, got, tt.expected)
-		}
-	}
-}
+	tea "github.com/charmbracelet/bubbletea"
+)
 
 func TestInitialModel_ValidJSON(t *testing.T) {
 	jsonContent := `{
@@ -99,3 +62,37 @@ func TestInitialModel_InvalidJSON(t *testing.T) {
 		t.Error("Expected error for invalid JSON, got nil")
 	}
 }
+
+func TestMetadata(t *testing.T) {
+	m, err := InitialModel(`{
+		"terraform_version": "1.9.5",
+		"errored": true,
+		"variables": {"env": {"value": "prod"}},
+		"resource_changes": [
+			{"address": "aws_instance.web", "type": "aws_instance", "name": "web", "provider_name": "registry.terraform.io/hashicorp/aws", "change": {"actions": ["create"]}}
+		],
+		"configuration": {"root_module": {"variables": {"env": {}}}}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+
+	view := m.View()
+	if !strings.Contains(view, "Terraform 1.9.5 · 1 variable") || !strings.Contains(view, "⚠ Plan errored") {
+		t.Errorf("Status line missing from the list view:\n%s", view)
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("i")})
+	panel := m.(model).viewport.View()
+	for _, want := range []string{"# Plan", "Status            errored", "# Variables (1)", `env = "prod"`, "registry.terraform.io/hashicorp/aws"} {
+		if !strings.Contains(panel, want) {
+			t.Errorf("Plan info panel missing %q:\n%s", want, panel)
+		}
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.(model).viewMode != "list" {
+		t.Errorf("Esc left the plan info panel in %q mode", m.(model).viewMode)
+	}
+}
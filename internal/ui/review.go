@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+// ReviewStore persists the addresses marked as reviewed in a plan.
+type ReviewStore interface {
+	Load(plan models.TfPlan) (map[string]bool, error)
+	Save(plan models.TfPlan, reviewed map[string]bool) error
+}
+
+// loadReviews restores the saved review progress, and saves it from now on.
+func (m *model) loadReviews(store ReviewStore) {
+	if store == nil {
+		return
+	}
+	m.reviews = store
+	reviewed, err := store.Load(m.plan)
+	if err != nil {
+		m.notice = fmt.Sprintf("Failed to load review progress: %v", err)
+		return
+	}
+	if reviewed != nil {
+		m.reviewed = reviewed
+	}
+}
+
+// toggleReviewed flips the reviewed mark of a resource and saves the progress.
+func (m model) toggleReviewed(address string) model {
+	m.reviewed[address] = !m.reviewed[address]
+	if m.reviews != nil {
+		if err := m.reviews.Save(m.plan, m.reviewed); err != nil {
+			m.notice = fmt.Sprintf("Failed to save review progress: %v", err)
+		}
+	}
+	return m
+}
+
+// tabProgress is the review counter appended to a tab title, e.g. " 2/5".
+func (m model) tabProgress(tab int) string {
+	list := m.lists[tab]
+	if len(list) == 0 {
+		return ""
+	}
+	n := 0
+	for _, rc := range list {
+		if m.reviewed[rc.Key()] {
+			n++
+		}
+	}
+	return fmt.Sprintf(" %d/%d", n, len(list))
+}
+
+func (m model) reviewedCount() int {
+	n := 0
+	for tab := 0; tab < len(m.tabs); tab++ {
+		for _, rc := range m.lists[tab] {
+			if m.reviewed[rc.Key()] {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func (m model) resourceCount() int {
+	n := 0
+	for tab := 0; tab < len(m.tabs); tab++ {
+		n += len(m.lists[tab])
+	}
+	return n
+}
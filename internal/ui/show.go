@@ -0,0 +1,35 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/bernard-sh/tfs/internal/models"
+	"github.com/bernard-sh/tfs/internal/summary"
+)
+
+// RenderPlan renders the whole categorised plan as text for non-interactive output:
+// the counts, then every resource diff grouped by category, as in the TUI tabs.
+// Colours follow the lipgloss colour profile, so they vanish when it is set to Ascii.
+func RenderPlan(plan models.TfPlan) string {
+	var s strings.Builder
+
+	sum := summary.New(plan)
+	s.WriteString(sum.String() + "\n")
+
+	lists := models.Partition(plan)
+	labels := tabLabels(sum.Counts[:])
+	for i, label := range labels {
+		rcs := lists[models.Category(i)]
+		if len(rcs) == 0 {
+			continue
+		}
+
+		s.WriteString("\n" + getTabStyle(i, false).Bold(true).Render(label) + "\n")
+		s.WriteString(strings.Repeat("─", len([]rune(label))+2) + "\n\n") // Tab style pads by 1 on each side
+		for _, rc := range rcs {
+			s.WriteString(RenderDiff(rc) + "\n")
+		}
+	}
+
+	return s.String()
+}
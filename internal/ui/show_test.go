@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bernard-sh/tfs/internal/models"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+func TestRenderPlan(t *testing.T) {
+	profile := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.Ascii)
+	t.Cleanup(func() { lipgloss.SetColorProfile(profile) })
+
+	change := func(actions ...string) models.ResourceChange {
+		return models.ResourceChange{Address: "res.a", Type: "res", Name: "a", Change: models.Change{Actions: actions}}
+	}
+	imported := change("no-op")
+	imported.Change.Importing = map[string]interface{}{"id": "a-1"}
+
+	tests := []struct {
+		name     string
+		rcs      []models.ResourceChange
+		expected string
+	}{
+		{"No changes", []models.ResourceChange{change("no-op")}, "No changes.\n"},
+		{"Create", []models.ResourceChange{change("create")},
+			"Plan: 1 to create, 0 to destroy, 0 to replace, 0 to update, 0 to import.\n" +
+				"\n CREATE (+ 1) \n──────────────\n\n" +
+				"# res.a will be created\n  + resource \"res\" \"a\" {\n}\n\n"},
+		{"Destroy", []models.ResourceChange{change("delete")},
+			"Plan: 0 to create, 1 to destroy, 0 to replace, 0 to update, 0 to import.\n" +
+				"\n DESTROY (- 1) \n───────────────\n\n" +
+				"# res.a will be destroyed\n  - resource \"res\" \"a\" {\n}\n\n"},
+		{"Replace", []models.ResourceChange{change("delete", "create")},
+			"Plan: 0 to create, 0 to destroy, 1 to replace, 0 to update, 0 to import.\n" +
+				"\n REPLACE (-/+ 1) \n─────────────────\n\n" +
+				"# res.a must be replaced\n  -/+ resource \"res\" \"a\" {\n}\n\n"},
+		{"Update", []models.ResourceChange{change("update")},
+			"Plan: 0 to create, 0 to destroy, 0 to replace, 1 to update, 0 to import.\n" +
+				"\n UPDATE (~ 1) \n──────────────\n\n" +
+				"# res.a will be updated in-place\n  ~ resource \"res\" \"a\" {\n}\n\n"},
+		{"Import", []models.ResourceChange{imported},
+			"Plan: 0 to create, 0 to destroy, 0 to replace, 0 to update, 1 to import.\n" +
+				"\n IMPORT (1) \n────────────\n\n" +
+				"# res.a will be imported\n   resource \"res\" \"a\" {\n}\n\n"},
+	}
+
+	for _, tt := range tests {
+		got := RenderPlan(models.TfPlan{ResourceChanges: tt.rcs})
+		if got != tt.expected {
+			t.Errorf("%s: RenderPlan() =\n%q\nwant\n%q", tt.name, got, tt.expected)
+		}
+		if strings.Contains(got, "\x1b[") {
+			t.Errorf("%s: RenderPlan() kept ANSI escapes under the Ascii profile: %q", tt.name, got)
+		}
+	}
+
+	// Categories follow the TUI tab order, whatever the order of the plan
+	got := RenderPlan(models.TfPlan{ResourceChanges: []models.ResourceChange{change("update"), change("create")}})
+	if strings.Index(got, "CREATE (+ 1)") > strings.Index(got, "UPDATE (~ 1)") {
+		t.Errorf("RenderPlan() should list creates before updates:\n%s", got)
+	}
+}
@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/bernard-sh/tfs/internal/config"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// editorDoneMsg ends the editor opened on a declaration, with its error if it failed.
+type editorDoneMsg struct{ err error }
+
+// editDeclaration opens the declaration of the selected resource in $VISUAL or
+// $EDITOR, suspending the TUI meanwhile.
+func (m model) editDeclaration() (model, tea.Cmd) {
+	list := m.lists[m.activeTab]
+	if len(list) == 0 || m.detail != nil {
+		return m, nil
+	}
+	if m.configDir == "" {
+		m.notice = "The configuration directory of this plan is unknown"
+		return m, nil
+	}
+	loc, ok := config.FindResource(m.plan.Configuration, m.configDir, list[m.cursor])
+	if !ok {
+		m.notice = "No declaration of " + list[m.cursor].Address + " found under " + m.configDir
+		return m, nil
+	}
+	cmd, ok := editorCommand(m.configDir, loc)
+	if !ok {
+		m.notice = "Set $VISUAL or $EDITOR to open declarations"
+		return m, nil
+	}
+	return m, tea.ExecProcess(cmd, func(err error) tea.Msg { return editorDoneMsg{err: err} })
+}
+
+// editorCommand builds the command opening loc, found under root, at its line. It
+// returns false when no editor is set.
+func editorCommand(root string, loc config.Location) (*exec.Cmd, bool) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	args := strings.Fields(editor)
+	if len(args) == 0 {
+		return nil, false
+	}
+
+	file := filepath.FromSlash(loc.File)
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(root, file)
+	}
+	switch strings.TrimSuffix(filepath.Base(args[0]), ".exe") {
+	case "code", "code-insiders", "codium":
+		args = append(args, "-g", fmt.Sprintf("%s:%d", file, loc.Line))
+	case "subl", "zed", "hx":
+		args = append(args, fmt.Sprintf("%s:%d", file, loc.Line))
+	default:
+		// vi, Emacs, nano and most terminal editors
+		args = append(args, fmt.Sprintf("+%d", loc.Line), file)
+	}
+	return exec.Command(args[0], args[1:]...), true
+}
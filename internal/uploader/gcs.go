@@ -3,7 +3,6 @@ package uploader
 import (
 	"context"
 	"fmt"
-	"os"
 	"time"
 	"io"
 
@@ -22,19 +21,14 @@ func NewGCSUploader(ctx context.Context) (*GCSUploader, error) {
 	return &GCSUploader{Client: client}, nil
 }
 
-func (u *GCSUploader) UploadAndSign(ctx context.Context, bucket, object, filePath string, expiration time.Duration) (string, error) {
-	f, err := os.Open(filePath)
-	if err != nil {
-		return "", fmt.Errorf("failed to open file: %w", err)
-	}
-	defer f.Close()
-
+// UploadAndSign stores body as object and returns a signed URL to read it.
+func (u *GCSUploader) UploadAndSign(ctx context.Context, bucket, object string, body io.Reader, contentType string, expiration time.Duration) (string, error) {
 	bkt := u.Client.Bucket(bucket)
 	obj := bkt.Object(object)
 	
 	wc := obj.NewWriter(ctx)
-	wc.ContentType = "text/html"
-	if _, err = io.Copy(wc, f); err != nil {
+	wc.ContentType = contentType
+	if _, err := io.Copy(wc, body); err != nil {
 		wc.Close()
 		return "", fmt.Errorf("failed to write to gcs: %w", err)
 	}
@@ -60,3 +54,18 @@ func (u *GCSUploader) UploadAndSign(ctx context.Context, bucket, object, filePat
 
 	return url, nil
 }
+
+// Download returns the content of an object, e.g. an archived plan.
+func (u *GCSUploader) Download(ctx context.Context, bucket, object string) ([]byte, error) {
+	r, err := u.Client.Bucket(bucket).Object(object).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download from gcs: %w", err)
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gcs object: %w", err)
+	}
+	return data, nil
+}
@@ -3,7 +3,7 @@ package uploader
 import (
 	"context"
 	"fmt"
-	"os"
+	"io"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -32,19 +32,14 @@ func NewS3Uploader(ctx context.Context, region string) (*S3Uploader, error) {
 	}, nil
 }
 
-func (u *S3Uploader) UploadAndPresign(ctx context.Context, bucket, key, filePath string, expiration time.Duration) (string, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return "", fmt.Errorf("failed to open file: %w", err)
-	}
-	defer file.Close()
-
+// UploadAndPresign stores body under key and returns a presigned URL to read it.
+func (u *S3Uploader) UploadAndPresign(ctx context.Context, bucket, key string, body io.Reader, contentType string, expiration time.Duration) (string, error) {
 	// Upload
-	_, err = u.Client.PutObject(ctx, &s3.PutObjectInput{
+	_, err := u.Client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
-		Body:   file,
-		ContentType: aws.String("text/html"),
+		Body:   body,
+		ContentType: aws.String(contentType),
 	})
 	if err != nil {
 		return "", fmt.Errorf("failed to upload to s3: %w", err)
@@ -61,3 +56,21 @@ func (u *S3Uploader) UploadAndPresign(ctx context.Context, bucket, key, filePath
 
 	return req.URL, nil
 }
+
+// Download returns the content of an object, e.g. an archived plan.
+func (u *S3Uploader) Download(ctx context.Context, bucket, key string) ([]byte, error) {
+	out, err := u.Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download from s3: %w", err)
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read s3 object: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,143 @@
+package web
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"os"
+
+	"github.com/bernard-sh/tfs/internal/compare"
+	"github.com/bernard-sh/tfs/internal/diff"
+)
+
+// diffClass maps a diff line to the CSS classes used by the reports.
+func diffClass(kind diff.LineKind) string {
+	switch kind {
+	case diff.LineHeader:
+		return "diff-header"
+	case diff.LineAdd:
+		return "diff-line diff-add"
+	case diff.LineDelete:
+		return "diff-line diff-del"
+	case diff.LineUpdate:
+		return "diff-line diff-mod"
+	case diff.LineReplace:
+		return "diff-line diff-rep"
+	default:
+		return "diff-line"
+	}
+}
+
+type compareSection struct {
+	Title   string
+	Key     string
+	Entries []compareEntry
+}
+
+type compareEntry struct {
+	Address     string
+	Description string
+	Lines       []diff.Line
+}
+
+var compareTemplate = template.Must(template.New("compare").Funcs(template.FuncMap{
+	"diffClass": diffClass,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Terraform Plan Comparison</title>
+    <style>
+        :root {
+            --bg-color: #1a1b26;
+            --text-color: #a9b1d6;
+            --sidebar-bg: #16161e;
+            --border-color: #414868;
+            --accent-color: #7aa2f7;
+            --create-color: #00AF00;
+            --destroy-color: #D70000;
+            --update-color: #AE00FF;
+            --replace-color: #FFAF00;
+        }
+        body { margin: 0; padding: 20px; font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; background-color: var(--bg-color); color: var(--text-color); }
+        h1 { font-size: 20px; color: var(--accent-color); }
+        h2 { font-size: 16px; margin-top: 30px; border-bottom: 1px solid var(--border-color); padding-bottom: 6px; }
+        h2.added { color: var(--create-color); }
+        h2.removed { color: var(--destroy-color); }
+        h2.action_changed { color: var(--replace-color); }
+        h2.values_changed { color: var(--update-color); }
+        details { background-color: var(--sidebar-bg); border: 1px solid var(--border-color); border-radius: 4px; margin: 8px 0; }
+        summary { padding: 10px 15px; cursor: pointer; font-size: 14px; }
+        summary .description { color: #565f89; margin-left: 10px; }
+        .diff { padding: 10px 20px; font-family: 'Consolas', 'Monaco', 'Courier New', monospace; font-size: 14px; line-height: 1.5; overflow-x: auto; }
+        .diff-line { white-space: pre; }
+        .diff-add { color: var(--create-color); }
+        .diff-del { color: var(--destroy-color); }
+        .diff-mod { color: var(--update-color); }
+        .diff-rep { color: var(--replace-color); }
+        .diff-header { font-weight: bold; margin-bottom: 10px; white-space: pre; }
+        .empty-state { color: var(--border-color); }
+    </style>
+</head>
+<body>
+<h1>Plan comparison: {{.Total}} difference(s)</h1>
+<p>{{.Old}} &rarr; {{.New}}</p>
+{{range .Sections}}
+<h2 class="{{.Key}}">{{.Title}} ({{len .Entries}})</h2>
+{{- range .Entries}}
+<details>
+    <summary><code>{{.Address}}</code><span class="description">{{.Description}}</span></summary>
+    <div class="diff">
+        {{- range .Lines}}
+        <div class="{{diffClass .Kind}}">{{.Text}}</div>
+        {{- end}}
+    </div>
+</details>
+{{- else}}
+<p class="empty-state">None</p>
+{{- end}}
+{{end}}
+</body>
+</html>
+`))
+
+// GenerateCompareHTML writes the report of the differences between two plans.
+// oldName and newName label the plans being compared (usually their file names).
+func GenerateCompareHTML(r compare.Result, oldName, newName, outputPath string) error {
+	var buf bytes.Buffer
+	if err := RenderCompare(&buf, r, oldName, newName); err != nil {
+		return err
+	}
+	return os.WriteFile(outputPath, buf.Bytes(), 0644)
+}
+
+// RenderCompare writes the comparison report to w.
+func RenderCompare(w io.Writer, r compare.Result, oldName, newName string) error {
+	titles := []string{"Added to the change set", "Removed from the change set", "Action changed", "Planned values changed"}
+
+	data := struct {
+		Old, New string
+		Total    int
+		Sections []compareSection
+	}{Old: oldName, New: newName, Total: r.Total()}
+
+	for i, entries := range r.Entries {
+		section := compareSection{Title: titles[i], Key: compare.Kind(i).String()}
+		for _, e := range entries {
+			var lines []diff.Line
+			switch e.Kind {
+			case compare.Removed:
+				lines = diff.Lines(*e.Old)
+			case compare.ValuesChanged:
+				lines = diff.Lines(e.ValuesChange())[1:]
+			default:
+				lines = diff.Lines(*e.New)
+			}
+			section.Entries = append(section.Entries, compareEntry{Address: e.Address, Description: e.Description(), Lines: lines})
+		}
+		data.Sections = append(data.Sections, section)
+	}
+
+	return compareTemplate.Execute(w, data)
+}
@@ -0,0 +1,38 @@
+package web
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/bernard-sh/tfs/internal/compare"
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+func TestRenderCompare(t *testing.T) {
+	oldPlan := models.TfPlan{}
+	newPlan := models.TfPlan{ResourceChanges: []models.ResourceChange{
+		{
+			Address: "res.new",
+			Type:    "res",
+			Name:    "new",
+			Change: models.Change{
+				Actions: []string{"create"},
+				After:   map[string]interface{}{"name": "<script>alert(1)</script>"},
+			},
+		},
+	}}
+
+	var buf bytes.Buffer
+	if err := RenderCompare(&buf, compare.Compare(oldPlan, newPlan), "old.tfplan", "new.tfplan"); err != nil {
+		t.Fatalf("RenderCompare failed: %v", err)
+	}
+
+	htmlStr := buf.String()
+	if !strings.Contains(htmlStr, "Added to the change set (1)") || !strings.Contains(htmlStr, "<code>res.new</code>") {
+		t.Errorf("Comparison report is missing the added resource")
+	}
+	if strings.Contains(htmlStr, "<script>alert(1)</script>") {
+		t.Errorf("Plan values are not escaped")
+	}
+}
@@ -0,0 +1,110 @@
+package web
+
+import (
+	"fmt"
+
+	"github.com/bernard-sh/tfs/internal/graph"
+	"github.com/bernard-sh/tfs/internal/models"
+)
+
+// Dimensions of the dependency graph drawing, in pixels. Dependencies are drawn
+// left of the resources depending on them.
+const (
+	graphNodeWidth  = 260
+	graphNodeHeight = 26
+	graphColGap     = 80
+	graphRowGap     = 12
+	graphMargin     = 10
+	graphLabelLen   = 34 // Characters of an address fitting in a node
+)
+
+// graphView is the dependency graph of the changed resources, laid out server-side
+// so it shows without JavaScript.
+type graphView struct {
+	ID             string // Element id, see elementID
+	Marker         string // Id of the arrow head, unique to the plan
+	Width, Height  int
+	Nodes          []graphNode
+	Edges          []graphEdge
+	Unchanged      int // Resources collapsed out of the drawing
+	IndirectEdges  bool
+	NodeW, NodeH   int
+	LabelX, LabelY int
+}
+
+type graphNode struct {
+	ID      string // Element id of the resource, see elementID
+	Address string
+	Label   string // The address, shortened to fit
+	Action  string // Category of the change
+	X, Y    int
+}
+
+type graphEdge struct {
+	From, To string
+	Path     string // SVG path data
+	Indirect bool
+}
+
+func newGraphView(label string, plan models.TfPlan) graphView {
+	full := graph.Build(plan)
+	g := full.Collapse()
+	v := graphView{
+		ID:        elementID(label, "graph"),
+		Marker:    "arrow-" + plan.Hash(),
+		Unchanged: len(full.Nodes) - len(g.Nodes),
+		NodeW:     graphNodeWidth,
+		NodeH:     graphNodeHeight,
+		LabelX:    8,
+		LabelY:    graphNodeHeight/2 + 4,
+	}
+
+	actions := make(map[string]string, len(g.Nodes))
+	for _, n := range g.Nodes {
+		actions[n.Address] = n.Action
+	}
+	type point struct{ x, y int }
+	positions := make(map[string]point, len(g.Nodes))
+	for col, layer := range g.Layers() {
+		for row, address := range layer {
+			p := point{
+				x: graphMargin + col*(graphNodeWidth+graphColGap),
+				y: graphMargin + row*(graphNodeHeight+graphRowGap),
+			}
+			positions[address] = p
+			v.Nodes = append(v.Nodes, graphNode{
+				ID:      elementID(label, address),
+				Address: address,
+				Label:   shorten(address, graphLabelLen),
+				Action:  actions[address],
+				X:       p.x,
+				Y:       p.y,
+			})
+			v.Width = max(v.Width, p.x+graphNodeWidth+graphMargin)
+			v.Height = max(v.Height, p.y+graphNodeHeight+graphMargin)
+		}
+	}
+
+	for _, e := range g.Edges {
+		from, to := positions[e.From], positions[e.To]
+		x1, y1 := from.x+graphNodeWidth, from.y+graphNodeHeight/2
+		x2, y2 := to.x, to.y+graphNodeHeight/2
+		v.Edges = append(v.Edges, graphEdge{
+			From:     e.From,
+			To:       e.To,
+			Path:     fmt.Sprintf("M%d %d C%d %d %d %d %d %d", x1, y1, x1+graphColGap/2, y1, x2-graphColGap/2, y2, x2, y2),
+			Indirect: e.Indirect,
+		})
+		v.IndirectEdges = v.IndirectEdges || e.Indirect
+	}
+	return v
+}
+
+// shorten keeps the end of an address too long for its node, the most specific part.
+func shorten(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return "…" + string(r[len(r)-n+1:])
+}
@@ -0,0 +1,235 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bernard-sh/tfs/internal/diff"
+	"github.com/bernard-sh/tfs/internal/metadata"
+	"github.com/bernard-sh/tfs/internal/models"
+	"github.com/bernard-sh/tfs/internal/risk"
+)
+
+const (
+	topRisks   = 5  // Resources listed under "Top risks"
+	maxModules = 10 // Modules charted, the others are summed up
+	maxValue   = 80 // Characters of an output value shown before truncating
+)
+
+// overviewView is the landing page of a plan: where it comes from, what it does at a
+// glance, and what needs attention first.
+type overviewView struct {
+	ID           string // Element id, see elementID
+	Meta         metadata.Metadata
+	Total        int
+	Counts       []countView
+	Bar          []barView // Share of each category, as one stacked bar
+	TopRisks     []riskView
+	Modules      []moduleView
+	OtherModules int // Modules with changes beyond maxModules
+	Outputs      []outputView
+	Drift        []driftView
+
+	// Sections the plan source could not read, see models.TfPlan.Lacks
+	OutputsUnavailable, DriftUnavailable bool
+}
+
+type countView struct {
+	Key, Title, Symbol string
+	Count              int
+}
+
+// barView is a segment of a chart bar, positioned in a 100-unit wide viewBox.
+type barView struct {
+	Key      string // Category name, for its color
+	X, Width string
+}
+
+type riskView struct {
+	ID, Address, Category, Level string
+	Score                        int
+	Finding                      string // The most severe finding
+}
+
+type moduleView struct {
+	Name  string
+	Total int
+	Bar   []barView // Scaled to the module with the most changes
+}
+
+type outputView struct {
+	Name, Key, Actions string
+	Before, After      string
+}
+
+type driftView struct {
+	Address, Actions string
+	Paths            string
+}
+
+func newOverview(label string, plan models.TfPlan, meta metadata.Metadata) overviewView {
+	o := overviewView{ID: elementID(label, "overview"), Meta: meta}
+
+	lists := models.Partition(plan)
+	var counts [models.NumCategories]int
+	for i, tab := range tabs {
+		cat := models.Category(i)
+		counts[i] = len(lists[cat])
+		o.Total += counts[i]
+		o.Counts = append(o.Counts, countView{Key: cat.String(), Title: tab.title, Symbol: tab.symbol, Count: counts[i]})
+	}
+	o.Bar = bars(counts, o.Total)
+	o.TopRisks = newTopRisks(label, lists)
+	o.Modules, o.OtherModules = newModules(lists)
+	o.OutputsUnavailable = plan.Lacks(models.SectionOutputChanges)
+	o.DriftUnavailable = plan.Lacks(models.SectionResourceDrift)
+
+	names := make([]string, 0, len(plan.OutputChanges))
+	for name := range plan.OutputChanges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		oc := plan.OutputChanges[name]
+		rc := models.ResourceChange{Change: models.Change{Actions: oc.Actions}}
+		if rc.IsNoOp() {
+			continue
+		}
+		out := outputView{Name: name, Key: models.Categorize(rc).String(), Actions: strings.Join(oc.Actions, ", ")}
+		// Older plans only mark sensitive outputs in the configuration
+		declared := plan.Configuration.RootModule.Outputs[name].Sensitive
+		if oc.Actions[0] != "create" {
+			out.Before = outputValue(oc.Before, declared || diff.IsMarked(oc.BeforeSensitive), false)
+		}
+		if oc.Actions[0] != "delete" {
+			out.After = outputValue(oc.After, declared || diff.IsMarked(oc.AfterSensitive), diff.IsMarked(oc.AfterUnknown))
+		}
+		o.Outputs = append(o.Outputs, out)
+	}
+
+	for _, rc := range plan.ResourceDrift {
+		if rc.IsNoOp() {
+			continue
+		}
+		o.Drift = append(o.Drift, driftView{
+			Address: rc.Address,
+			Actions: strings.Join(rc.Change.Actions, ", "),
+			Paths:   strings.Join(rc.Change.ChangedPaths(), ", "),
+		})
+	}
+	return o
+}
+
+// bars lays out counts as consecutive segments of a bar, scale being its full width.
+func bars(counts [models.NumCategories]int, scale int) []barView {
+	var segments []barView
+	x := 0.0
+	for i, n := range counts {
+		if n == 0 || scale == 0 {
+			continue
+		}
+		w := 100 * float64(n) / float64(scale)
+		segments = append(segments, barView{
+			Key:   models.Category(i).String(),
+			X:     fmt.Sprintf("%.2f", x),
+			Width: fmt.Sprintf("%.2f", w),
+		})
+		x += w
+	}
+	return segments
+}
+
+// newTopRisks lists the riskiest resources, highest score first, then in tab order.
+func newTopRisks(label string, lists map[models.Category][]models.ResourceChange) []riskView {
+	var risks []riskView
+	for i := 0; i < models.NumCategories; i++ {
+		cat := models.Category(i)
+		for _, rc := range lists[cat] {
+			a := risk.Assess(rc)
+			if a.Score == 0 {
+				continue
+			}
+			r := riskView{ID: elementID(label, rc.Key()), Address: rc.Key(), Category: cat.String(), Level: a.Level, Score: a.Score}
+			var worst risk.Severity
+			for _, f := range a.Findings {
+				if f.Severity > worst {
+					worst, r.Finding = f.Severity, f.Message
+				}
+			}
+			risks = append(risks, r)
+		}
+	}
+	sort.SliceStable(risks, func(i, j int) bool { return risks[i].Score > risks[j].Score })
+	if len(risks) > topRisks {
+		risks = risks[:topRisks]
+	}
+	return risks
+}
+
+// newModules counts the changes of each module, the root module included, most
+// changes first. Modules beyond maxModules are only counted.
+func newModules(lists map[models.Category][]models.ResourceChange) ([]moduleView, int) {
+	counts := make(map[string]*[models.NumCategories]int)
+	for cat, rcs := range lists {
+		for _, rc := range rcs {
+			name := rc.ModuleAddress
+			if name == "" {
+				name = "(root)"
+			}
+			if counts[name] == nil {
+				counts[name] = new([models.NumCategories]int)
+			}
+			counts[name][cat]++
+		}
+	}
+
+	modules := make([]moduleView, 0, len(counts))
+	for name, c := range counts {
+		total := 0
+		for _, n := range c {
+			total += n
+		}
+		modules = append(modules, moduleView{Name: name, Total: total})
+	}
+	sort.Slice(modules, func(i, j int) bool {
+		if modules[i].Total != modules[j].Total {
+			return modules[i].Total > modules[j].Total
+		}
+		return modules[i].Name < modules[j].Name
+	})
+
+	other := 0
+	if len(modules) > maxModules {
+		other = len(modules) - maxModules
+		modules = modules[:maxModules]
+	}
+	for i := range modules {
+		modules[i].Bar = bars(*counts[modules[i].Name], modules[0].Total)
+	}
+	return modules, other
+}
+
+// outputValue is the compact JSON display of an output value, unless it is sensitive
+// or not known until apply.
+func outputValue(v interface{}, sensitive, unknown bool) string {
+	if sensitive {
+		return "(sensitive value)"
+	}
+	if unknown {
+		return "(known after apply)"
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false) // Escaped by the template
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	s := strings.TrimSuffix(buf.String(), "\n")
+	if len([]rune(s)) > maxValue {
+		s = string([]rune(s)[:maxValue-1]) + "…"
+	}
+	return s
+}
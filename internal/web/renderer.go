@@ -1,32 +1,242 @@
 package web
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"html/template"
+	"io"
+	"net/url"
 	"os"
+	"strings"
+
+	"github.com/bernard-sh/tfs/internal/config"
+	"github.com/bernard-sh/tfs/internal/diff"
+	"github.com/bernard-sh/tfs/internal/graph"
+	"github.com/bernard-sh/tfs/internal/metadata"
+	"github.com/bernard-sh/tfs/internal/models"
+	"github.com/bernard-sh/tfs/internal/risk"
+	"github.com/bernard-sh/tfs/internal/stack"
 )
 
+// Options tweak the generated report.
+type Options struct {
+	// LiveReload makes the page reload itself when the server behind it
+	// (`tfs serve`) announces a new plan on /api/events.
+	LiveReload bool
+	// Workspace is the Terraform workspace shown on the overview. JSON plans do not
+	// record it, so it comes from the environment the plan ran in.
+	Workspace string
+	// ConfigDir is the root module directory of a single plan, to show the file and
+	// line declaring each resource. Multi-plan reports use the Dir of each stack.
+	ConfigDir string
+}
+
 func GenerateHTML(plan interface{}, outputPath string) error {
-	// Plan is passed as interface{} to avoid circular dependency if TfPlan is in TUI
-	// But ideally we share models. For now, assume it's valid struct.
-    // Wait, main.go defines TfPlan. We should move models to internal/models or similar.
-    // Or defining structs here again?
-    // Let's create `internal/models/plan.go` ideally.
-    // For this step I'll just accept interface{} and marshal it so I don't break things 
-    // before seeing where TfPlan goes.
-    
-	planJSON, err := json.Marshal(plan)
+	var buf bytes.Buffer
+	if err := Render(&buf, plan, Options{}); err != nil {
+		return err
+	}
+	return os.WriteFile(outputPath, buf.Bytes(), 0644)
+}
+
+// GenerateStacksHTML writes a single report covering several plans, opening on
+// an index of the stacks.
+func GenerateStacksHTML(stacks []stack.Stack, outputPath string) error {
+	var buf bytes.Buffer
+	if err := RenderStacks(&buf, stacks, Options{}); err != nil {
+		return err
+	}
+	return os.WriteFile(outputPath, buf.Bytes(), 0644)
+}
+
+// RenderStacks writes the multi-plan HTML report to w.
+func RenderStacks(w io.Writer, stacks []stack.Stack, opts Options) error {
+	plans := make([]planView, 0, len(stacks))
+	for _, st := range stacks {
+		plans = append(plans, newPlanView(st.Label, st.Plan, opts.Workspace, st.Dir))
+	}
+	return render(w, reportData{Stacks: true, Plans: plans}, opts)
+}
+
+// Render writes the HTML report of plan to w. plan is usually a models.TfPlan, but
+// any value encoding to the same JSON renders.
+func Render(w io.Writer, plan interface{}, opts Options) error {
+	p, err := toPlan(plan)
+	if err != nil {
+		return err
+	}
+	return render(w, reportData{Plans: []planView{newPlanView("", p, opts.Workspace, opts.ConfigDir)}}, opts)
+}
+
+// toPlan converts the value given to Render, e.g. raw plan JSON decoded into maps.
+func toPlan(plan interface{}) (models.TfPlan, error) {
+	if p, ok := plan.(models.TfPlan); ok {
+		return p, nil
+	}
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return models.TfPlan{}, err
+	}
+	return models.ParsePlan(string(data))
+}
+
+// tabs are the category tabs, in the TUI's order.
+var tabs = [models.NumCategories]struct{ title, symbol string }{
+	{"CREATE", "+"}, {"DESTROY", "-"}, {"REPLACE", "-/+"}, {"UPDATE", "~"}, {"IMPORT", ""},
+}
+
+// reportData fills reportTemplate. Everything is rendered server-side, so the report
+// reads (and searches, and prints) without JavaScript.
+type reportData struct {
+	Stacks     bool // Open on the index of Plans
+	Plans      []planView
+	Nonce      string // Allows the report's own script and style under its CSP
+	LiveReload bool
+}
+
+type planView struct {
+	ID       string   // Element id: the stack label, "plan" for a single plan
+	Label    string   // Stack label, empty for a single plan
+	Hash     string   // Keys the review progress
+	Actions  []string // Distinct action lists, the options of the action filter
+	Stats    stack.Stats
+	Overview overviewView
+	Tabs     []tabView
+	Graph    graphView
+}
+
+type tabView struct {
+	ID        string // Element id, see elementID
+	Key       string // Category name, e.g. "create"
+	Title     string
+	Symbol    string
+	Resources []resourceView
+}
+
+type resourceView struct {
+	ID      string // Element id, see elementID
+	Address string
+	Type    string
+	Module  string
+	Actions string // e.g. "delete, create"
+	Risk    string // Risk level: none, low, medium or high
+	Lines   []diff.Line
+	Blast   []diff.Line // Blast radius of destroyed and replaced resources
+	Source  []diff.Line // Module, expressions and declaration, from the configuration
+}
+
+func newPlanView(label string, plan models.TfPlan, workspace, dir string) planView {
+	meta := metadata.New(plan, dir)
+	if plan.Meta.Workspace == "" && workspace != "" {
+		meta.Workspace = workspace
+	}
+	id := label
+	if id == "" {
+		id = "plan"
+	}
+	v := planView{
+		ID:       id,
+		Label:    label,
+		Hash:     plan.Hash(),
+		Stats:    stack.Stack{Plan: plan}.Stats(),
+		Overview: newOverview(label, plan, meta),
+		Graph:    newGraphView(label, plan),
+	}
+	lists := models.Partition(plan)
+	blasts := graph.BlastRadius(plan)
+	seen := make(map[string]bool)
+	for i, tab := range tabs {
+		cat := models.Category(i)
+		t := tabView{ID: elementID(label, cat.String()), Key: cat.String(), Title: tab.title, Symbol: tab.symbol}
+		for _, rc := range lists[cat] {
+			actions := strings.Join(rc.Change.Actions, ", ")
+			if !seen[actions] {
+				seen[actions] = true
+				v.Actions = append(v.Actions, actions)
+			}
+			r := resourceView{
+				ID:      elementID(label, rc.Key()),
+				Address: rc.Key(),
+				Type:    rc.Type,
+				Module:  rc.ModuleAddress,
+				Actions: actions,
+				Risk:    risk.Assess(rc).Level,
+				Lines:   diff.Lines(rc),
+			}
+			if b, ok := blasts[rc.Address]; ok && rc.Deposed == "" {
+				r.Blast = b.Lines()
+			}
+			if src, ok := config.Describe(plan.Configuration, dir, rc); ok {
+				r.Source = src.Lines()
+			}
+			t.Resources = append(t.Resources, r)
+		}
+		v.Tabs = append(v.Tabs, t)
+	}
+	return v
+}
+
+// elementID is the id of a tab (named by its category, "overview" or "graph") or resource (by
+// its address). Ids double as the report's URL fragments, e.g. #replace or #module.db.aws_rds_cluster.main,
+// prefixed by "<stack label>:" in multi-plan reports to stay unique across stacks.
+func elementID(label, name string) string {
+	if label == "" {
+		return name
+	}
+	return label + ":" + name
+}
+
+// DeepLink links to the resource at address in the report uploaded at reportURL.
+// label is the resource's stack in a multi-plan report, empty otherwise.
+func DeepLink(reportURL, label, address string) string {
+	return reportURL + string(fragment(elementID(label, address)))
+}
+
+// fragment is the link to the element id, escaped only where URLs require it so
+// shared links stay readable, e.g. #envs/prod:aws_instance.web.
+func fragment(id string) template.URL {
+	u := url.URL{Fragment: id}
+	return template.URL("#" + u.EscapedFragment())
+}
+
+func render(w io.Writer, data reportData, opts Options) error {
+	nonce, err := newNonce()
 	if err != nil {
 		return err
 	}
+	data.Nonce = nonce
+	data.LiveReload = opts.LiveReload
+	return reportTemplate.Execute(w, data)
+}
+
+// newNonce returns a random CSP nonce, URL-safe so it needs no escaping in attributes.
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
 
-	html := fmt.Sprintf(`<!DOCTYPE html>
+// reportTemplate is the plan report. Plan values are escaped by html/template, the
+// script only adds navigation and review progress on top of the rendered markup, and
+// the Content-Security-Policy blocks any script or style but the report's own.
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"diffClass": diffClass,
+	"elementID": elementID,
+	"fragment":  fragment,
+}).Parse(`<!DOCTYPE html>
 <html lang="en">
 <head>
     <meta charset="UTF-8">
     <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <meta http-equiv="Content-Security-Policy" content="default-src 'none'; script-src 'nonce-{{.Nonce}}'; style-src 'nonce-{{.Nonce}}'; connect-src 'self'">
     <title>Terraform Plan Analysis</title>
-    <style>
+    <script nonce="{{.Nonce}}">document.documentElement.classList.add("js");</script>
+    <style nonce="{{.Nonce}}">
         :root {
             --bg-color: #1a1b26;
             --text-color: #a9b1d6;
@@ -42,11 +252,16 @@ func GenerateHTML(plan interface{}, outputPath string) error {
             --tab-text-active: #FAFAFA;
         }
 
+        /* Without JavaScript the report is one long page listing every resource.
+           The script adds the "js" class and turns it into a tabbed viewer. */
         body {
             margin: 0;
             font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif;
             background-color: var(--bg-color);
             color: var(--text-color);
+        }
+
+        html.js body {
             height: 100vh;
             display: flex;
             flex-direction: column;
@@ -58,8 +273,9 @@ func GenerateHTML(plan interface{}, outputPath string) error {
             background-color: var(--sidebar-bg);
             border-bottom: 1px solid var(--border-color);
             padding: 0 10px;
-            height: 40px;
+            min-height: 40px;
             display: flex;
+            flex-wrap: wrap;
             align-items: flex-end;
             user-select: none;
         }
@@ -70,6 +286,7 @@ func GenerateHTML(plan interface{}, outputPath string) error {
             font-weight: bold;
             font-size: 14px;
             color: var(--tab-text-inactive);
+            text-decoration: none;
             border-top-left-radius: 4px;
             border-top-right-radius: 4px;
             margin-right: 2px;
@@ -83,14 +300,14 @@ func GenerateHTML(plan interface{}, outputPath string) error {
         .tab.active {
             color: var(--tab-text-active);
         }
-        
+
         /* Tab Colors */
         .tab-create.active { background-color: var(--create-color); }
         .tab-destroy.active { background-color: var(--destroy-color); }
         .tab-replace.active { background-color: var(--replace-color); }
         .tab-update.active { background-color: var(--update-color); }
         .tab-import.active { background-color: var(--import-color); }
-        
+
         .tab-create { color: var(--create-color); }
         .tab-destroy { color: var(--destroy-color); }
         .tab-replace { color: var(--replace-color); }
@@ -100,24 +317,38 @@ func GenerateHTML(plan interface{}, outputPath string) error {
         /* MAIN LAYOUT */
         .container {
             display: flex;
-            flex: 1;
-            overflow: hidden;
+            align-items: flex-start;
         }
 
+        html.js .plan-view { display: none; flex: 1; flex-direction: column; min-height: 0; }
+        html.js .plan-view.active { display: flex; }
+        html.js .container { flex: 1; overflow: hidden; align-items: stretch; }
+
         /* SIDEBAR LIST */
         .sidebar {
             width: 350px;
             background-color: var(--sidebar-bg);
             border-right: 1px solid var(--border-color);
             overflow-y: auto;
-            display: flex;
-            flex-direction: column;
             flex-shrink: 0;
+            position: sticky;
+            top: 0;
+            max-height: 100vh;
         }
 
+        html.js .sidebar { position: static; max-height: none; }
+
+        .list-title { padding: 10px 15px 4px; font-size: 12px; font-weight: bold; }
+        html.js .list-title { display: none; }
+        html.js .list-group { display: none; }
+        html.js .list-group.active { display: block; }
+
         .resource-item {
+            display: block;
             padding: 10px 15px;
             cursor: pointer;
+            color: inherit;
+            text-decoration: none;
             border-bottom: 1px solid rgba(65, 72, 104, 0.3);
             white-space: nowrap;
             overflow: hidden;
@@ -139,309 +370,818 @@ func GenerateHTML(plan interface{}, outputPath string) error {
         /* DETAIL VIEW */
         .detail-view {
             flex: 1;
+            min-width: 0;
             padding: 20px;
-            overflow-y: auto;
+            overflow-x: auto;
             font-family: 'Consolas', 'Monaco', 'Courier New', monospace;
             line-height: 1.5;
             font-size: 14px;
         }
 
+        html.js .detail-view { overflow-y: auto; }
+
+        .category-title { font-size: 16px; margin: 30px 0 10px 0; border-bottom: 1px solid var(--border-color); padding-bottom: 6px; }
+        .category-title:first-of-type { margin-top: 0; }
+        .resource { margin-bottom: 24px; }
+        .category-empty { color: var(--border-color); }
+        html.js .category-title, html.js .category-empty, html.js .resource { display: none; }
+        html.js .resource.active { display: block; }
+        .detail-empty { display: none; }
+        html.js .detail-empty.active { display: block; }
+
         .diff-line { white-space: pre; }
         .diff-add { color: var(--create-color); }
         .diff-del { color: var(--destroy-color); }
         .diff-mod { color: var(--update-color); }
         .diff-rep { color: var(--replace-color); }
-        .diff-header { font-weight: bold; margin-bottom: 10px; display: block; }
+        .diff-header { font-weight: bold; margin-bottom: 10px; white-space: pre; }
         .diff-block-header { font-weight: normal; }
+        .blast, .source { margin-top: 16px; padding-top: 10px; border-top: 1px dashed var(--border-color); }
 
         /* SCROLLBAR */
         ::-webkit-scrollbar { width: 10px; height: 10px; }
         ::-webkit-scrollbar-track { background: var(--bg-color); }
         ::-webkit-scrollbar-thumb { background: var(--border-color); border-radius: 5px; }
         ::-webkit-scrollbar-thumb:hover { background: #565f89; }
-        
+
         .empty-state { padding: 40px; text-align: center; color: var(--border-color); }
+        .list-group .empty-state { padding: 10px 15px; text-align: left; }
+        html.js .list-group .empty-state { padding: 40px; text-align: center; }
+
+        /* STACK INDEX (multi-plan reports) */
+        .index-view { overflow-y: auto; padding: 20px; }
+        html.js .index-view { display: none; flex: 1; }
+        html.js .index-view.active { display: block; }
+        .index-view h1 { font-size: 18px; color: var(--accent-color); margin: 0 0 16px 0; }
+        .index-table { border-collapse: collapse; width: 100%; font-size: 14px; }
+        .index-table th, .index-table td { padding: 8px 12px; border-bottom: 1px solid rgba(65, 72, 104, 0.3); text-align: right; }
+        .index-table th:first-child, .index-table td:first-child { text-align: left; }
+        .index-table a { color: inherit; text-decoration: none; }
+        .index-table tbody tr { cursor: pointer; }
+        .index-table tbody tr:hover { background-color: rgba(255, 255, 255, 0.05); }
+        .index-table tbody tr.cursor { outline: 1px solid var(--accent-color); outline-offset: -1px; }
+        .index-table .high-risk { color: var(--destroy-color); font-weight: bold; }
+        .tab-back { color: var(--accent-color); }
+        html:not(.js) .tab-back { display: none; }
+
+        /* SEARCH & FILTERS */
+        .filters { padding: 10px 15px; border-bottom: 1px solid var(--border-color); display: flex; flex-wrap: wrap; gap: 6px; align-items: center; font-size: 13px; }
+        .filters input[type="search"] { flex: 1 1 100%; box-sizing: border-box; padding: 6px 8px; }
+        .filters input[type="search"], .filters select { background: var(--bg-color); color: var(--text-color); border: 1px solid var(--border-color); border-radius: 4px; }
+        .filters select { padding: 3px; }
+        html:not(.js) .filters, html:not(.js) .help { display: none; }
+        html.js .resource-item.filtered-out { display: none; }
+        .no-match { display: none; }
+        html.js .no-match.active { display: block; }
+        .resource-item.cursor { outline: 1px solid var(--accent-color); outline-offset: -1px; }
+        .help { white-space: pre; padding: 6px 15px; font-size: 12px; color: #565f89; border-top: 1px solid var(--border-color); background-color: var(--sidebar-bg); }
+
+        /* PAGES (overview, graph): shown instead of the resource list */
+        .page { padding: 20px; border-bottom: 1px solid var(--border-color); }
+        html.js .page { display: none; flex: 1; overflow: auto; border-bottom: none; }
+        html.js .page.active { display: block; }
+        html.js .plan-view.page-open .container { display: none; }
+        .tab-overview, .tab-graph { color: var(--accent-color); }
+        .tab-overview.active, .tab-graph.active { background-color: var(--accent-color); }
 
+        /* OVERVIEW */
+        .overview h2 { font-size: 15px; color: var(--accent-color); margin: 24px 0 10px 0; }
+        .overview h2:first-child { margin-top: 0; }
+        .overview table { border-collapse: collapse; font-size: 14px; }
+        .overview th, .overview td { padding: 6px 12px 6px 0; text-align: left; vertical-align: top; border-bottom: 1px solid rgba(65, 72, 104, 0.3); }
+        .overview th { font-weight: normal; color: #565f89; }
+        .overview a { color: inherit; }
+        .overview .value { font-family: 'Consolas', 'Monaco', 'Courier New', monospace; word-break: break-all; }
+        .overview .none { color: var(--border-color); }
+        .counts { display: flex; flex-wrap: wrap; gap: 10px; }
+        .count { min-width: 90px; padding: 10px 14px; border: 1px solid var(--border-color); border-radius: 4px; text-decoration: none; }
+        .count-value { display: block; font-size: 22px; font-weight: bold; }
+        .chart { display: block; width: 100%; max-width: 640px; height: 14px; margin: 10px 0; }
+        .module-row { display: flex; align-items: center; gap: 10px; font-size: 13px; max-width: 900px; }
+        .module-name { flex: 0 0 280px; overflow: hidden; text-overflow: ellipsis; white-space: nowrap; }
+        .module-row .chart { flex: 1; margin: 4px 0; }
+        .module-total { flex: 0 0 40px; text-align: right; }
+        .bar-create { fill: var(--create-color); }
+        .bar-destroy { fill: var(--destroy-color); }
+        .bar-replace { fill: var(--replace-color); }
+        .bar-update { fill: var(--update-color); }
+        .bar-import { fill: var(--import-color); }
+        .risk-high { color: var(--destroy-color); font-weight: bold; }
+        .risk-medium { color: var(--replace-color); }
+
+        /* DEPENDENCY GRAPH */
+        .graph h2 { font-size: 15px; color: var(--accent-color); margin: 0 0 10px 0; }
+        .graph-note { font-size: 13px; margin: 0 0 12px 0; }
+        .graph-status { font-size: 13px; min-height: 1.5em; color: var(--accent-color); }
+        .graph svg { display: block; }
+        .graph .node rect { stroke: none; }
+        .graph .node text { fill: #FFFFFF; font-size: 12px; font-family: 'Consolas', 'Monaco', 'Courier New', monospace; }
+        .graph .node { cursor: pointer; }
+        .graph .node-create rect { fill: var(--create-color); }
+        .graph .node-destroy rect { fill: var(--destroy-color); }
+        .graph .node-replace rect { fill: var(--replace-color); }
+        .graph .node-update rect { fill: var(--update-color); }
+        .graph .node-import rect { fill: var(--import-color); }
+        .graph .node.focus rect { stroke: var(--tab-text-active); stroke-width: 2; }
+        .graph .edge { fill: none; stroke: #565f89; stroke-width: 1.5; }
+        .graph .edge.indirect { stroke-dasharray: 5 4; }
+        .graph .edge.lit { stroke: var(--tab-text-active); }
+        .graph .arrow { fill: #565f89; }
+        .graph .dim { opacity: 0.15; }
+
+        /* REVIEW PROGRESS */
+        .review-toggle { margin: 0 8px 0 0; vertical-align: middle; cursor: pointer; }
+        .resource-item.reviewed { opacity: 0.55; }
+        .tab-progress { font-weight: normal; font-size: 12px; opacity: 0.8; }
+        .stack-label { padding: 8px 16px; font-size: 14px; color: var(--text-color); align-self: center; }
+        .plan-meta { margin-left: auto; padding: 8px 6px; font-size: 12px; color: #565f89; align-self: center; }
+        .plan-warning { color: var(--replace-color); font-weight: bold; }
+
+        /* PRINT: every resource, dark on white, without the navigation */
+        @media print {
+            :root {
+                --bg-color: #FFFFFF;
+                --text-color: #000000;
+                --border-color: #999999;
+                --create-color: #006400;
+                --destroy-color: #A00000;
+                --update-color: #6A00A0;
+                --replace-color: #A05A00;
+                --import-color: #005A8C;
+            }
+            html.js body { height: auto; display: block; overflow: visible; }
+            .sidebar, .tab, .detail-empty, .review-toggle, .help { display: none !important; }
+            html.js .page { display: block !important; border-bottom: 1px solid var(--border-color); padding: 0 0 20px 0; }
+            html.js .plan-view.page-open .container { display: block !important; }
+            .graph-status { display: none; }
+            .header { border: none; background: none; padding: 0; min-height: 0; }
+            .stack-label { font-size: 18px; font-weight: bold; padding: 0; }
+            html.js .index-view, html.js .plan-view, html.js .container { display: block !important; overflow: visible; }
+            html.js .category-title, html.js .resource { display: block !important; }
+            .category-empty { display: none; }
+            .detail-view, html.js .detail-view { overflow: visible; padding: 0; }
+            .index-view + .plan-view, .plan-view + .plan-view { break-before: page; }
+            .category-title { break-after: avoid; }
+            .resource { break-inside: avoid; }
+            .diff-line, .diff-header { white-space: pre-wrap; }
+        }
     </style>
 </head>
 <body>
+{{- if .Stacks}}
 
-<div class="header" id="tabs-container">
-    <!-- Tabs will be injected here -->
+<div class="index-view" id="index-view">
+    <h1>{{len .Plans}} stacks</h1>
+    <table class="index-table">
+        <thead>
+            <tr><th>Stack</th><th>+</th><th>-</th><th>-/+</th><th>~</th><th>Import</th><th>High risk</th><th>Max risk</th></tr>
+        </thead>
+        <tbody>
+        {{- range .Plans}}
+            <tr{{if .Stats.HighRisk}} class="high-risk"{{end}}>
+                <td><a href="{{fragment .ID}}">{{.Label}}</a></td>
+                {{- range .Stats.Counts}}<td>{{.}}</td>{{end}}
+                <td>{{.Stats.HighRisk}}</td><td>{{.Stats.MaxRisk}}</td>
+            </tr>
+        {{- end}}
+        </tbody>
+    </table>
 </div>
+{{- end}}
+{{- range $plan := .Plans}}
 
-<div class="container">
-    <div class="sidebar" id="resource-list">
-        <!-- Resources will be injected here -->
+<div class="plan-view" id="{{$plan.ID}}" data-hash="{{$plan.Hash}}">
+    <div class="header">
+        {{- if $.Stacks}}
+        <a class="tab tab-back" href="#">&larr; STACKS</a>
+        <div class="stack-label">{{$plan.Label}}</div>
+        {{- end}}
+        <a class="tab tab-overview" href="{{fragment $plan.Overview.ID}}" data-tab="overview">OVERVIEW</a>
+        {{- range $plan.Tabs}}
+        <a class="tab tab-{{.Key}}" href="{{fragment .ID}}" data-tab="{{.Key}}">{{.Title}} ({{if .Symbol}}{{.Symbol}} {{end}}<span class="tab-count">{{len .Resources}}</span>)<span class="tab-progress"></span></a>
+        {{- end}}
+        <a class="tab tab-graph" href="{{fragment $plan.Graph.ID}}" data-tab="graph">GRAPH</a>
+        {{- with $plan.Overview.Meta}}{{if or .Summary .Warnings}}
+        <div class="plan-meta">{{.Summary}}{{with .Warnings}} <span class="plan-warning">&#9888; Plan {{range $i, $w := .}}{{if $i}}, {{end}}{{$w}}{{end}}</span>{{end}}</div>
+        {{- end}}{{end}}
     </div>
-    <div class="detail-view" id="detail-view">
-        <div class="empty-state">Select a resource to view details</div>
+    {{- with $plan.Overview}}
+
+    <section class="overview page" id="{{.ID}}" data-tab="overview">
+        <h2>Plan</h2>
+        <table>
+            {{- with .Meta}}
+            <tr><th>Terraform</th><td>{{or .TerraformVersion "unknown"}}</td></tr>
+            {{- if .FormatVersion}}
+            <tr><th>Format version</th><td>{{.FormatVersion}}</td></tr>
+            {{- end}}
+            <tr><th>Planned at</th><td>{{or .Timestamp "unknown"}}</td></tr>
+            {{- if .Workspace}}
+            <tr><th>Workspace</th><td>{{.Workspace}}</td></tr>
+            {{- end}}
+            {{- with .Warnings}}
+            <tr><th>Status</th><td class="plan-warning">{{range $i, $w := .}}{{if $i}}, {{end}}{{$w}}{{end}}</td></tr>
+            {{- else}}{{if .Ready}}
+            <tr><th>Status</th><td>applyable, complete</td></tr>
+            {{- end}}{{end}}
+            {{- end}}
+            <tr><th>Changes</th><td>{{.Total}}</td></tr>
+        </table>
+
+        <h2>Variables</h2>
+        {{- if .Meta.Variables}}
+        <table>
+            <tbody>
+            {{- range .Meta.Variables}}
+                <tr><th>{{.Name}}</th><td class="value">{{.Value}}</td></tr>
+            {{- end}}
+            </tbody>
+        </table>
+        {{- else}}
+        <p class="none">No input variables</p>
+        {{- end}}
+
+        <h2>Providers</h2>
+        {{- if .Meta.Providers}}
+        <table>
+            <thead><tr><th>Provider</th><th>Version</th><th>Constraints</th></tr></thead>
+            <tbody>
+            {{- range .Meta.Providers}}
+                <tr><td>{{.Source}}</td><td>{{or .Version "unknown"}}</td><td class="value">{{.Constraint}}</td></tr>
+            {{- end}}
+            </tbody>
+        </table>
+        {{- else}}
+        <p class="none">No providers recorded</p>
+        {{- end}}
+
+        <h2>Changes by action</h2>
+        <div class="counts">
+            {{- range .Counts}}
+            <a class="count tab-{{.Key}}" href="{{fragment (elementID $plan.Label .Key)}}"><span class="count-value">{{.Count}}</span>{{.Title}}{{if .Symbol}} ({{.Symbol}}){{end}}</a>
+            {{- end}}
+        </div>
+        {{- if .Bar}}
+        {{template "bar" .Bar}}
+        {{- end}}
+
+        <h2>Top risks</h2>
+        {{- if .TopRisks}}
+        <table>
+            <thead><tr><th>Resource</th><th>Action</th><th>Risk</th><th>Finding</th></tr></thead>
+            <tbody>
+            {{- range .TopRisks}}
+                <tr><td><a href="{{fragment .ID}}">{{.Address}}</a></td><td class="tab-{{.Category}}">{{.Category}}</td><td class="risk-{{.Level}}">{{.Level}} ({{.Score}})</td><td>{{.Finding}}</td></tr>
+            {{- end}}
+            </tbody>
+        </table>
+        {{- else}}
+        <p class="none">No risky changes</p>
+        {{- end}}
+
+        <h2>Changes by module</h2>
+        {{- range .Modules}}
+        <div class="module-row"><span class="module-name" title="{{.Name}}">{{.Name}}</span>{{template "bar" .Bar}}<span class="module-total">{{.Total}}</span></div>
+        {{- else}}
+        <p class="none">No changes</p>
+        {{- end}}
+        {{- if .OtherModules}}
+        <p class="none">and {{.OtherModules}} more modules</p>
+        {{- end}}
+
+        <h2>Outputs</h2>
+        {{- if .Outputs}}
+        <table>
+            <thead><tr><th>Output</th><th>Action</th><th>Before</th><th>After</th></tr></thead>
+            <tbody>
+            {{- range .Outputs}}
+                <tr><td>{{.Name}}</td><td class="tab-{{.Key}}">{{.Actions}}</td><td class="value">{{.Before}}</td><td class="value">{{.After}}</td></tr>
+            {{- end}}
+            </tbody>
+        </table>
+        {{- else if .OutputsUnavailable}}
+        <p class="none">Not available for this plan source</p>
+        {{- else}}
+        <p class="none">No output changes</p>
+        {{- end}}
+
+        <h2>Drift</h2>
+        {{- if .Drift}}
+        <p>Changed outside of Terraform since the last apply:</p>
+        <table>
+            <thead><tr><th>Resource</th><th>Action</th><th>Attributes</th></tr></thead>
+            <tbody>
+            {{- range .Drift}}
+                <tr><td>{{.Address}}</td><td>{{.Actions}}</td><td class="value">{{.Paths}}</td></tr>
+            {{- end}}
+            </tbody>
+        </table>
+        {{- else if .DriftUnavailable}}
+        <p class="none">Not available for this plan source</p>
+        {{- else}}
+        <p class="none">No drift detected</p>
+        {{- end}}
+    </section>
+    {{- end}}
+    {{- with $plan.Graph}}
+
+    <section class="graph page" id="{{.ID}}" data-tab="graph">
+        <h2>Dependencies</h2>
+        {{- if .Nodes}}
+        <p class="graph-note">Resources are drawn right of the resources they depend on: a change cascades to the right.
+            {{- if .Unchanged}} {{.Unchanged}} unchanged resources are hidden{{if .IndirectEdges}}, dashed arrows go through them{{end}}.{{end}}</p>
+        <div class="graph-status"></div>
+        <svg width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}">
+            <defs><marker id="{{.Marker}}" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="7" markerHeight="7" orient="auto"><path class="arrow" d="M0 0 L10 5 L0 10 z"></path></marker></defs>
+            {{- $marker := .Marker}}
+            {{- range .Edges}}
+            <path class="edge{{if .Indirect}} indirect{{end}}" d="{{.Path}}" marker-end="url(#{{$marker}})" data-from="{{.From}}" data-to="{{.To}}"></path>
+            {{- end}}
+            {{- $g := .}}
+            {{- range .Nodes}}
+            <a class="node node-{{.Action}}" href="{{fragment .ID}}" data-address="{{.Address}}">
+                <title>{{.Address}} ({{.Action}})</title>
+                <rect x="{{.X}}" y="{{.Y}}" width="{{$g.NodeW}}" height="{{$g.NodeH}}" rx="4"></rect>
+                <text x="{{.X}}" y="{{.Y}}" dx="{{$g.LabelX}}" dy="{{$g.LabelY}}">{{.Label}}</text>
+            </a>
+            {{- end}}
+        </svg>
+        {{- else}}
+        <p class="none">No changes</p>
+        {{- end}}
+    </section>
+    {{- end}}
+
+    <div class="container">
+        <nav class="sidebar">
+            <div class="filters">
+                <input type="search" class="filter-search" placeholder="Search address, type, module  [/]" aria-label="Search resources">
+                <label><input type="checkbox" class="filter-values"> Values</label>
+                <select class="filter-action" aria-label="Action">
+                    <option value="">Any action</option>
+                    {{- range $plan.Actions}}
+                    <option value="{{.}}">{{.}}</option>
+                    {{- end}}
+                </select>
+                <select class="filter-risk" aria-label="Risk">
+                    <option value="0">Any risk</option>
+                    <option value="1">Low and above</option>
+                    <option value="2">Medium and above</option>
+                    <option value="3">High</option>
+                </select>
+            </div>
+            {{- range $plan.Tabs}}
+            <div class="list-group" data-tab="{{.Key}}">
+                <div class="list-title tab-{{.Key}}">{{.Title}}</div>
+                {{- range .Resources}}
+                <a class="resource-item" href="{{fragment .ID}}" data-address="{{.Address}}" data-type="{{.Type}}" data-module="{{.Module}}" data-actions="{{.Actions}}" data-risk="{{.Risk}}" title="{{.Address}}">{{.Address}}</a>
+                {{- else}}
+                <div class="empty-state">No resources</div>
+                {{- end}}
+                {{- if .Resources}}
+                <div class="empty-state no-match">No matching resources</div>
+                {{- end}}
+            </div>
+            {{- end}}
+        </nav>
+        <main class="detail-view">
+            <div class="empty-state detail-empty">Select a resource to view details</div>
+            {{- range $tab := $plan.Tabs}}
+            <h2 class="category-title tab-{{.Key}}" id="{{.ID}}" data-tab="{{.Key}}">{{.Title}} ({{len .Resources}})</h2>
+            {{- range .Resources}}
+            <section class="resource" id="{{.ID}}" data-tab="{{$tab.Key}}" data-address="{{.Address}}">
+                {{- range .Lines}}
+                <div class="{{diffClass .Kind}}">{{.Text}}</div>
+                {{- end}}
+                {{- if .Blast}}
+                <div class="blast">
+                    {{- range .Blast}}
+                    <div class="{{diffClass .Kind}}">{{.Text}}</div>
+                    {{- end}}
+                </div>
+                {{- end}}
+                {{- if .Source}}
+                <div class="source">
+                    {{- range .Source}}
+                    <div class="{{diffClass .Kind}}">{{.Text}}</div>
+                    {{- end}}
+                </div>
+                {{- end}}
+            </section>
+            {{- else}}
+            <p class="category-empty">No resources</p>
+            {{- end}}
+            {{- end}}
+        </main>
     </div>
+    <div class="help">[j/k]: Navigate  [Enter]: Details  [Space]: Reviewed  [Tab]: Next Category  [/]: Search  [Esc]: Back</div>
 </div>
+{{- end}}
 
-<script>
-    // Embedded Plan Data
-    const planData = %s;
-    
-    // State
-    let activeTab = 0;
-    let selectedResourceIndex = -1;
-    let filteredResources = [];
-
-    // Categories
-    const CAT_CREATE = 0;
-    const CAT_DESTROY = 1;
-    const CAT_REPLACE = 2; // Fixed: Replace is 2 in Go logic now
-    const CAT_UPDATE = 3;  // Fixed: Update is 3 in Go logic now
-    const CAT_IMPORT = 4;
-
-    function getCategory(rc) {
-        const actions = rc.address ? rc.change.actions : rc.Change.Actions; // Handle case sensitivity if raw JSON vs Go struct differs
-        // Go struct: rc.Change.Actions. JSON usually: rc.change.actions (lowercase)
-        // Let's normalize content
-        const act = actions || [];
-        
-        if (act.length > 1 && act[0] === "delete" && act[1] === "create") return CAT_REPLACE;
-        if (act[0] === "create") return CAT_CREATE;
-        if (act[0] === "delete") return CAT_DESTROY;
-        if (act[0] === "update") return CAT_UPDATE;
-        return CAT_IMPORT;
+<script nonce="{{.Nonce}}">
+    // The page is complete without this script: it only turns the rendered plans
+    // into a tabbed viewer and tracks review progress.
+    const indexView = document.getElementById('index-view');
+    const planViews = Array.from(document.querySelectorAll('.plan-view'));
+
+    // --- REVIEW PROGRESS ---
+    // Saved in localStorage per plan hash, in the reviewed Set of each plan view
+
+    function reviewKey(view) {
+        return "tfs-reviewed-" + view.dataset.hash;
     }
 
-    // Process Data into buckets
-    const resourcesByCat = { 0: [], 1: [], 2: [], 3: [], 4: [] };
-    
-    // Check capitalization from JSON marshal
-    // Go "ResourceChanges" -> JSON "resource_changes" usually? 
-    // Wait, json.Marshal uses struct tags. Go struct has no tags? 
-    // Let's assume standard Go struct field rules or check 'plan.json' viewed earlier.
-    // Viewed file 'plan.json': "resource_changes": [ ... "change": { "actions": ... } ]
-    // So lowercase underscore.
-    
-    const allResources = planData.resource_changes || [];
-
-    allResources.forEach(rc => {
-        // Skip null changes if any (Terraform sometimes includes no-op resources in plan)
-        if (!rc.change || !rc.change.actions || rc.change.actions.length === 0 || rc.change.actions[0] === "no-op") return;
-        
-        const cat = getCategory(rc);
-        resourcesByCat[cat].push(rc);
-    });
+    function loadReviewed(view) {
+        try {
+            view.reviewed = new Set(JSON.parse(localStorage.getItem(reviewKey(view)) || "[]"));
+        } catch (e) {
+            view.reviewed = new Set();
+        }
+    }
 
-    function renderTabs() {
-        const categories = [
-            { id: 0, label: "CREATE", symbol: "+", key: "create" },
-            { id: 1, label: "DESTROY", symbol: "-", key: "destroy" },
-            { id: 2, label: "REPLACE", symbol: "-/+", key: "replace" },
-            { id: 3, label: "UPDATE", symbol: "~", key: "update" },
-            { id: 4, label: "IMPORT", symbol: "", key: "import" }
-        ];
-
-        const container = document.getElementById('tabs-container');
-        container.innerHTML = "";
-
-        categories.forEach(cat => {
-            const count = resourcesByCat[cat.id].length;
-            const el = document.createElement('div');
-            el.className = "tab tab-" + cat.key + (activeTab === cat.id ? " active" : "");
-            el.textContent = cat.label + " (" + cat.symbol + " " + count + ")";
-            el.onclick = () => switchTab(cat.id);
-            container.appendChild(el);
+    function toggleReviewed(view, address) {
+        if (view.reviewed.has(address)) {
+            view.reviewed.delete(address);
+        } else {
+            view.reviewed.add(address);
+        }
+        try {
+            localStorage.setItem(reviewKey(view), JSON.stringify(Array.from(view.reviewed)));
+        } catch (e) {
+            // Storage unavailable (e.g. private browsing): progress lasts for the visit
+        }
+        renderProgress(view);
+    }
+
+    function renderProgress(view) {
+        view.querySelectorAll('.list-group').forEach(group => {
+            const items = Array.from(group.querySelectorAll('.resource-item'));
+            items.forEach(item => {
+                const done = view.reviewed.has(item.dataset.address);
+                item.classList.toggle("reviewed", done);
+                item.querySelector('.review-toggle').checked = done;
+            });
+            const count = items.filter(item => view.reviewed.has(item.dataset.address)).length;
+            const progress = view.querySelector('.tab[data-tab="' + group.dataset.tab + '"] .tab-progress');
+            progress.textContent = items.length > 0 ? " " + count + "/" + items.length : "";
         });
     }
 
-    function switchTab(id) {
-        activeTab = id;
-        selectedResourceIndex = -1;
-        renderTabs();
-        renderList();
-        renderDetail();
+    // --- NAVIGATION ---
+
+    function showIndex() {
+        planViews.forEach(view => view.classList.remove("active"));
+        indexView.classList.add("active");
     }
 
-    function renderList() {
-        const listContainer = document.getElementById('resource-list');
-        listContainer.innerHTML = "";
-        
-        filteredResources = resourcesByCat[activeTab];
-
-        if (filteredResources.length === 0) {
-            const empty = document.createElement('div');
-            empty.className = "empty-state";
-            empty.textContent = "No resources";
-            listContainer.appendChild(empty);
-            return;
-        }
+    function openPlan(view) {
+        if (indexView) indexView.classList.remove("active");
+        planViews.forEach(v => v.classList.toggle("active", v === view));
+    }
 
-        filteredResources.forEach((rc, idx) => {
-            const el = document.createElement('div');
-            el.className = "resource-item" + (selectedResourceIndex === idx ? " selected" : "");
-            el.textContent = rc.address;
-            el.title = rc.address;
-            el.onclick = () => selectResource(idx);
-            listContainer.appendChild(el);
+    function switchTab(view, key) {
+        view.querySelectorAll('.tab[data-tab], .list-group, .page').forEach(el => {
+            el.classList.toggle("active", el.dataset.tab === key);
         });
+        view.classList.toggle("page-open", view.querySelector('.page[data-tab="' + key + '"]') !== null);
+        selectResource(view, null);
+        setCursor(view, visibleItems(view)[0]);
     }
 
-    function selectResource(idx) {
-        selectedResourceIndex = idx;
-        renderList(); // Re-render to update selected class
-        renderDetail();
+    function selectResource(view, address) {
+        view.querySelectorAll('.resource-item').forEach(item => {
+            const selected = item.dataset.address === address;
+            item.classList.toggle("selected", selected);
+            if (selected) setCursor(view, item);
+        });
+        view.querySelectorAll('.resource').forEach(el => {
+            el.classList.toggle("active", el.dataset.address === address);
+        });
+        view.querySelector('.detail-empty').classList.toggle("active", address === null);
     }
 
-    // --- DIFF RENDERING LOGIC (Ported from Go) ---
-    
-    function formatValue(v, indent) {
-        if (v === null || v === undefined) return "null";
-        
-        if (typeof v === 'object' && !Array.isArray(v)) {
-            // Map
-            const keys = Object.keys(v).sort();
-            let sb = "{\n";
-            const padding = " ".repeat(indent + 4);
-            keys.forEach(k => {
-                sb += padding + k + " = " + formatValue(v[k], indent + 4) + "\n";
-            });
-            sb += " ".repeat(indent) + "}";
-            return sb;
-        } else if (Array.isArray(v)) {
-            // List
-            if (v.length === 0) return "[]";
-            let sb = "[\n";
-            const padding = " ".repeat(indent + 4);
-            v.forEach(item => {
-                sb += padding + formatValue(item, indent + 4) + ",\n";
+    // --- SEARCH & FILTERS ---
+    // Applied to every tab at once: tab counts become "matches of total"
+
+    const riskRank = { none: 0, low: 1, medium: 2, high: 3 };
+
+    function matches(view, item, query, inValues, action, minRisk) {
+        if (action && item.dataset.actions !== action) return false;
+        if (riskRank[item.dataset.risk] < minRisk) return false;
+        if (!query) return true;
+
+        const fields = [item.dataset.address, item.dataset.type, item.dataset.module].join(" ").toLowerCase();
+        if (fields.includes(query)) return true;
+        if (!inValues) return false;
+        // Attribute values, searched in the rendered diff
+        const section = view.querySelector('.resource[data-address="' + CSS.escape(item.dataset.address) + '"]');
+        return section !== null && section.textContent.toLowerCase().includes(query);
+    }
+
+    function applyFilters(view) {
+        const query = view.querySelector('.filter-search').value.trim().toLowerCase();
+        const inValues = view.querySelector('.filter-values').checked;
+        const action = view.querySelector('.filter-action').value;
+        const minRisk = Number(view.querySelector('.filter-risk').value);
+        const filtering = query !== "" || action !== "" || minRisk > 0;
+
+        view.querySelectorAll('.list-group').forEach(group => {
+            const items = Array.from(group.querySelectorAll('.resource-item'));
+            let shown = 0;
+            items.forEach(item => {
+                const ok = matches(view, item, query, inValues, action, minRisk);
+                item.classList.toggle("filtered-out", !ok);
+                if (ok) shown++;
             });
-            sb += " ".repeat(indent) + "]";
-            return sb;
-        } else if (typeof v === 'string') {
-            // Unescape newlines to interpret them as actual line breaks in CSS 'white-space: pre'
-            return JSON.stringify(v).replace(/\\n/g, "\n"); 
-        } else {
-            return String(v);
+            const noMatch = group.querySelector('.no-match');
+            if (noMatch) noMatch.classList.toggle("active", shown === 0);
+
+            const count = view.querySelector('.tab[data-tab="' + group.dataset.tab + '"] .tab-count');
+            count.textContent = filtering ? shown + " of " + items.length : items.length;
+        });
+
+        const cursor = view.querySelector('.resource-item.cursor');
+        if (!cursor || cursor.classList.contains("filtered-out")) {
+            setCursor(view, visibleItems(view)[0]);
         }
     }
 
-    function stringifyDiff(key, valBefore, valAfter, unknown, indent, modClass) {
-        let sb = "";
-        const padding = " ".repeat(indent);
-        
-        const isUnknown = (unknown === true); 
-
-        // 1. ADDITION
-        if (valBefore === null && (valAfter !== null || isUnknown)) {
-            let valStr = "(known after apply)";
-            if (!isUnknown) valStr = formatValue(valAfter, indent);
-            // Additions always Green ("diff-add") regardless of parent action
-            return '<div class="diff-line diff-add">' + padding + '+ ' + key + ' = ' + valStr + '</div>';
+    // --- KEYBOARD ---
+    // Mirrors the TUI: Tab/l/Right and Shift+Tab/h/Left switch tabs, j/k/Up/Down move
+    // through the list (or scroll an open resource), Enter opens, Esc goes back,
+    // Space marks as reviewed and / searches.
+
+    function activeView() {
+        return planViews.find(view => view.classList.contains("active")) || null;
+    }
+
+    function visibleItems(view) {
+        return Array.from(view.querySelectorAll('.list-group.active .resource-item:not(.filtered-out)'));
+    }
+
+    function setCursor(view, item) {
+        view.querySelectorAll('.resource-item.cursor').forEach(el => el.classList.remove("cursor"));
+        if (item) {
+            item.classList.add("cursor");
+            item.scrollIntoView({ block: "nearest" });
         }
+    }
 
-        // 2. DELETION
-        if (valBefore !== null && valAfter === null && !isUnknown) {
-            let valStr = formatValue(valBefore, indent);
-            // Deletions always Red ("diff-del")
-            return '<div class="diff-line diff-del">' + padding + '- ' + key + ' = ' + valStr + '</div>';
+    function moveCursor(items, cursorClass, delta) {
+        if (items.length === 0) return null;
+        const idx = items.findIndex(el => el.classList.contains(cursorClass));
+        return items[Math.max(0, Math.min(items.length - 1, idx + delta))];
+    }
+
+    function cycleTab(view, delta) {
+        const tabs = Array.from(view.querySelectorAll('.tab[data-tab]'));
+        const idx = tabs.findIndex(tab => tab.classList.contains("active"));
+        tabs[(idx + delta + tabs.length) % tabs.length].click();
+    }
+
+    function onIndexKey(e) {
+        const rows = Array.from(indexView.querySelectorAll('tbody tr'));
+        let row = null;
+        switch (e.key) {
+            case "ArrowDown": case "j":
+                row = moveCursor(rows, "cursor", 1);
+                break;
+            case "ArrowUp": case "k":
+                row = moveCursor(rows, "cursor", -1);
+                break;
+            case "Enter": {
+                const current = indexView.querySelector('tbody tr.cursor');
+                if (current) current.querySelector('a').click();
+                break;
+            }
+            default:
+                return;
+        }
+        if (row) {
+            rows.forEach(r => r.classList.toggle("cursor", r === row));
+            row.scrollIntoView({ block: "nearest" });
         }
+        e.preventDefault();
+    }
 
-        // 3. MODIFICATION
-        // Check if map
-        const isMapBefore = (valBefore && typeof valBefore === 'object' && !Array.isArray(valBefore));
-        const isMapAfter = (valAfter && typeof valAfter === 'object' && !Array.isArray(valAfter));
-
-        if (isMapBefore && isMapAfter) {
-            sb += '<div class="diff-line ' + modClass + '">' + padding + '~ ' + key + ' = {</div>';
-            
-            const seen = new Set([...Object.keys(valBefore), ...Object.keys(valAfter)]);
-            const keys = Array.from(seen).sort();
-            
-            keys.forEach(k => {
-                 const vB = valBefore.hasOwnProperty(k) ? valBefore[k] : null;
-                 const vA = valAfter.hasOwnProperty(k) ? valAfter[k] : null;
-                 sb += stringifyDiff(k, vB, vA, null, indent + 4, modClass);
-            });
-            
-            sb += '<div class="diff-line ' + modClass + '">' + padding + '}</div>';
-            return sb;
+    function onPlanKey(e, view) {
+        const open = view.querySelector('.resource.active');
+        const detail = view.querySelector('.detail-view');
+        switch (e.key) {
+            case "Tab":
+                cycleTab(view, e.shiftKey ? -1 : 1);
+                break;
+            case "ArrowRight": case "l":
+                cycleTab(view, 1);
+                break;
+            case "ArrowLeft": case "h":
+                cycleTab(view, -1);
+                break;
+            case "ArrowDown": case "j":
+                if (open) {
+                    detail.scrollBy(0, 40);
+                } else {
+                    setCursor(view, moveCursor(visibleItems(view), "cursor", 1));
+                }
+                break;
+            case "ArrowUp": case "k":
+                if (open) {
+                    detail.scrollBy(0, -40);
+                } else {
+                    setCursor(view, moveCursor(visibleItems(view), "cursor", -1));
+                }
+                break;
+            case "Enter": {
+                const item = view.querySelector('.resource-item.cursor');
+                if (item && !open) item.click();
+                break;
+            }
+            case "Escape":
+                if (open) {
+                    view.querySelector('.tab.active').click();
+                } else if (indexView) {
+                    view.querySelector('.tab-back').click();
+                }
+                break;
+            case " ": {
+                const item = view.querySelector('.resource-item.cursor');
+                if (item) toggleReviewed(view, item.dataset.address);
+                break;
+            }
+            case "/":
+                view.querySelector('.filter-search').focus();
+                break;
+            default:
+                return;
         }
+        e.preventDefault();
+    }
 
-        // Scalar Update
-        let sBefore = formatValue(valBefore, indent);
-        let sAfter = "(known after apply)";
-        if (!isUnknown) sAfter = formatValue(valAfter, indent);
+    document.addEventListener("keydown", (e) => {
+        if (e.ctrlKey || e.metaKey || e.altKey) return;
 
-        if (sBefore !== sAfter) {
-             return '<div class="diff-line ' + modClass + '">' + padding + '~ ' + key + ' = ' + sBefore + ' -> ' + sAfter + '</div>';
+        // In the filters, only leave: Enter jumps to the results, Esc gives keys back
+        if (e.target.closest('input, select, textarea')) {
+            if (e.key === "Enter" || e.key === "Escape") {
+                e.target.blur();
+                e.preventDefault();
+            }
+            return;
         }
-        
-        return ""; // Unchanged
+
+        const view = activeView();
+        if (view) {
+            onPlanKey(e, view);
+        } else if (indexView) {
+            onIndexKey(e);
+        }
+    });
+
+    // --- DEPENDENCY GRAPH ---
+    // A click on a resource highlights what it depends on and what its change cascades
+    // to; a double click opens it.
+
+    function focusNode(view, node) {
+        const page = view.querySelector('.graph');
+        const edges = Array.from(page.querySelectorAll('.edge'));
+        const nodes = Array.from(page.querySelectorAll('.node'));
+        const status = page.querySelector('.graph-status');
+        const focused = node && !node.classList.contains("focus");
+
+        nodes.forEach(n => n.classList.remove("focus", "dim"));
+        edges.forEach(e => e.classList.remove("lit", "dim"));
+        status.textContent = "";
+        if (!focused) return;
+
+        // Walk the edges both ways from the node
+        const walk = (from, to) => {
+            const reached = new Set([node.dataset.address]);
+            const queue = [node.dataset.address];
+            while (queue.length > 0) {
+                const cur = queue.shift();
+                edges.forEach(e => {
+                    if (e.dataset[from] === cur && !reached.has(e.dataset[to])) {
+                        reached.add(e.dataset[to]);
+                        queue.push(e.dataset[to]);
+                    }
+                });
+            }
+            reached.delete(node.dataset.address);
+            return reached;
+        };
+        const dependents = walk("from", "to");
+        const dependencies = walk("to", "from");
+        const related = new Set([node.dataset.address, ...dependents, ...dependencies]);
+
+        node.classList.add("focus");
+        nodes.forEach(n => n.classList.toggle("dim", !related.has(n.dataset.address)));
+        edges.forEach(e => {
+            const lit = related.has(e.dataset.from) && related.has(e.dataset.to);
+            e.classList.toggle("lit", lit);
+            e.classList.toggle("dim", !lit);
+        });
+        status.textContent = node.dataset.address + ": " + dependents.size + " changed resources depend on it, it depends on " +
+            dependencies.size;
     }
 
-    function renderDetail() {
-        const view = document.getElementById('detail-view');
-        if (selectedResourceIndex === -1) {
-            view.innerHTML = '<div class="empty-state">Select a resource to view details</div>';
+    // --- ROUTING ---
+    // The URL fragment is the id of what is shown: a tab (#overview, #replace), a resource
+    // (#module.db.aws_rds_cluster.main), a stack (#envs/prod, or #envs/prod:replace...)
+    // or, when empty, the stack index. Tabs and resources are plain links, so every
+    // view has a shareable URL and back/forward walk through the review.
+
+    function route() {
+        let id = "";
+        try {
+            id = decodeURIComponent(location.hash.slice(1));
+        } catch (e) {
+            // Malformed escape: fall back to the default view
+        }
+        const target = id ? document.getElementById(id) : null;
+        const view = target ? target.closest('.plan-view') : null;
+
+        if (!view) {
+            if (indexView) {
+                showIndex();
+            } else {
+                openPlan(planViews[0]);
+                switchTab(planViews[0], "overview");
+            }
             return;
         }
 
-        const rc = filteredResources[selectedResourceIndex];
-        const action = rc.change.actions[0];
-        const isReplace = (rc.change.actions.length > 1 && rc.change.actions[0] === 'delete');
-
-        // Styles
-        let actionClass = "diff-mod"; // Default header style
-        let childModClass = "diff-mod"; // Default style for modified attributes
-        let symbol = "~";
-        let headerText = "# " + rc.type + "." + rc.name + " will be updated in-place";
-        
-        if (isReplace) {
-             actionClass = "diff-rep";
-             childModClass = "diff-rep"; // Use replace color for attributes too
-             symbol = "-/+";
-             headerText = "# " + rc.type + "." + rc.name + " must be replaced";
-        } else if (action === "create") {
-             actionClass = "diff-add";
-             childModClass = "diff-add"; // Creations imply everything new is green
-             symbol = "+";
-             headerText = "# " + rc.type + "." + rc.name + " will be created";
-        } else if (action === "delete") {
-             actionClass = "diff-del";
-             childModClass = "diff-del";
-             symbol = "-";
-             headerText = "# " + rc.type + "." + rc.name + " will be destroyed";
+        openPlan(view);
+        if (target.classList.contains("resource")) {
+            switchTab(view, target.dataset.tab);
+            selectResource(view, target.dataset.address);
+        } else if (target.classList.contains("category-title") || target.classList.contains("page")) {
+            switchTab(view, target.dataset.tab);
+        } else if (target === view) {
+            switchTab(view, "overview");
         }
+    }
+
+    planViews.forEach(view => {
+        loadReviewed(view);
+
+        view.querySelectorAll('.resource-item').forEach(item => {
+            const toggle = document.createElement('input');
+            toggle.type = "checkbox";
+            toggle.className = "review-toggle";
+            toggle.title = "Mark as reviewed";
+            toggle.onclick = (e) => {
+                e.stopPropagation();
+                toggleReviewed(view, item.dataset.address);
+            };
+            item.prepend(toggle);
+        });
 
-        let html = '<div class="diff-header">' + headerText + '</div>';
-        
-        // Resource Block Open
-        html += '<div class="diff-line ' + actionClass + '">  ' + symbol + ' resource "' + rc.type + '" "' + rc.name + '" {</div>';
-        
-        // Attributes
-        const before = rc.change.before || {};
-        const after = rc.change.after || {};
-        const unknown = rc.change.after_unknown || {};
-
-        const seen = new Set([...Object.keys(before), ...Object.keys(after), ...Object.keys(unknown)]);
-        const keys = Array.from(seen).sort().filter(k => k !== 'id');
-
-        keys.forEach(k => {
-             const vB = before.hasOwnProperty(k) ? before[k] : null;
-             const vA = after.hasOwnProperty(k) ? after[k] : null;
-             // Check unknown map. Unmarshal often makes it { key: true }
-             const vU = unknown.hasOwnProperty(k) ? unknown[k] : null;
-             
-             html += stringifyDiff(k, vB, vA, vU, 6, childModClass);
+        view.querySelectorAll('.filters input, .filters select').forEach(input => {
+            input.addEventListener("input", () => applyFilters(view));
         });
 
-        html += '<div class="diff-line">    }</div>';
-        view.innerHTML = html;
+        view.querySelectorAll('.graph .node').forEach(node => {
+            node.addEventListener("click", (e) => {
+                e.preventDefault();
+                focusNode(view, node);
+            });
+            node.addEventListener("dblclick", () => {
+                location.hash = node.getAttribute("href");
+            });
+        });
+        const graphSVG = view.querySelector('.graph svg');
+        if (graphSVG) {
+            graphSVG.addEventListener("click", (e) => {
+                if (!e.target.closest('.node')) focusNode(view, null);
+            });
+        }
+
+        renderProgress(view);
+    });
+
+    if (indexView) {
+        indexView.querySelectorAll('tbody tr').forEach(row => {
+            const link = row.querySelector('a');
+            row.onclick = (e) => {
+                if (e.target !== link) link.click();
+            };
+        });
     }
 
-    // Init
-    renderTabs();
-    renderList();
-    
+    window.addEventListener("hashchange", route);
+    route();
+{{- if .LiveReload}}
+
+    // Reload when tfs serve announces a new plan
+    if (window.EventSource) {
+        const events = new EventSource("/api/events");
+        events.addEventListener("reload", () => window.location.reload());
+    }
+{{- end}}
 </script>
 </body>
-</html>`, string(planJSON))
-
-	return os.WriteFile(outputPath, []byte(html), 0644)
-}
+</html>
+{{- define "bar"}}<svg class="chart" viewBox="0 0 100 10" preserveAspectRatio="none" aria-hidden="true">
+            {{- range .}}<rect class="bar-{{.Key}}" x="{{.X}}" width="{{.Width}}" height="10"></rect>{{end -}}
+        </svg>{{end}}
+`))
@@ -1,9 +1,14 @@
 package web
 
 import (
+	"bytes"
 	"os"
+	"regexp"
 	"strings"
 	"testing"
+
+	"github.com/bernard-sh/tfs/internal/models"
+	"github.com/bernard-sh/tfs/internal/stack"
 )
 
 func TestGenerateHTML(t *testing.T) {
@@ -55,3 +60,403 @@ func TestGenerateHTML(t *testing.T) {
 		t.Errorf("HTML output does not contain plan data")
 	}
 }
+
+func TestRenderStacks(t *testing.T) {
+	stacks := []stack.Stack{
+		{Label: "envs/dev", Plan: models.TfPlan{}},
+		{Label: "envs/prod", Plan: models.TfPlan{ResourceChanges: []models.ResourceChange{
+			{Address: "aws_instance.web", Type: "aws_instance", Name: "web", Change: models.Change{Actions: []string{"create"}}},
+		}}},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderStacks(&buf, stacks, Options{}); err != nil {
+		t.Fatalf("RenderStacks failed: %v", err)
+	}
+
+	htmlStr := buf.String()
+	for _, want := range []string{
+		`<a href="#envs/prod">envs/prod</a>`,
+		`<td>1</td><td>0</td><td>0</td><td>0</td><td>0</td>`,
+		`<section class="resource" id="envs/prod:aws_instance.web"`,
+		"# aws_instance.web will be created",
+	} {
+		if !strings.Contains(htmlStr, want) {
+			t.Errorf("Multi-plan report missing %q", want)
+		}
+	}
+}
+
+func TestRender_ReviewKey(t *testing.T) {
+	plan := models.TfPlan{ResourceChanges: []models.ResourceChange{
+		{Address: "aws_instance.web", Type: "aws_instance", Name: "web", Change: models.Change{Actions: []string{"create"}}},
+	}}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, plan, Options{}); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	htmlStr := buf.String()
+	for _, want := range []string{`data-hash="` + plan.Hash() + `"`, `"tfs-reviewed-" + view.dataset.hash`, "review-toggle"} {
+		if !strings.Contains(htmlStr, want) {
+			t.Errorf("Report missing %q", want)
+		}
+	}
+
+	buf.Reset()
+	if err := RenderStacks(&buf, []stack.Stack{{Label: "prod", Plan: plan}}, Options{}); err != nil {
+		t.Fatalf("RenderStacks failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `<div class="plan-view" id="prod" data-hash="`+plan.Hash()+`">`) {
+		t.Error("Multi-plan report is missing the stack hash")
+	}
+}
+
+func TestRender_EscapesPlanValues(t *testing.T) {
+	const payload = `</script><img src=x onerror=alert(1)>`
+	plan := models.TfPlan{ResourceChanges: []models.ResourceChange{{
+		Address: "aws_instance." + payload,
+		Type:    "aws_instance",
+		Name:    payload,
+		Change: models.Change{
+			Actions: []string{"create"},
+			After:   map[string]interface{}{payload: payload, "tags": map[string]interface{}{"Name": payload}},
+		},
+	}}}
+	stacks := []stack.Stack{{Label: payload, Plan: plan}}
+
+	var single, multi bytes.Buffer
+	if err := Render(&single, plan, Options{LiveReload: true}); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if err := RenderStacks(&multi, stacks, Options{}); err != nil {
+		t.Fatalf("RenderStacks failed: %v", err)
+	}
+
+	for name, htmlStr := range map[string]string{"single": single.String(), "stacks": multi.String()} {
+		if strings.Contains(htmlStr, "<img") || strings.Count(htmlStr, "</script>") != 2 {
+			t.Errorf("%s: plan values reach the page unescaped", name)
+		}
+		if !strings.Contains(htmlStr, `&lt;/script&gt;&lt;img src=x onerror=alert(1)&gt;`) {
+			t.Errorf("%s: escaped plan values are missing", name)
+		}
+		if strings.Contains(htmlStr, "innerHTML") {
+			t.Errorf("%s: report builds markup from strings", name)
+		}
+	}
+}
+
+func TestRender_ContentSecurityPolicy(t *testing.T) {
+	var first, second bytes.Buffer
+	for _, buf := range []*bytes.Buffer{&first, &second} {
+		if err := Render(buf, models.TfPlan{}, Options{LiveReload: true}); err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+	}
+
+	htmlStr := first.String()
+	m := regexp.MustCompile(`script-src 'nonce-([A-Za-z0-9_-]+)'`).FindStringSubmatch(htmlStr)
+	if m == nil {
+		t.Fatal("Report has no Content-Security-Policy with a script nonce")
+	}
+	nonce := m[1]
+	for _, want := range []string{"default-src 'none'", "style-src 'nonce-" + nonce + "'", `<script nonce="` + nonce + `">`, `<style nonce="` + nonce + `">`} {
+		if !strings.Contains(htmlStr, want) {
+			t.Errorf("Report missing %q", want)
+		}
+	}
+	if strings.Contains(htmlStr, "style=") || strings.Contains(htmlStr, "<script>") {
+		t.Error("Report has inline code the policy would block")
+	}
+	if strings.Contains(second.String(), nonce) {
+		t.Error("Nonce is reused across reports")
+	}
+}
+
+func TestRender_Prerendered(t *testing.T) {
+	plan := models.TfPlan{ResourceChanges: []models.ResourceChange{
+		{Address: "aws_instance.web", Type: "aws_instance", Name: "web", Change: models.Change{
+			Actions: []string{"update"},
+			Before:  map[string]interface{}{"instance_type": "t3.micro"},
+			After:   map[string]interface{}{"instance_type": "t3.large"},
+		}},
+		{Address: "aws_s3_bucket.logs", Type: "aws_s3_bucket", Name: "logs", Change: models.Change{Actions: []string{"delete"}}},
+		{Address: "aws_vpc.main", Type: "aws_vpc", Name: "main", Change: models.Change{Actions: []string{"no-op"}}},
+	}}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, plan, Options{}); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	htmlStr := buf.String()
+
+	// Every resource is in the markup, diff included, without running the script
+	for _, want := range []string{
+		`<a class="tab tab-update" href="#update" data-tab="update">UPDATE (~ <span class="tab-count">1</span>)`,
+		`<a class="resource-item" href="#aws_s3_bucket.logs"`,
+		`<h2 class="category-title tab-destroy" id="destroy" data-tab="destroy">DESTROY (1)</h2>`,
+		`<div class="diff-header"># aws_instance.web will be updated in-place</div>`,
+		`<div class="diff-line diff-mod">  ~ instance_type = &#34;t3.micro&#34; -&gt; &#34;t3.large&#34;</div>`,
+		`<div class="diff-header"># aws_s3_bucket.logs will be destroyed</div>`,
+		"@media print",
+	} {
+		if !strings.Contains(htmlStr, want) {
+			t.Errorf("Report missing %q", want)
+		}
+	}
+	if strings.Contains(htmlStr, "aws_vpc.main") {
+		t.Error("No-op resources should not be listed")
+	}
+}
+
+func TestDeepLink(t *testing.T) {
+	tests := []struct {
+		label, address, want string
+	}{
+		{"", "module.db.aws_rds_cluster.main", "https://bucket/report.html?X-Sig=1#module.db.aws_rds_cluster.main"},
+		{"envs/prod", `aws_instance.web["a b"]`, "https://bucket/report.html?X-Sig=1#envs/prod:aws_instance.web%5B%22a%20b%22%5D"},
+	}
+	for _, tt := range tests {
+		if got := DeepLink("https://bucket/report.html?X-Sig=1", tt.label, tt.address); got != tt.want {
+			t.Errorf("DeepLink(%q, %q) = %q, want %q", tt.label, tt.address, got, tt.want)
+		}
+	}
+
+	// The fragment names the rendered resource
+	var buf bytes.Buffer
+	stacks := []stack.Stack{{Label: "envs/prod", Plan: models.TfPlan{ResourceChanges: []models.ResourceChange{
+		{Address: `aws_instance.web["a b"]`, Type: "aws_instance", Name: "web", Change: models.Change{Actions: []string{"create"}}},
+	}}}}
+	if err := RenderStacks(&buf, stacks, Options{}); err != nil {
+		t.Fatalf("RenderStacks failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `id="envs/prod:aws_instance.web[&#34;a b&#34;]"`) {
+		t.Error("Report has no element matching the deep link")
+	}
+}
+
+func TestRender_Filters(t *testing.T) {
+	plan := models.TfPlan{ResourceChanges: []models.ResourceChange{
+		{Address: "module.app.aws_instance.web", Type: "aws_instance", Name: "web", ModuleAddress: "module.app", Change: models.Change{Actions: []string{"delete", "create"}}},
+		{Address: "aws_s3_bucket.logs", Type: "aws_s3_bucket", Name: "logs", Change: models.Change{Actions: []string{"create"}}},
+	}}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, plan, Options{}); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	htmlStr := buf.String()
+	for _, want := range []string{
+		`data-type="aws_instance" data-module="module.app" data-actions="delete, create"`,
+		`<option value="delete, create">delete, create</option>`,
+		`<option value="create">create</option>`,
+		`<option value="3">High</option>`,
+		`<div class="empty-state no-match">No matching resources</div>`,
+		"[/]: Search",
+	} {
+		if !strings.Contains(htmlStr, want) {
+			t.Errorf("Report missing %q", want)
+		}
+	}
+	if strings.Count(htmlStr, `<option value="create">`) != 1 {
+		t.Error("Action filter lists an action twice")
+	}
+}
+
+func TestRender_Overview(t *testing.T) {
+	plan, err := models.ParsePlan(`{
+		"terraform_version": "1.9.5",
+		"timestamp": "2024-05-01T12:30:00+02:00",
+		"resource_changes": [
+			{"address": "aws_s3_bucket.logs", "type": "aws_s3_bucket", "name": "logs", "change": {"actions": ["delete"]}},
+			{"address": "module.app.aws_instance.a", "module_address": "module.app", "type": "aws_instance", "name": "a", "change": {"actions": ["create"]}},
+			{"address": "module.app.aws_instance.b", "module_address": "module.app", "type": "aws_instance", "name": "b", "change": {"actions": ["create"]}}
+		],
+		"resource_drift": [
+			{"address": "aws_instance.legacy", "type": "aws_instance", "name": "legacy", "change": {
+				"actions": ["update"], "before": {"instance_type": "t3.micro"}, "after": {"instance_type": "t3.large"}}}
+		],
+		"output_changes": {
+			"url": {"actions": ["update"], "before": "http://old", "after": "<http://new>"},
+			"password": {"actions": ["create"], "before": null, "after": "hunter2", "after_sensitive": true},
+			"ip": {"actions": ["create"], "before": null, "after": null, "after_unknown": true},
+			"same": {"actions": ["no-op"], "before": 1, "after": 1}
+		}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, plan, Options{Workspace: "prod"}); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	htmlStr := buf.String()
+	for _, want := range []string{
+		`<a class="tab tab-overview" href="#overview" data-tab="overview">OVERVIEW</a>`,
+		"<tr><th>Terraform</th><td>1.9.5</td></tr>",
+		"<tr><th>Planned at</th><td>2024-05-01 10:30:00 UTC</td></tr>",
+		"<tr><th>Workspace</th><td>prod</td></tr>",
+		`<a class="count tab-create" href="#create"><span class="count-value">2</span>CREATE (&#43;)</a>`,
+		`<rect class="bar-create" x="0.00" width="66.67" height="10"></rect><rect class="bar-destroy" x="66.67" width="33.33" height="10"></rect>`,
+		`<td><a href="#aws_s3_bucket.logs">aws_s3_bucket.logs</a></td><td class="tab-destroy">destroy</td><td class="risk-high">high`,
+		`<span class="module-name" title="module.app">module.app</span>`,
+		`<td class="value">&#34;http://old&#34;</td><td class="value">&#34;&lt;http://new&gt;&#34;</td>`,
+		`<td class="value"></td><td class="value">(sensitive value)</td>`,
+		`<td class="value"></td><td class="value">(known after apply)</td>`,
+		`<tr><td>aws_instance.legacy</td><td>update</td><td class="value">instance_type</td></tr>`,
+	} {
+		if !strings.Contains(htmlStr, want) {
+			t.Errorf("Overview missing %q", want)
+		}
+	}
+	if strings.Contains(htmlStr, "hunter2") || strings.Contains(htmlStr, "<td>same</td>") {
+		t.Error("Overview shows a sensitive or unchanged output")
+	}
+
+	// Sections the plan source could not read are not reported as empty
+	plan = models.TfPlan{Meta: models.PlanMeta{Missing: []string{models.SectionOutputChanges, models.SectionResourceDrift}}}
+	buf.Reset()
+	if err := Render(&buf, plan, Options{}); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	htmlStr = buf.String()
+	if strings.Count(htmlStr, `<p class="none">Not available for this plan source</p>`) != 2 ||
+		strings.Contains(htmlStr, "No output changes") || strings.Contains(htmlStr, "No drift detected") {
+		t.Error("Overview should show outputs and drift as not available")
+	}
+}
+
+func TestRender_Graph(t *testing.T) {
+	plan, err := models.ParsePlan(`{
+		"resource_changes": [
+			{"address": "aws_subnet.a", "type": "aws_subnet", "name": "a", "change": {"actions": ["delete", "create"]}},
+			{"address": "aws_instance.web[0]", "type": "aws_instance", "name": "web", "change": {"actions": ["delete", "create"]}},
+			{"address": "aws_network_interface.eni", "type": "aws_network_interface", "name": "eni", "change": {"actions": ["no-op"]}},
+			{"address": "aws_eip.ip", "type": "aws_eip", "name": "ip", "change": {"actions": ["update"]}}
+		],
+		"configuration": {"root_module": {"resources": [
+			{"address": "aws_instance.web", "mode": "managed", "type": "aws_instance", "name": "web",
+				"expressions": {"subnet_id": {"references": ["aws_subnet.a.id", "aws_subnet.a"]}}},
+			{"address": "aws_network_interface.eni", "mode": "managed", "type": "aws_network_interface", "name": "eni",
+				"expressions": {"subnet_id": {"references": ["aws_subnet.a.id", "aws_subnet.a"]}}},
+			{"address": "aws_eip.ip", "mode": "managed", "type": "aws_eip", "name": "ip",
+				"depends_on": ["aws_network_interface.eni"]}
+		]}}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := RenderStacks(&buf, []stack.Stack{{Label: "prod", Plan: plan}}, Options{}); err != nil {
+		t.Fatalf("RenderStacks failed: %v", err)
+	}
+	htmlStr := buf.String()
+	for _, want := range []string{
+		`<a class="tab tab-graph" href="#prod:graph" data-tab="graph">GRAPH</a>`,
+		`<section class="graph page" id="prod:graph" data-tab="graph">`,
+		"1 unchanged resources are hidden, dashed arrows go through them.",
+		`<a class="node node-replace" href="#prod:aws_instance.web%5B0%5D" data-address="aws_instance.web[0]">`,
+		`class="edge" d="M270 23 C310 23 310 61 350 61" marker-end="url(#arrow-` + plan.Hash() + `)" data-from="aws_subnet.a" data-to="aws_instance.web[0]"`,
+		`class="edge indirect"`,
+		`data-from="aws_subnet.a" data-to="aws_eip.ip"`,
+	} {
+		if !strings.Contains(htmlStr, want) {
+			t.Errorf("Graph missing %q", want)
+		}
+	}
+	if strings.Contains(htmlStr, `data-address="aws_network_interface.eni">`) {
+		t.Error("Graph shows an unchanged resource")
+	}
+}
+
+func TestRender_BlastRadius(t *testing.T) {
+	plan := models.TfPlan{
+		ResourceChanges: []models.ResourceChange{
+			{Address: "aws_subnet.a", Type: "aws_subnet", Name: "a", Change: models.Change{Actions: []string{"delete"}}},
+			{Address: "aws_instance.web", Type: "aws_instance", Name: "web", Change: models.Change{Actions: []string{"no-op"}}},
+		},
+		Configuration: models.Configuration{RootModule: models.ConfigModule{Resources: []models.ConfigResource{
+			{Address: "aws_instance.web", Type: "aws_instance", Name: "web", DependsOn: []string{"aws_subnet.a"}},
+		}}},
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, plan, Options{}); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	want := `<div class="blast">
+                    <div class="diff-header"># Blast radius: 1 dependents, 0 replaced, destroyed or made unknown</div>
+                    <div class="diff-line">      aws_instance.web (unchanged)</div>`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("Report missing the blast radius of aws_subnet.a")
+	}
+}
+
+func TestRender_Source(t *testing.T) {
+	plan := models.TfPlan{
+		ResourceChanges: []models.ResourceChange{
+			{Address: "module.app.aws_instance.web", ModuleAddress: "module.app", Mode: "managed", Type: "aws_instance", Name: "web", Change: models.Change{Actions: []string{"create"}}},
+		},
+		Configuration: models.Configuration{RootModule: models.ConfigModule{ModuleCalls: map[string]models.ModuleCall{
+			"app": {Source: "./modules/app", Module: models.ConfigModule{Resources: []models.ConfigResource{
+				{Address: "aws_instance.web", Mode: "managed", Type: "aws_instance", Name: "web", Expressions: map[string]interface{}{
+					"subnet_id": map[string]interface{}{"references": []interface{}{"var.subnet_id"}},
+				}},
+			}}},
+		}}},
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, plan, Options{}); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	want := `<div class="source">
+                    <div class="diff-header"># Configuration</div>
+                    <div class="diff-line">  module module.app (./modules/app)</div>
+                    <div class="diff-line">  subnet_id = var.subnet_id</div>`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("Report missing the source of module.app.aws_instance.web")
+	}
+}
+
+func TestRender_Metadata(t *testing.T) {
+	plan, err := models.ParsePlan(`{
+		"format_version": "1.2",
+		"terraform_version": "1.9.5",
+		"errored": true,
+		"variables": {"env": {"value": "prod"}, "token": {"value": "secret"}},
+		"resource_changes": [
+			{"address": "aws_instance.web", "type": "aws_instance", "name": "web", "change": {"actions": ["create"]}}
+		],
+		"configuration": {
+			"provider_config": {"aws": {"name": "aws", "full_name": "registry.terraform.io/hashicorp/aws", "version_constraint": "~> 5.0"}},
+			"root_module": {"variables": {"token": {"sensitive": true}}}
+		}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, plan, Options{Workspace: "prod"}); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	htmlStr := buf.String()
+	for _, want := range []string{
+		`<div class="plan-meta">Terraform 1.9.5 · workspace prod · 2 variables <span class="plan-warning">&#9888; Plan errored</span></div>`,
+		"<tr><th>Format version</th><td>1.2</td></tr>",
+		`<tr><th>Status</th><td class="plan-warning">errored</td></tr>`,
+		`<tr><th>env</th><td class="value">&#34;prod&#34;</td></tr>`,
+		`<tr><th>token</th><td class="value">(sensitive value)</td></tr>`,
+		`<tr><td>registry.terraform.io/hashicorp/aws</td><td>unknown</td><td class="value">~&gt; 5.0</td></tr>`,
+	} {
+		if !strings.Contains(htmlStr, want) {
+			t.Errorf("Report missing %q", want)
+		}
+	}
+	if strings.Contains(htmlStr, "secret") {
+		t.Errorf("Report shows the value of a sensitive variable")
+	}
+}